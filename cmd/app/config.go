@@ -0,0 +1,171 @@
+package main
+
+import (
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/cmd/app/flags"
+	thanosnotif "github.com/tokamak-network/tokamak-thanos-event-listener/internal/app/thanos-notif"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+// loadConfig builds the config from the flag defaults, then the config file,
+// then the flags and environment variables set explicitly. The flags configure
+// the default network.
+func loadConfig(ctx *cli.Context) (*thanosnotif.Config, error) {
+	config := &thanosnotif.Config{}
+
+	applyFlags(ctx, config, func(string) bool { return true })
+
+	if path := ctx.String(flags.ConfigFlagName); path != "" {
+		// the default chains are dropped when the file only lists networks
+		defaultChains := config.Chains
+		config.Chains = nil
+
+		if err := thanosnotif.LoadConfigFile(path, config); err != nil {
+			return nil, err
+		}
+
+		if len(config.Chains) == 0 && len(config.Networks) == 0 {
+			config.Chains = defaultChains
+		}
+	}
+
+	applyFlags(ctx, config, ctx.IsSet)
+
+	return config, nil
+}
+
+// applyFlags copies the flags for which isSet returns true to the config.
+func applyFlags(ctx *cli.Context, config *thanosnotif.Config, isSet func(name string) bool) {
+	setString := func(name string, dst *string) {
+		if isSet(name) {
+			*dst = ctx.String(name)
+		}
+	}
+	setStrings := func(name string, dst *[]string) {
+		if isSet(name) {
+			*dst = ctx.StringSlice(name)
+		}
+	}
+	setInt := func(name string, dst *int) {
+		if isSet(name) {
+			*dst = ctx.Int(name)
+		}
+	}
+	setDuration := func(name string, dst *time.Duration) {
+		if isSet(name) {
+			*dst = ctx.Duration(name)
+		}
+	}
+
+	setString(flags.NetworkFlagName, &config.Network)
+
+	// the single L1/L2 pair flags configure the first chain of each layer
+	if anySet(isSet, flags.L1WsRpcUrlFlagName, flags.L1HttpRpcUrlFlagName, flags.L1ExplorerUrlFlagName, flags.L1TokenAddresses) {
+		l1 := config.LayerChain(types.LayerL1)
+		setString(flags.L1WsRpcUrlFlagName, &l1.WsRpc)
+		setString(flags.L1HttpRpcUrlFlagName, &l1.HttpRpc)
+		setString(flags.L1ExplorerUrlFlagName, &l1.ExplorerUrl)
+		setStrings(flags.L1TokenAddresses, &l1.Tokens)
+	}
+
+	if anySet(isSet, flags.L2WsRpcUrlFlagName, flags.L2HttpRpcUrlFlagName, flags.L2ExplorerUrlFlagName, flags.L2TokenAddresses,
+		flags.L1StandardBridgeFlagName, flags.L2StandardBridgeFlagName, flags.L1UsdcBridgeFlagName, flags.L2UsdcBridgeFlagName) {
+		l2 := config.LayerChain(types.LayerL2)
+		setString(flags.L2WsRpcUrlFlagName, &l2.WsRpc)
+		setString(flags.L2HttpRpcUrlFlagName, &l2.HttpRpc)
+		setString(flags.L2ExplorerUrlFlagName, &l2.ExplorerUrl)
+		setStrings(flags.L2TokenAddresses, &l2.Tokens)
+
+		setString(flags.L1StandardBridgeFlagName, &l2.Bridges.L1Standard)
+		setString(flags.L2StandardBridgeFlagName, &l2.Bridges.L2Standard)
+		setString(flags.L1UsdcBridgeFlagName, &l2.Bridges.L1Usdc)
+		setString(flags.L2UsdcBridgeFlagName, &l2.Bridges.L2Usdc)
+	}
+
+	setStrings(flags.TokenListsFlagName, &config.TokenLists)
+
+	setString(flags.CatchUpPolicyFlagName, &config.CatchUp.Policy)
+	setDuration(flags.CatchUpFreshnessFlagName, &config.CatchUp.Freshness)
+
+	if isSet(flags.SlackUrlFlagName) && ctx.String(flags.SlackUrlFlagName) != "" {
+		config.SetSlackURL(ctx.String(flags.SlackUrlFlagName))
+	}
+
+	if isSet(flags.SlackBotTokenFlagName) && ctx.String(flags.SlackBotTokenFlagName) != "" {
+		config.SetSlackBot(ctx.String(flags.SlackBotTokenFlagName), ctx.String(flags.SlackChannelFlagName))
+	}
+
+	redisConfig := &config.RedisConfig
+	setString(flags.RedisModeFlagName, &redisConfig.Mode)
+	setString(flags.RedisAddressFlagName, &redisConfig.Addresses)
+	setString(flags.RedisUsernameFlagName, &redisConfig.Username)
+	setString(flags.RedisPasswordFlagName, &redisConfig.Password)
+	setString(flags.RedisMasterNameFlagName, &redisConfig.MasterName)
+	setInt(flags.RedisDBFlagName, &redisConfig.DB)
+	setString(flags.RedisSentinelUsernameFlagName, &redisConfig.SentinelUsername)
+	setString(flags.RedisSentinelPasswordFlagName, &redisConfig.SentinelPassword)
+	if isSet(flags.RedisTLSEnabledFlagName) {
+		redisConfig.TLS.Enabled = ctx.Bool(flags.RedisTLSEnabledFlagName)
+	}
+	setString(flags.RedisTLSCAFileFlagName, &redisConfig.TLS.CAFile)
+	setString(flags.RedisTLSCertFileFlagName, &redisConfig.TLS.CertFile)
+	setString(flags.RedisTLSKeyFileFlagName, &redisConfig.TLS.KeyFile)
+	setString(flags.RedisTLSServerNameFlagName, &redisConfig.TLS.ServerName)
+	if isSet(flags.RedisTLSInsecureSkipVerifyFlagName) {
+		redisConfig.TLS.InsecureSkipVerify = ctx.Bool(flags.RedisTLSInsecureSkipVerifyFlagName)
+	}
+	setDuration(flags.RedisDialTimeoutFlagName, &redisConfig.DialTimeout)
+	setDuration(flags.RedisReadTimeoutFlagName, &redisConfig.ReadTimeout)
+	setDuration(flags.RedisWriteTimeoutFlagName, &redisConfig.WriteTimeout)
+	setInt(flags.RedisPoolSizeFlagName, &redisConfig.PoolSize)
+	setInt(flags.RedisMinIdleConnsFlagName, &redisConfig.MinIdleConns)
+
+	setString(flags.StorageTypeFlagName, &config.StorageConfig.Type)
+	setString(flags.StorageFileDirFlagName, &config.StorageConfig.FileDir)
+	setString(flags.StoragePostgresDSNFlagName, &config.StorageConfig.PostgresDSN)
+
+	setString(flags.ArchiveDBDriverFlagName, &config.ArchiveConfig.Database.Driver)
+	setString(flags.ArchiveDBDSNFlagName, &config.ArchiveConfig.Database.DSN)
+	setString(flags.ArchiveHTTPAddrFlagName, &config.ArchiveConfig.HTTPAddr)
+
+	setString(flags.PublisherRedisStreamFlagName, &config.PublisherConfig.RedisStream)
+	if isSet(flags.PublisherRedisStreamMaxLenFlagName) {
+		config.PublisherConfig.RedisStreamMaxLen = ctx.Int64(flags.PublisherRedisStreamMaxLenFlagName)
+	}
+	setStrings(flags.PublisherKafkaBrokersFlagName, &config.PublisherConfig.KafkaBrokers)
+	setString(flags.PublisherKafkaTopicFlagName, &config.PublisherConfig.KafkaTopic)
+
+	setString(flags.StreamGRPCAddrFlagName, &config.StreamConfig.GRPCAddr)
+	setString(flags.StreamWebSocketAddrFlagName, &config.StreamConfig.WebSocketAddr)
+	setInt(flags.StreamBufferSizeFlagName, &config.StreamConfig.BufferSize)
+
+	setDuration(flags.SupervisorMinBackoffFlagName, &config.SupervisorConfig.MinBackoff)
+	setDuration(flags.SupervisorMaxBackoffFlagName, &config.SupervisorConfig.MaxBackoff)
+	setInt(flags.SupervisorAlertAfterFlagName, &config.SupervisorConfig.AlertAfter)
+	setDuration(flags.SupervisorResetAfterFlagName, &config.SupervisorConfig.ResetAfter)
+	setString(flags.SupervisorHTTPAddrFlagName, &config.SupervisorConfig.HTTPAddr)
+
+	setString(flags.AdminHTTPAddrFlagName, &config.AdminConfig.HTTPAddr)
+	setString(flags.AdminTokenFlagName, &config.AdminConfig.Token)
+
+	setString(flags.PricesFileFlagName, &config.PricesConfig.File)
+	setString(flags.PricesHTTPURLFlagName, &config.PricesConfig.HTTP.URL)
+	setDuration(flags.PricesHTTPTTLFlagName, &config.PricesConfig.HTTP.TTL)
+
+	setStrings(flags.AddressBookFilesFlagName, &config.AddressBook.Files)
+
+	setStrings(flags.DigestPeriodsFlagName, &config.DigestConfig.Periods)
+}
+
+func anySet(isSet func(name string) bool, names ...string) bool {
+	for _, name := range names {
+		if isSet(name) {
+			return true
+		}
+	}
+
+	return false
+}
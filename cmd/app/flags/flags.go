@@ -1,30 +1,95 @@
 package flags
 
 import (
-	"github.com/ethereum-optimism/optimism/op-bindings/predeploys"
+	"github.com/tokamak-network/tokamak-thanos/op-bindings/predeploys"
 	"github.com/urfave/cli/v2"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/price"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/publisher"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/repository"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/stream"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/supervisor"
 )
 
 const (
-	NetworkFlagName          = "network"
-	L1HttpRpcUrlFlagName     = "l1-http-rpc-url"
-	L1WsRpcUrlFlagName       = "l1-ws-rpc"
-	L2WsRpcUrlFlagName       = "l2-ws-rpc"
-	L2HttpRpcUrlFlagName     = "l2-http-rpc"
-	L1StandardBridgeFlagName = "l1-standard-bridge-address"
-	L2StandardBridgeFlagName = "l2-standard-bridge-address"
-	L1UsdcBridgeFlagName     = "l1-usdc-bridge-address"
-	L2UsdcBridgeFlagName     = "l2-usdc-bridge-address"
-	SlackUrlFlagName         = "slack-url"
-	L1ExplorerUrlFlagName    = "l1-explorer-url"
-	L2ExplorerUrlFlagName    = "l2-explorer-url"
-	L1TokenAddresses         = "l1-token-addresses"
-	L2TokenAddresses         = "l2-token-addresses"
-	RedisAddressFlagName     = "redis-address"
-	RedisDBFlagName          = "redis-db"
+	ConfigFlagName                     = "config"
+	ConfigWatchFlagName                = "config-watch"
+	NetworkFlagName                    = "network"
+	L1HttpRpcUrlFlagName               = "l1-http-rpc-url"
+	L1WsRpcUrlFlagName                 = "l1-ws-rpc"
+	L2WsRpcUrlFlagName                 = "l2-ws-rpc"
+	L2HttpRpcUrlFlagName               = "l2-http-rpc"
+	L1StandardBridgeFlagName           = "l1-standard-bridge-address"
+	L2StandardBridgeFlagName           = "l2-standard-bridge-address"
+	L1UsdcBridgeFlagName               = "l1-usdc-bridge-address"
+	L2UsdcBridgeFlagName               = "l2-usdc-bridge-address"
+	SlackUrlFlagName                   = "slack-url"
+	SlackBotTokenFlagName              = "slack-bot-token"
+	SlackChannelFlagName               = "slack-channel"
+	L1ExplorerUrlFlagName              = "l1-explorer-url"
+	L2ExplorerUrlFlagName              = "l2-explorer-url"
+	L1TokenAddresses                   = "l1-token-addresses"
+	L2TokenAddresses                   = "l2-token-addresses"
+	TokenListsFlagName                 = "token-lists"
+	RedisAddressFlagName               = "redis-address"
+	RedisDBFlagName                    = "redis-db"
+	RedisModeFlagName                  = "redis-mode"
+	RedisUsernameFlagName              = "redis-username"
+	RedisPasswordFlagName              = "redis-password"
+	RedisMasterNameFlagName            = "redis-master-name"
+	RedisSentinelUsernameFlagName      = "redis-sentinel-username"
+	RedisSentinelPasswordFlagName      = "redis-sentinel-password"
+	RedisTLSEnabledFlagName            = "redis-tls-enabled"
+	RedisTLSCAFileFlagName             = "redis-tls-ca-file"
+	RedisTLSCertFileFlagName           = "redis-tls-cert-file"
+	RedisTLSKeyFileFlagName            = "redis-tls-key-file"
+	RedisTLSServerNameFlagName         = "redis-tls-server-name"
+	RedisTLSInsecureSkipVerifyFlagName = "redis-tls-insecure-skip-verify"
+	RedisDialTimeoutFlagName           = "redis-dial-timeout"
+	RedisReadTimeoutFlagName           = "redis-read-timeout"
+	RedisWriteTimeoutFlagName          = "redis-write-timeout"
+	RedisPoolSizeFlagName              = "redis-pool-size"
+	RedisMinIdleConnsFlagName          = "redis-min-idle-conns"
+	StorageTypeFlagName                = "storage-type"
+	StorageFileDirFlagName             = "storage-file-dir"
+	StoragePostgresDSNFlagName         = "storage-postgres-dsn"
+	ArchiveDBDriverFlagName            = "archive-db-driver"
+	ArchiveDBDSNFlagName               = "archive-db-dsn"
+	ArchiveHTTPAddrFlagName            = "archive-http-addr"
+	PublisherRedisStreamFlagName       = "publisher-redis-stream"
+	PublisherRedisStreamMaxLenFlagName = "publisher-redis-stream-max-len"
+	PublisherKafkaBrokersFlagName      = "publisher-kafka-brokers"
+	PublisherKafkaTopicFlagName        = "publisher-kafka-topic"
+	StreamGRPCAddrFlagName             = "stream-grpc-addr"
+	StreamWebSocketAddrFlagName        = "stream-websocket-addr"
+	StreamBufferSizeFlagName           = "stream-buffer-size"
+	SupervisorMinBackoffFlagName       = "supervisor-min-backoff"
+	SupervisorMaxBackoffFlagName       = "supervisor-max-backoff"
+	SupervisorAlertAfterFlagName       = "supervisor-alert-after"
+	SupervisorResetAfterFlagName       = "supervisor-reset-after"
+	SupervisorHTTPAddrFlagName         = "supervisor-http-addr"
+	AdminHTTPAddrFlagName              = "admin-http-addr"
+	AdminTokenFlagName                 = "admin-token"
+	PricesFileFlagName                 = "prices-file"
+	PricesHTTPURLFlagName              = "prices-http-url"
+	PricesHTTPTTLFlagName              = "prices-http-ttl"
+	AddressBookFilesFlagName           = "address-book-files"
+	DigestPeriodsFlagName              = "digest-periods"
+	CatchUpPolicyFlagName              = "catch-up-policy"
+	CatchUpFreshnessFlagName           = "catch-up-freshness"
 )
 
 var (
+	ConfigFlag = &cli.StringFlag{
+		Name:    ConfigFlagName,
+		Usage:   "YAML or TOML config file. Flags and environment variables override its values",
+		EnvVars: []string{"CONFIG_FILE"},
+	}
+	ConfigWatchFlag = &cli.BoolFlag{
+		Name:    ConfigWatchFlagName,
+		Usage:   "Reload the config file when it changes. SIGHUP always reloads it",
+		EnvVars: []string{"CONFIG_WATCH"},
+	}
 	NetworkFlag = &cli.StringFlag{
 		Name:    NetworkFlagName,
 		Usage:   "Network name",
@@ -80,6 +145,16 @@ var (
 		Usage:   "slack url for notification",
 		EnvVars: []string{"SLACK_URL"},
 	}
+	SlackBotTokenFlag = &cli.StringFlag{
+		Name:    SlackBotTokenFlagName,
+		Usage:   "Slack bot token posting the notifications with chat.postMessage, threading the follow-ups of a transfer",
+		EnvVars: []string{"SLACK_BOT_TOKEN"},
+	}
+	SlackChannelFlag = &cli.StringFlag{
+		Name:    SlackChannelFlagName,
+		Usage:   "Slack channel of the bot token",
+		EnvVars: []string{"SLACK_CHANNEL"},
+	}
 	L1ExplorerUrlFlag = &cli.StringFlag{
 		Name:    L1ExplorerUrlFlagName,
 		Usage:   "L1 explorer url",
@@ -100,6 +175,11 @@ var (
 		Usage:   "List of L2 tokens address to get symbol and decimals",
 		EnvVars: []string{"L2_TOKEN_ADDRESSES"},
 	}
+	TokenListsFlag = &cli.StringSliceFlag{
+		Name:    TokenListsFlagName,
+		Usage:   "Files or URLs of Uniswap format token lists, e.g. the Optimism token list, to resolve the token symbols and decimals",
+		EnvVars: []string{"TOKEN_LISTS"},
+	}
 	RedisAddressFlag = &cli.StringFlag{
 		Name: RedisAddressFlagName,
 		EnvVars: []string{
@@ -112,10 +192,240 @@ var (
 			"REDIS_DB",
 		},
 	}
+	RedisModeFlag = &cli.StringFlag{
+		Name:    RedisModeFlagName,
+		Usage:   "Redis deployment (standalone, sentinel, cluster). Inferred from the addresses when empty",
+		EnvVars: []string{"REDIS_MODE"},
+	}
+	RedisUsernameFlag = &cli.StringFlag{
+		Name:    RedisUsernameFlagName,
+		Usage:   "Redis ACL username",
+		EnvVars: []string{"REDIS_USERNAME"},
+	}
+	RedisPasswordFlag = &cli.StringFlag{
+		Name:    RedisPasswordFlagName,
+		Usage:   "Redis password",
+		EnvVars: []string{"REDIS_PASSWORD"},
+	}
+	RedisMasterNameFlag = &cli.StringFlag{
+		Name:    RedisMasterNameFlagName,
+		Usage:   "Redis master name monitored by the sentinels",
+		EnvVars: []string{"REDIS_MASTER_NAME"},
+	}
+	RedisSentinelUsernameFlag = &cli.StringFlag{
+		Name:    RedisSentinelUsernameFlagName,
+		Usage:   "Username of the redis sentinels",
+		EnvVars: []string{"REDIS_SENTINEL_USERNAME"},
+	}
+	RedisSentinelPasswordFlag = &cli.StringFlag{
+		Name:    RedisSentinelPasswordFlagName,
+		Usage:   "Password of the redis sentinels",
+		EnvVars: []string{"REDIS_SENTINEL_PASSWORD"},
+	}
+	RedisTLSEnabledFlag = &cli.BoolFlag{
+		Name:    RedisTLSEnabledFlagName,
+		Usage:   "Connect to redis over TLS",
+		EnvVars: []string{"REDIS_TLS_ENABLED"},
+	}
+	RedisTLSCAFileFlag = &cli.StringFlag{
+		Name:    RedisTLSCAFileFlagName,
+		Usage:   "CA certificate to verify the redis server. The system pool is used when empty",
+		EnvVars: []string{"REDIS_TLS_CA_FILE"},
+	}
+	RedisTLSCertFileFlag = &cli.StringFlag{
+		Name:    RedisTLSCertFileFlagName,
+		Usage:   "Client certificate for redis mutual TLS",
+		EnvVars: []string{"REDIS_TLS_CERT_FILE"},
+	}
+	RedisTLSKeyFileFlag = &cli.StringFlag{
+		Name:    RedisTLSKeyFileFlagName,
+		Usage:   "Client key for redis mutual TLS",
+		EnvVars: []string{"REDIS_TLS_KEY_FILE"},
+	}
+	RedisTLSServerNameFlag = &cli.StringFlag{
+		Name:    RedisTLSServerNameFlagName,
+		Usage:   "Server name to verify the redis certificate against",
+		EnvVars: []string{"REDIS_TLS_SERVER_NAME"},
+	}
+	RedisTLSInsecureSkipVerifyFlag = &cli.BoolFlag{
+		Name:    RedisTLSInsecureSkipVerifyFlagName,
+		Usage:   "Skip the redis certificate verification",
+		EnvVars: []string{"REDIS_TLS_INSECURE_SKIP_VERIFY"},
+	}
+	RedisDialTimeoutFlag = &cli.DurationFlag{
+		Name:    RedisDialTimeoutFlagName,
+		Usage:   "Timeout to connect to redis, the client default is used when zero",
+		EnvVars: []string{"REDIS_DIAL_TIMEOUT"},
+	}
+	RedisReadTimeoutFlag = &cli.DurationFlag{
+		Name:    RedisReadTimeoutFlagName,
+		Usage:   "Timeout of the redis reads, the client default is used when zero",
+		EnvVars: []string{"REDIS_READ_TIMEOUT"},
+	}
+	RedisWriteTimeoutFlag = &cli.DurationFlag{
+		Name:    RedisWriteTimeoutFlagName,
+		Usage:   "Timeout of the redis writes, the client default is used when zero",
+		EnvVars: []string{"REDIS_WRITE_TIMEOUT"},
+	}
+	RedisPoolSizeFlag = &cli.IntFlag{
+		Name:    RedisPoolSizeFlagName,
+		Usage:   "Maximum number of redis connections, the client default is used when zero",
+		EnvVars: []string{"REDIS_POOL_SIZE"},
+	}
+	RedisMinIdleConnsFlag = &cli.IntFlag{
+		Name:    RedisMinIdleConnsFlagName,
+		Usage:   "Minimum number of idle redis connections",
+		EnvVars: []string{"REDIS_MIN_IDLE_CONNS"},
+	}
+	StorageTypeFlag = &cli.StringFlag{
+		Name:    StorageTypeFlagName,
+		Usage:   "Storage backend of the sync block metadata (redis, file, postgres)",
+		Value:   repository.StorageTypeRedis,
+		EnvVars: []string{"STORAGE_TYPE"},
+	}
+	StorageFileDirFlag = &cli.StringFlag{
+		Name:    StorageFileDirFlagName,
+		Usage:   "Directory of the file storage backend",
+		EnvVars: []string{"STORAGE_FILE_DIR"},
+	}
+	StoragePostgresDSNFlag = &cli.StringFlag{
+		Name:    StoragePostgresDSNFlagName,
+		Usage:   "PostgreSQL DSN of the postgres storage backend",
+		EnvVars: []string{"STORAGE_POSTGRES_DSN"},
+	}
+	ArchiveDBDriverFlag = &cli.StringFlag{
+		Name:    ArchiveDBDriverFlagName,
+		Usage:   "Database driver of the event archive (postgres, sqlite). The archive is disabled when empty",
+		EnvVars: []string{"ARCHIVE_DB_DRIVER"},
+	}
+	ArchiveDBDSNFlag = &cli.StringFlag{
+		Name:    ArchiveDBDSNFlagName,
+		Usage:   "Database DSN of the event archive",
+		EnvVars: []string{"ARCHIVE_DB_DSN"},
+	}
+	ArchiveHTTPAddrFlag = &cli.StringFlag{
+		Name:    ArchiveHTTPAddrFlagName,
+		Usage:   "Listen address of the archive query API, e.g. :8080. The API is disabled when empty",
+		EnvVars: []string{"ARCHIVE_HTTP_ADDR"},
+	}
+	PublisherRedisStreamFlag = &cli.StringFlag{
+		Name:    PublisherRedisStreamFlagName,
+		Usage:   "Redis Stream to publish the decoded events to. Publishing is disabled when empty",
+		EnvVars: []string{"PUBLISHER_REDIS_STREAM"},
+	}
+	PublisherRedisStreamMaxLenFlag = &cli.Int64Flag{
+		Name:    PublisherRedisStreamMaxLenFlagName,
+		Usage:   "Approximate maximum length of the Redis Stream",
+		Value:   publisher.DefaultRedisStreamMaxLen,
+		EnvVars: []string{"PUBLISHER_REDIS_STREAM_MAX_LEN"},
+	}
+	PublisherKafkaBrokersFlag = &cli.StringSliceFlag{
+		Name:    PublisherKafkaBrokersFlagName,
+		Usage:   "List of Kafka brokers",
+		EnvVars: []string{"PUBLISHER_KAFKA_BROKERS"},
+	}
+	PublisherKafkaTopicFlag = &cli.StringFlag{
+		Name:    PublisherKafkaTopicFlagName,
+		Usage:   "Kafka topic to publish the decoded events to. Publishing is disabled when empty",
+		EnvVars: []string{"PUBLISHER_KAFKA_TOPIC"},
+	}
+	StreamGRPCAddrFlag = &cli.StringFlag{
+		Name:    StreamGRPCAddrFlagName,
+		Usage:   "Listen address of the gRPC event stream, e.g. :9090. Disabled when empty",
+		EnvVars: []string{"STREAM_GRPC_ADDR"},
+	}
+	StreamWebSocketAddrFlag = &cli.StringFlag{
+		Name:    StreamWebSocketAddrFlagName,
+		Usage:   "Listen address of the websocket event stream, e.g. :8081. Disabled when empty",
+		EnvVars: []string{"STREAM_WEBSOCKET_ADDR"},
+	}
+	StreamBufferSizeFlag = &cli.IntFlag{
+		Name:    StreamBufferSizeFlagName,
+		Usage:   "Number of the latest events kept to resume the stream from a cursor",
+		Value:   stream.DefaultBufferSize,
+		EnvVars: []string{"STREAM_BUFFER_SIZE"},
+	}
+	SupervisorMinBackoffFlag = &cli.DurationFlag{
+		Name:    SupervisorMinBackoffFlagName,
+		Usage:   "Delay before restarting a failed listener, doubled after each consecutive failure",
+		Value:   supervisor.DefaultMinBackoff,
+		EnvVars: []string{"SUPERVISOR_MIN_BACKOFF"},
+	}
+	SupervisorMaxBackoffFlag = &cli.DurationFlag{
+		Name:    SupervisorMaxBackoffFlagName,
+		Usage:   "Maximum delay before restarting a failed listener",
+		Value:   supervisor.DefaultMaxBackoff,
+		EnvVars: []string{"SUPERVISOR_MAX_BACKOFF"},
+	}
+	SupervisorAlertAfterFlag = &cli.IntFlag{
+		Name:    SupervisorAlertAfterFlagName,
+		Usage:   "Number of consecutive listener failures sending an alert",
+		Value:   supervisor.DefaultAlertAfter,
+		EnvVars: []string{"SUPERVISOR_ALERT_AFTER"},
+	}
+	SupervisorResetAfterFlag = &cli.DurationFlag{
+		Name:    SupervisorResetAfterFlagName,
+		Usage:   "Run duration after which a listener failure is not consecutive to the previous one",
+		Value:   supervisor.DefaultResetAfter,
+		EnvVars: []string{"SUPERVISOR_RESET_AFTER"},
+	}
+	SupervisorHTTPAddrFlag = &cli.StringFlag{
+		Name:    SupervisorHTTPAddrFlagName,
+		Usage:   "Listen address of the listener restart counts API, e.g. :8082. Disabled when empty",
+		EnvVars: []string{"SUPERVISOR_HTTP_ADDR"},
+	}
+	AdminHTTPAddrFlag = &cli.StringFlag{
+		Name:    AdminHTTPAddrFlagName,
+		Usage:   "Listen address of the admin API, e.g. :8083. Disabled when empty",
+		EnvVars: []string{"ADMIN_HTTP_ADDR"},
+	}
+	AdminTokenFlag = &cli.StringFlag{
+		Name:    AdminTokenFlagName,
+		Usage:   "Bearer token authenticating the admin API requests",
+		EnvVars: []string{"ADMIN_TOKEN"},
+	}
+	PricesFileFlag = &cli.StringFlag{
+		Name:    PricesFileFlagName,
+		Usage:   "JSON file of the USD prices of the assets, e.g. updated by a cron job",
+		EnvVars: []string{"PRICES_FILE"},
+	}
+	PricesHTTPURLFlag = &cli.StringFlag{
+		Name:    PricesHTTPURLFlagName,
+		Usage:   "URL of the USD price API, e.g. the CoinGecko simple price API. Disabled when empty",
+		EnvVars: []string{"PRICES_HTTP_URL"},
+	}
+	PricesHTTPTTLFlag = &cli.DurationFlag{
+		Name:    PricesHTTPTTLFlagName,
+		Usage:   "Duration the prices of the price API are cached",
+		Value:   price.DefaultHTTPTTL,
+		EnvVars: []string{"PRICES_HTTP_TTL"},
+	}
+	AddressBookFilesFlag = &cli.StringSliceFlag{
+		Name:    AddressBookFilesFlagName,
+		Usage:   "CSV files of the address labels and watchlist tags, with the address, label and tags columns",
+		EnvVars: []string{"ADDRESS_BOOK_FILES"},
+	}
+	DigestPeriodsFlag = &cli.StringSliceFlag{
+		Name:    DigestPeriodsFlagName,
+		Usage:   "Periods of the bridge event digests: hourly, daily or weekly. Disabled when empty",
+		EnvVars: []string{"DIGEST_PERIODS"},
+	}
+	CatchUpPolicyFlag = &cli.StringFlag{
+		Name:    CatchUpPolicyFlagName,
+		Usage:   "Notifications of the blocks older than the catch-up freshness: notify, suppress or summary",
+		EnvVars: []string{"CATCH_UP_POLICY"},
+	}
+	CatchUpFreshnessFlag = &cli.DurationFlag{
+		Name:    CatchUpFreshnessFlagName,
+		Usage:   "Age after which the blocks are handled by the catch-up policy (default: 10m)",
+		EnvVars: []string{"CATCH_UP_FRESHNESS"},
+	}
 )
 
 func Flags() []cli.Flag {
 	return []cli.Flag{
+		ConfigFlag,
+		ConfigWatchFlag,
 		NetworkFlag,
 		L1WsRpcFlag,
 		L1HttpRpcFlag,
@@ -126,11 +436,58 @@ func Flags() []cli.Flag {
 		L1UsdcBridgeFlag,
 		L2UsdcBridgeFlag,
 		SlackUrlFlag,
+		SlackBotTokenFlag,
+		SlackChannelFlag,
 		L1ExplorerUrlFlag,
 		L2ExplorerUrlFlag,
 		L1TokenAddressesFlag,
 		L2TokenAddressesFlag,
+		TokenListsFlag,
 		RedisAddressFlag,
 		RedisDBFlag,
+		RedisModeFlag,
+		RedisUsernameFlag,
+		RedisPasswordFlag,
+		RedisMasterNameFlag,
+		RedisSentinelUsernameFlag,
+		RedisSentinelPasswordFlag,
+		RedisTLSEnabledFlag,
+		RedisTLSCAFileFlag,
+		RedisTLSCertFileFlag,
+		RedisTLSKeyFileFlag,
+		RedisTLSServerNameFlag,
+		RedisTLSInsecureSkipVerifyFlag,
+		RedisDialTimeoutFlag,
+		RedisReadTimeoutFlag,
+		RedisWriteTimeoutFlag,
+		RedisPoolSizeFlag,
+		RedisMinIdleConnsFlag,
+		StorageTypeFlag,
+		StorageFileDirFlag,
+		StoragePostgresDSNFlag,
+		ArchiveDBDriverFlag,
+		ArchiveDBDSNFlag,
+		ArchiveHTTPAddrFlag,
+		PublisherRedisStreamFlag,
+		PublisherRedisStreamMaxLenFlag,
+		PublisherKafkaBrokersFlag,
+		PublisherKafkaTopicFlag,
+		StreamGRPCAddrFlag,
+		StreamWebSocketAddrFlag,
+		StreamBufferSizeFlag,
+		SupervisorMinBackoffFlag,
+		SupervisorMaxBackoffFlag,
+		SupervisorAlertAfterFlag,
+		SupervisorResetAfterFlag,
+		SupervisorHTTPAddrFlag,
+		AdminHTTPAddrFlag,
+		AdminTokenFlag,
+		PricesFileFlag,
+		PricesHTTPURLFlag,
+		PricesHTTPTTLFlag,
+		AddressBookFilesFlag,
+		DigestPeriodsFlag,
+		CatchUpPolicyFlag,
+		CatchUpFreshnessFlag,
 	}
 }
@@ -7,7 +7,6 @@ import (
 
 	"github.com/tokamak-network/tokamak-thanos-event-listener/cmd/app/flags"
 	thanosnotif "github.com/tokamak-network/tokamak-thanos-event-listener/internal/app/thanos-notif"
-	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/redis"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
 )
 
@@ -32,25 +31,10 @@ func main() {
 func startListener(ctx *cli.Context) error {
 	log.GetLogger().Info("Start the application")
 
-	config := &thanosnotif.Config{
-		Network:          ctx.String(flags.NetworkFlagName),
-		L1WsRpc:          ctx.String(flags.L1WsRpcUrlFlagName),
-		L1HttpRpc:        ctx.String(flags.L1HttpRpcUrlFlagName),
-		L2WsRpc:          ctx.String(flags.L2WsRpcUrlFlagName),
-		L2HttpRpc:        ctx.String(flags.L2HttpRpcUrlFlagName),
-		L1StandardBridge: ctx.String(flags.L1StandardBridgeFlagName),
-		L2StandardBridge: ctx.String(flags.L2StandardBridgeFlagName),
-		L1UsdcBridge:     ctx.String(flags.L1UsdcBridgeFlagName),
-		L2UsdcBridge:     ctx.String(flags.L2UsdcBridgeFlagName),
-		SlackURL:         ctx.String(flags.SlackUrlFlagName),
-		L1ExplorerUrl:    ctx.String(flags.L1ExplorerUrlFlagName),
-		L2ExplorerUrl:    ctx.String(flags.L2ExplorerUrlFlagName),
-		L1TokenAddresses: ctx.StringSlice(flags.L1TokenAddresses),
-		L2TokenAddresses: ctx.StringSlice(flags.L2TokenAddresses),
-		RedisConfig: redis.Config{
-			Addresses: ctx.String(flags.RedisAddressFlagName),
-			DB:        ctx.Int(flags.RedisDBFlagName),
-		},
+	config, err := loadConfig(ctx)
+	if err != nil {
+		log.GetLogger().Errorw("Failed to load the configuration", "error", err)
+		return err
 	}
 
 	if err := config.Validate(); err != nil {
@@ -65,5 +49,7 @@ func startListener(ctx *cli.Context) error {
 		return err
 	}
 
+	watchConfig(ctx, app)
+
 	return app.Start(ctx.Context)
 }
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/urfave/cli/v2"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/cmd/app/flags"
+	thanosnotif "github.com/tokamak-network/tokamak-thanos-event-listener/internal/app/thanos-notif"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+// configWatchDebounce coalesces the write events of a single file save.
+const configWatchDebounce = time.Second
+
+// watchConfig reloads the config on SIGHUP, and on changes of the config
+// file when watching is enabled.
+func watchConfig(ctx *cli.Context, app *thanosnotif.App) {
+	reloadCh := make(chan struct{}, 1)
+	trigger := func() {
+		select {
+		case reloadCh <- struct{}{}:
+		default:
+		}
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(sigCh)
+		for {
+			select {
+			case <-ctx.Context.Done():
+				return
+			case <-sigCh:
+				log.GetLogger().Infow("Got SIGHUP, reload the configuration")
+				trigger()
+			}
+		}
+	}()
+
+	if path := ctx.String(flags.ConfigFlagName); path != "" && ctx.Bool(flags.ConfigWatchFlagName) {
+		if err := watchConfigFile(ctx.Context, path, trigger); err != nil {
+			log.GetLogger().Errorw("Failed to watch the config file, reload with SIGHUP instead", "error", err, "path", path)
+		}
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Context.Done():
+				return
+			case <-reloadCh:
+				reloadConfig(ctx, app)
+			}
+		}
+	}()
+}
+
+func reloadConfig(ctx *cli.Context, app *thanosnotif.App) {
+	config, err := loadConfig(ctx)
+	if err != nil {
+		log.GetLogger().Errorw("Failed to load the configuration, keep the current one", "error", err)
+		return
+	}
+
+	if err := config.Validate(); err != nil {
+		log.GetLogger().Errorw("Invalid configuration, keep the current one", "error", err)
+		return
+	}
+
+	if err := app.Reload(config); err != nil {
+		log.GetLogger().Errorw("Failed to reload the configuration, keep the current one", "error", err)
+	}
+}
+
+// watchConfigFile watches the directory of the file, as editors and config
+// maps replace the file instead of writing it.
+func watchConfigFile(ctx context.Context, path string, trigger func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	path, err = filepath.Abs(path)
+	if err != nil {
+		_ = watcher.Close()
+		return err
+	}
+
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != path || !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+					continue
+				}
+				debounce = time.After(configWatchDebounce)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.GetLogger().Errorw("Config file watcher error", "error", err)
+			case <-debounce:
+				debounce = nil
+				log.GetLogger().Infow("Config file changed, reload the configuration", "path", path)
+				trigger()
+			}
+		}
+	}()
+
+	return nil
+}
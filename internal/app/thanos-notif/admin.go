@@ -0,0 +1,254 @@
+package thanosnotif
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/admin"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/listener"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+// adminResetTimeout bounds the wait for a listener to apply a head reset.
+const adminResetTimeout = time.Minute
+
+// AdminConfig serves the runtime operations on the listeners, authenticated by
+// the bearer token, when HTTPAddr is set.
+type AdminConfig struct {
+	HTTPAddr string `yaml:"http_addr" toml:"http_addr"`
+	Token    string `json:"-" yaml:"token" toml:"token"`
+	// DeadLetters is the number of the last failed notifications kept to be
+	// replayed, in redis when configured.
+	DeadLetters int `yaml:"dead_letters" toml:"dead_letters"`
+}
+
+// adminOperations runs the operations of the admin API on the networks. The
+// backfills run until ctx is done.
+type adminOperations struct {
+	ctx context.Context
+	app *App
+}
+
+func (o *adminOperations) Chains() []admin.ChainStatus {
+	chains := make([]admin.ChainStatus, 0)
+	for _, n := range o.app.networks {
+		for _, name := range n.chainNames {
+			c := n.chains[name]
+			head, chainHead := c.watchdog.Heads()
+
+			status := admin.ChainStatus{
+				Network:   n.name,
+				Chain:     c.name,
+				Layer:     c.layer,
+				Head:      head,
+				ChainHead: chainHead,
+				Paused:    !n.notifier.Enabled(),
+			}
+			if chainHead > head {
+				status.Lag = chainHead - head
+			}
+
+			chains = append(chains, status)
+		}
+	}
+
+	return chains
+}
+
+func (o *adminOperations) Subscriptions(networkName, chainName string) ([]admin.Subscription, error) {
+	networks, err := o.networks(networkName)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]admin.Subscription, 0)
+	for _, n := range networks {
+		for _, name := range n.chainNames {
+			if chainName != "" && name != chainName {
+				continue
+			}
+
+			for _, request := range n.chains[name].listener.Requests() {
+				requestType := "event"
+				if request.GetRequestType() == listener.RequestTransactionType {
+					requestType = "transaction"
+				}
+
+				subscriptions = append(subscriptions, admin.Subscription{
+					Network: n.name,
+					Chain:   name,
+					Type:    requestType,
+					Key:     request.SerializeEventRequest(),
+				})
+			}
+		}
+	}
+
+	return subscriptions, nil
+}
+
+// Pause skips the routine bridge event notifications of the networks until
+// they are resumed. The events are still archived and counted, and the alerts
+// and the whale or watchlist escalated events still notified.
+func (o *adminOperations) Pause(networkName string) error {
+	networks, err := o.networks(networkName)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range networks {
+		n.notifier.Disable()
+		log.GetLogger().Infow("Paused the routine notifications", "network", n.name)
+	}
+
+	return nil
+}
+
+func (o *adminOperations) Resume(networkName string) error {
+	networks, err := o.networks(networkName)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range networks {
+		n.notifier.Enable()
+		log.GetLogger().Infow("Resumed the routine notifications", "network", n.name)
+	}
+
+	return nil
+}
+
+func (o *adminOperations) ResetHead(ctx context.Context, networkName, chainName string, number uint64) error {
+	c, err := o.chain(networkName, chainName)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, adminResetTimeout)
+	defer cancel()
+
+	return c.listener.ResetHead(ctx, number)
+}
+
+func (o *adminOperations) Backfill(networkName, chainName string, fromBlock, toBlock uint64) error {
+	c, err := o.chain(networkName, chainName)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := c.listener.Backfill(o.ctx, fromBlock, toBlock); err != nil {
+			log.GetLogger().Errorw("Failed to backfill the blocks", "error", err, "chain", c.key(), "from", fromBlock, "to", toBlock)
+			return
+		}
+
+		log.GetLogger().Infow("Backfilled the blocks", "chain", c.key(), "from", fromBlock, "to", toBlock)
+	}()
+
+	return nil
+}
+
+func (o *adminOperations) DeadLetters(ctx context.Context) ([]admin.DeadLetter, error) {
+	deadLetters := make([]admin.DeadLetter, 0)
+	if o.app.deadLetters == nil {
+		return deadLetters, nil
+	}
+
+	letters, err := o.app.deadLetters.List(ctx)
+	if err != nil {
+		log.GetLogger().Errorw("Failed to list the failed notifications", "error", err)
+		return nil, err
+	}
+
+	for _, letter := range letters {
+		deadLetters = append(deadLetters, admin.DeadLetter{
+			Network:  letter.Network,
+			Notifier: letter.Notifier,
+			Title:    letter.Message.Title,
+			Error:    letter.Error,
+			FailedAt: letter.FailedAt,
+		})
+	}
+
+	return deadLetters, nil
+}
+
+// ReplayDeadLetters sends the failed notifications again to their notifier,
+// through its rate limit. The notifications failing again are kept, and the
+// ones of the notifiers removed since are dropped.
+func (o *adminOperations) ReplayDeadLetters(ctx context.Context) (int, error) {
+	if o.app.deadLetters == nil {
+		return 0, nil
+	}
+
+	letters, err := o.app.deadLetters.Take(ctx)
+	if err != nil {
+		log.GetLogger().Errorw("Failed to take the failed notifications", "error", err)
+		return 0, err
+	}
+
+	replayed := 0
+	for _, letter := range letters {
+		var sender notification.Sender
+		for _, n := range o.app.networks {
+			if n.name == letter.Network {
+				sender = n.notifier.Sender(letter.Notifier)
+			}
+		}
+		if sender == nil {
+			log.GetLogger().Warnw("Dropped the failed notification of an unknown notifier", "network", letter.Network, "notifier", letter.Notifier, "title", letter.Message.Title)
+			continue
+		}
+
+		if err := sender.Notify(letter.Message); err != nil {
+			log.GetLogger().Errorw("Failed to replay the notification", "error", err, "network", letter.Network, "notifier", letter.Notifier, "title", letter.Message.Title)
+			continue
+		}
+		replayed++
+	}
+
+	log.GetLogger().Infow("Replayed the failed notifications", "replayed", replayed, "failed", len(letters)-replayed)
+
+	return replayed, nil
+}
+
+// networks returns the network of the name, every network when empty.
+func (o *adminOperations) networks(name string) ([]*network, error) {
+	if name == "" {
+		return o.app.networks, nil
+	}
+
+	for _, n := range o.app.networks {
+		if n.name == name {
+			return []*network{n}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: network %s", admin.ErrNotFound, name)
+}
+
+func (o *adminOperations) chain(networkName, chainName string) (*chain, error) {
+	for _, n := range o.app.networks {
+		if n.name != networkName {
+			continue
+		}
+
+		if c, ok := n.chains[chainName]; ok {
+			return c, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: chain %s:%s", admin.ErrNotFound, networkName, chainName)
+}
+
+func (c *AdminConfig) validate(v *validator) {
+	if c.HTTPAddr != "" {
+		v.required("admin.token", c.Token)
+	}
+
+	if c.DeadLetters < 0 {
+		v.add("admin.dead_letters", "must not be negative")
+	}
+}
@@ -0,0 +1,106 @@
+package thanosnotif
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/admin"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+func TestAdminOperations(t *testing.T) {
+	chains := testChainsConfig()
+	chains[1].BatchInbox = "0xff00000000000000000000000000000000111551"
+
+	app := newTestApp(t, &Config{
+		NetworkConfig: NetworkConfig{
+			Network:   "sepolia",
+			Chains:    chains,
+			Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"}},
+		},
+	})
+	ops := &adminOperations{ctx: context.Background(), app: app}
+
+	n := testNetwork(app, "sepolia")
+	slack := &testSender{}
+	require.NoError(t, n.notifier.Update(map[string]notification.Sender{"slack": slack}, []string{"slack"}, nil))
+
+	n.chains["l1"].watchdog.HeadStored(&ethereumTypes.Header{Number: big.NewInt(100)}, nil)
+	n.chains["l1"].watchdog.ChainHead(110)
+
+	statuses := ops.Chains()
+	require.Len(t, statuses, 3)
+	assert.Equal(t, admin.ChainStatus{Network: "sepolia", Chain: "l1", Layer: "l1", Head: 100, ChainHead: 110, Lag: 10}, statuses[0])
+
+	subscriptions, err := ops.Subscriptions("sepolia", "l1")
+	require.NoError(t, err)
+	assert.Contains(t, subscriptions, admin.Subscription{Network: "sepolia", Chain: "l1", Type: "transaction", Key: "tx:0xff00000000000000000000000000000000111551"})
+
+	_, err = ops.Subscriptions("mainnet", "")
+	require.ErrorIs(t, err, admin.ErrNotFound)
+
+	// the paused network skips the routine notifications, not the alerts and
+	// the escalated events
+	handler := app.bridgeEventHandler(n.rollups["thanos-a"], func(*rollup, *ethereumTypes.Log) (*types.BridgeEvent, error) {
+		return &types.BridgeEvent{
+			Network:   "sepolia",
+			Layer:     types.LayerL1,
+			Bridge:    types.BridgeStandard,
+			Direction: types.DirectionDeposit,
+			Status:    types.StatusInitiated,
+			Amount:    big.NewInt(1),
+			BlockTime: time.Now(),
+		}, nil
+	})
+
+	require.NoError(t, ops.Pause(""))
+	assert.True(t, ops.Chains()[0].Paused)
+	msg, err := handler(&ethereumTypes.Log{})
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	require.NoError(t, n.notifier.Notify(msg))
+	assert.Empty(t, slack.messages)
+
+	alert := &notification.Message{Title: "alert", Attributes: map[string]string{"alert": watchdogAlert}}
+	require.NoError(t, n.notifier.Notify(alert))
+	escalated := &notification.Message{Title: "escalated", Severity: notification.SeverityWarning}
+	require.NoError(t, n.notifier.Notify(escalated))
+	assert.Equal(t, []*notification.Message{alert, escalated}, slack.messages)
+
+	require.NoError(t, ops.Resume("sepolia"))
+	assert.False(t, ops.Chains()[0].Paused)
+	msg, err = handler(&ethereumTypes.Log{})
+	require.NoError(t, err)
+	require.NoError(t, n.notifier.Notify(msg))
+	assert.Equal(t, msg, slack.messages[len(slack.messages)-1])
+
+	// the failed notifications are sent again to their notifier
+	ctx := context.Background()
+	app.deadLetters = notification.NewMemoryDeadLetterStore(0)
+	failed := &notification.Message{Title: "failed"}
+	require.NoError(t, app.deadLetters.Add(ctx, &notification.DeadLetter{Network: "sepolia", Notifier: "slack", Message: failed, Error: "webhook failed"}))
+	require.NoError(t, app.deadLetters.Add(ctx, &notification.DeadLetter{Network: "sepolia", Notifier: "removed", Message: &notification.Message{Title: "dropped"}}))
+
+	deadLetters, err := ops.DeadLetters(ctx)
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 2)
+	assert.Equal(t, admin.DeadLetter{Network: "sepolia", Notifier: "slack", Title: "failed", Error: "webhook failed"}, deadLetters[0])
+
+	replayed, err := ops.ReplayDeadLetters(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+	assert.Equal(t, failed, slack.messages[len(slack.messages)-1])
+	deadLetters, err = ops.DeadLetters(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, deadLetters)
+
+	require.ErrorIs(t, ops.Backfill("sepolia", "thanos-c", 1, 10), admin.ErrNotFound)
+	require.ErrorIs(t, ops.ResetHead(context.Background(), "mainnet", "l1", 1), admin.ErrNotFound)
+}
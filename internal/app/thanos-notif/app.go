@@ -2,18 +2,24 @@ package thanosnotif
 
 import (
 	"context"
-	"fmt"
-	"sync"
+	"database/sql"
+	"sync/atomic"
 
 	redislib "github.com/go-redis/redis/v8"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/addressbook"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/admin"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/archive"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/bcclient"
-	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/listener"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/database"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/price"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/redis"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/repository"
-	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/stream"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/supervisor"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/token"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
 )
 
@@ -27,95 +33,120 @@ const (
 )
 
 type App struct {
-	cfg          *Config
-	l1TokensInfo map[string]*types.Token
-	l2TokensInfo map[string]*types.Token
-	l1Listener   *listener.EventService
-	l2Listener   *listener.EventService
-	l1Client     *bcclient.Client
-	l2Client     *bcclient.Client
-	mu           sync.Mutex
+	cfg         atomic.Pointer[Config]
+	clients     map[string]*bcclient.Client
+	networks    []*network
+	redisClient redislib.UniversalClient
+	db          *sql.DB
+	sinks       []EventSink
+	archive     *archive.Store
+	streamHub   *stream.Hub
+	supervisor  *supervisor.Supervisor
+	tokens      *token.Registry
+	threads     notification.ThreadStore
+	deadLetters notification.DeadLetterStore
+	prices      atomic.Pointer[price.Chain]
+	addresses   atomic.Pointer[addressbook.Book]
 }
 
 func New(ctx context.Context, cfg *Config) (*App, error) {
-	redisClient, err := redis.New(ctx, cfg.RedisConfig)
-	if err != nil {
-		log.GetLogger().Errorw("Failed to connect to redis", "error", err)
-		return nil, err
+	app := &App{
+		clients: make(map[string]*bcclient.Client),
 	}
+	app.cfg.Store(cfg)
+	app.supervisor = supervisor.New(cfg.SupervisorConfig.Config, app.alertTaskFailure)
+	app.supervisor.OnRecover(app.resolveTaskFailure)
+	app.prices.Store(newPrices(&cfg.PricesConfig))
 
-	l1Client, err := bcclient.New(ctx, cfg.L1WsRpc, cfg.L1HttpRpc)
-	if err != nil {
-		log.GetLogger().Errorw("Failed to create L1 client", "error", err)
+	if err := app.initStorage(ctx); err != nil {
+		log.GetLogger().Errorw("Failed to initialize storage", "error", err)
 		return nil, err
 	}
 
-	l2Client, err := bcclient.New(ctx, cfg.L2WsRpc, cfg.L2HttpRpc)
-	if err != nil {
-		log.GetLogger().Errorw("Failed to create L2 client", "error", err)
+	if err := app.initArchive(ctx); err != nil {
+		log.GetLogger().Errorw("Failed to initialize the event archive", "error", err)
 		return nil, err
 	}
 
-	l1Tokens, err := fetchTokensInfo(l1Client, cfg.L1TokenAddresses)
-	if err != nil {
-		log.GetLogger().Errorw("Failed to fetch L1 tokens info", "error", err)
+	if err := app.initPublisher(ctx); err != nil {
+		log.GetLogger().Errorw("Failed to initialize the event publisher", "error", err)
 		return nil, err
 	}
 
-	l2Tokens, err := fetchTokensInfo(l2Client, cfg.L2TokenAddresses)
+	app.initStream()
+
+	addresses, err := newAddressBook(&cfg.AddressBook)
 	if err != nil {
-		log.GetLogger().Errorw("Failed to fetch L2 tokens info", "error", err)
 		return nil, err
 	}
+	app.addresses.Store(addresses)
 
-	app := &App{
-		cfg:          cfg,
-		l1TokensInfo: l1Tokens,
-		l2TokensInfo: l2Tokens,
-		l1Client:     l1Client,
-		l2Client:     l2Client,
+	if err := app.initTokens(ctx); err != nil {
+		log.GetLogger().Errorw("Failed to initialize the token registry", "error", err)
+		return nil, err
 	}
 
-	slackNotifier := notification.MakeSlackNotificationService(cfg.SlackURL, 5)
-
-	l1Listener, err := app.initL1Listener(ctx, slackNotifier, l1Client, redisClient)
-	if err != nil {
-		log.GetLogger().Errorw("Failed to initialize L1 listener", "error", err)
+	if err := app.initThreads(ctx); err != nil {
+		log.GetLogger().Errorw("Failed to initialize the notification threads", "error", err)
 		return nil, err
 	}
 
-	l2Listener, err := app.initL2Listener(ctx, slackNotifier, l2Client, redisClient)
-	if err != nil {
-		log.GetLogger().Errorw("Failed to initialize L2 listener", "error", err)
+	if err := app.initNetworks(ctx); err != nil {
+		log.GetLogger().Errorw("Failed to initialize the networks", "error", err)
 		return nil, err
 	}
 
-	app.l1Listener = l1Listener
-	app.l2Listener = l2Listener
-
 	return app, nil
 }
 
+// Start runs the listeners of every network and the servers. A failed
+// listener is restarted by the supervisor while the other ones keep running.
 func (p *App) Start(ctx context.Context) error {
-	var g errgroup.Group
+	g, ctx := errgroup.WithContext(ctx)
 
-	g.Go(func() error {
-		err := p.l1Listener.Start(ctx)
-		if err != nil {
-			return err
-		}
+	// the servers and the periodic checks are supervised as the listeners, so
+	// that their failure is alerted and retried without stopping the listeners
+	services := make(map[string]supervisor.RunFunc)
 
-		return nil
-	})
+	if p.archive != nil && p.config().ArchiveConfig.HTTPAddr != "" {
+		services["archive"] = archive.NewServer(p.config().ArchiveConfig.HTTPAddr, p.archive).Start
+	}
 
-	g.Go(func() error {
-		err := p.l2Listener.Start(ctx)
-		if err != nil {
-			return err
-		}
+	if p.streamHub != nil {
+		services["stream"] = stream.NewServer(p.streamHub, p.config().StreamConfig.GRPCAddr, p.config().StreamConfig.WebSocketAddr).Start
+	}
 
-		return nil
-	})
+	if p.config().SupervisorConfig.HTTPAddr != "" {
+		services["supervisor"] = supervisor.NewServer(p.config().SupervisorConfig.HTTPAddr, p.supervisor).Start
+	}
+
+	if p.config().AdminConfig.HTTPAddr != "" {
+		ops := &adminOperations{ctx: ctx, app: p}
+		services["admin"] = admin.NewServer(p.config().AdminConfig.HTTPAddr, p.config().AdminConfig.Token, ops).Start
+	}
+
+	if len(p.config().DigestConfig.Periods) > 0 {
+		services["digests"] = p.runDigests
+	}
+
+	services["watchdogs"] = p.runWatchdogs
+	services["solvency"] = p.runSolvency
+
+	for name, run := range services {
+		name, run := name, run
+		g.Go(func() error {
+			return p.supervisor.Run(ctx, name, run)
+		})
+	}
+
+	for _, n := range p.networks {
+		for _, name := range n.chainNames {
+			c := n.chains[name]
+			g.Go(func() error {
+				return p.supervisor.Run(ctx, c.key(), c.listener.Start)
+			})
+		}
+	}
 
 	if err := g.Wait(); err != nil {
 		log.GetLogger().Errorw("Failed to start service", "error", err)
@@ -125,56 +156,111 @@ func (p *App) Start(ctx context.Context) error {
 	return nil
 }
 
-func (p *App) initL1Listener(ctx context.Context, slackNotifier *notification.SlackNotificationService, l1Client *bcclient.Client, redisClient redislib.UniversalClient) (*listener.EventService, error) {
-	l1SyncBlockMetadataRepo := repository.NewSyncBlockMetadataRepository(fmt.Sprintf("%s:%s", p.cfg.Network, "l1"), redisClient)
-	l1BlockKeeper, err := repository.NewBlockKeeper(ctx, l1Client, l1SyncBlockMetadataRepo)
-	if err != nil {
-		log.GetLogger().Errorw("Failed to create L1 block keeper", "error", err)
-		return nil, err
+// ListenerStats returns the restarts of the listeners, named network:chain,
+// and of the services.
+func (p *App) ListenerStats() []supervisor.Stats {
+	return p.supervisor.Stats()
+}
+
+// initTokens creates the token registry, persisting its cache in Redis when
+// Redis is configured.
+func (p *App) initTokens(ctx context.Context) error {
+	var cache token.Cache
+	if p.config().RedisConfig.Addresses != "" {
+		redisClient, err := p.getRedisClient(ctx)
+		if err != nil {
+			return err
+		}
+		cache = token.NewRedisCache(redisClient)
 	}
 
-	l1Service, err := listener.MakeService("l1-event-listener", l1Client, l1BlockKeeper)
+	p.tokens = token.NewRegistry(cache)
+
+	return p.importTokenLists(ctx, p.config().TokenLists)
+}
+
+// initThreads keeps the notification threads and the failed notifications in
+// Redis when Redis is configured, in memory otherwise.
+func (p *App) initThreads(ctx context.Context) error {
+	deadLetters := p.config().AdminConfig.DeadLetters
+	if p.config().RedisConfig.Addresses == "" {
+		p.threads = notification.NewMemoryThreadStore(notification.DefaultThreadTTL)
+		p.deadLetters = notification.NewMemoryDeadLetterStore(deadLetters)
+		return nil
+	}
+
+	redisClient, err := p.getRedisClient(ctx)
 	if err != nil {
-		log.GetLogger().Errorw("Failed to make L1 service", "error", err)
-		return nil, err
+		return err
 	}
+	p.threads = notification.NewRedisThreadStore(redisClient, notification.DefaultThreadTTL)
+	p.deadLetters = notification.NewRedisDeadLetterStore(redisClient, deadLetters)
 
-	// L1StandardBridge ETH deposit and withdrawal
-	l1Service.AddSubscribeRequest(listener.MakeEventRequest(slackNotifier, p.cfg.L1StandardBridge, ETHDepositInitiatedEventABI, p.depositETHInitiatedEvent))
-	l1Service.AddSubscribeRequest(listener.MakeEventRequest(slackNotifier, p.cfg.L1StandardBridge, ETHWithdrawalFinalizedEventABI, p.withdrawalETHFinalizedEvent))
+	return nil
+}
 
-	// L1StandardBridge ERC20 deposit and withdrawal
-	l1Service.AddSubscribeRequest(listener.MakeEventRequest(slackNotifier, p.cfg.L1StandardBridge, ERC20DepositInitiatedEventABI, p.depositERC20InitiatedEvent))
-	l1Service.AddSubscribeRequest(listener.MakeEventRequest(slackNotifier, p.cfg.L1StandardBridge, ERC20WithdrawalFinalizedEventABI, p.withdrawalERC20FinalizedEvent))
+func (p *App) importTokenLists(ctx context.Context, sources []string) error {
+	for _, source := range sources {
+		list, err := token.LoadList(ctx, source)
+		if err != nil {
+			log.GetLogger().Errorw("Failed to load the token list", "error", err, "source", source)
+			return err
+		}
 
-	// L1UsdcBridge ERC20 deposit and withdrawal
-	l1Service.AddSubscribeRequest(listener.MakeEventRequest(slackNotifier, p.cfg.L1UsdcBridge, ERC20DepositInitiatedEventABI, p.depositUsdcInitiatedEvent))
-	l1Service.AddSubscribeRequest(listener.MakeEventRequest(slackNotifier, p.cfg.L1UsdcBridge, ERC20WithdrawalFinalizedEventABI, p.withdrawalUsdcFinalizedEvent))
+		imported := p.tokens.ImportList(list)
+		log.GetLogger().Infow("Imported the token list", "source", source, "name", list.Name, "tokens", imported)
+	}
 
-	return l1Service, nil
+	return nil
 }
 
-func (p *App) initL2Listener(ctx context.Context, slackNotifier *notification.SlackNotificationService, l2Client *bcclient.Client, redisClient redislib.UniversalClient) (*listener.EventService, error) {
-	l2SyncBlockMetadataRepo := repository.NewSyncBlockMetadataRepository(fmt.Sprintf("%s:%s", p.cfg.Network, "l2"), redisClient)
-	l2BlockKeeper, err := repository.NewBlockKeeper(ctx, l2Client, l2SyncBlockMetadataRepo)
-	if err != nil {
-		log.GetLogger().Errorw("Failed to make L2 service", "error", err)
-		return nil, err
+func (p *App) initStorage(ctx context.Context) error {
+	switch p.config().StorageConfig.Type {
+	case repository.StorageTypeFile:
+		return nil
+	case repository.StorageTypePostgres:
+		db, err := database.New(ctx, database.Config{
+			Driver: database.DriverPostgres,
+			DSN:    p.config().StorageConfig.PostgresDSN,
+		})
+		if err != nil {
+			log.GetLogger().Errorw("Failed to connect to postgres", "error", err)
+			return err
+		}
+		p.db = db
+	default:
+		if _, err := p.getRedisClient(ctx); err != nil {
+			return err
+		}
 	}
 
-	l2Service, err := listener.MakeService("l2-event-listener", l2Client, l2BlockKeeper)
+	return nil
+}
+
+// getRedisClient connects to redis on the first use, as only some of the
+// components need it.
+func (p *App) getRedisClient(ctx context.Context) (redislib.UniversalClient, error) {
+	if p.redisClient != nil {
+		return p.redisClient, nil
+	}
+
+	redisClient, err := redis.New(ctx, p.config().RedisConfig)
 	if err != nil {
-		log.GetLogger().Errorw("Failed to make L2 service", "error", err)
+		log.GetLogger().Errorw("Failed to connect to redis", "error", err)
 		return nil, err
 	}
+	p.redisClient = redisClient
 
-	// L2StandardBridge deposit and withdrawal
-	l2Service.AddSubscribeRequest(listener.MakeEventRequest(slackNotifier, p.cfg.L2StandardBridge, DepositFinalizedEventABI, p.depositFinalizedEvent))
-	l2Service.AddSubscribeRequest(listener.MakeEventRequest(slackNotifier, p.cfg.L2StandardBridge, WithdrawalInitiatedEventABI, p.withdrawalInitiatedEvent))
-
-	// L2UsdcBridge ERC20 deposit and withdrawal
-	l2Service.AddSubscribeRequest(listener.MakeEventRequest(slackNotifier, p.cfg.L2UsdcBridge, DepositFinalizedEventABI, p.depositUsdcFinalizedEvent))
-	l2Service.AddSubscribeRequest(listener.MakeEventRequest(slackNotifier, p.cfg.L2UsdcBridge, WithdrawalInitiatedEventABI, p.withdrawalUsdcInitiatedEvent))
+	return redisClient, nil
+}
 
-	return l2Service, nil
+func (p *App) newSyncBlockMetadataKeeper(ctx context.Context, prefix string) (repository.SyncBlockMetadataKeeper, error) {
+	switch p.config().StorageConfig.Type {
+	case repository.StorageTypeFile:
+		return repository.NewFileSyncBlockMetadataRepository(prefix, p.config().StorageConfig.FileDir)
+	case repository.StorageTypePostgres:
+		return repository.NewPostgresSyncBlockMetadataRepository(ctx, prefix, p.db)
+	default:
+		return repository.NewSyncBlockMetadataRepository(prefix, p.redisClient), nil
+	}
 }
@@ -0,0 +1,205 @@
+package thanosnotif
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	// CatchUpNotify notifies the stale events as the other events.
+	CatchUpNotify = "notify"
+	// CatchUpSuppress only writes the stale events to the sinks and digests.
+	CatchUpSuppress = "suppress"
+	// CatchUpSummary notifies a summary of the stale events of a chain once
+	// its missed blocks are processed.
+	CatchUpSummary = "summary"
+
+	defaultCatchUpFreshness = 10 * time.Minute
+
+	catchUpAlert = "catch_up"
+)
+
+// CatchUpConfig handles the events of the blocks older than Freshness, such as
+// the blocks missed while the service was down.
+type CatchUpConfig struct {
+	// Policy is notify, suppress or summary, notify by default.
+	Policy string `yaml:"policy" toml:"policy"`
+	// Freshness is the age from which a block is stale, 10m by default.
+	Freshness time.Duration `yaml:"freshness" toml:"freshness"`
+}
+
+// catchUpSummary aggregates the stale events of a chain.
+type catchUpSummary struct {
+	mu        sync.Mutex
+	events    int
+	kinds     map[string]int
+	assets    map[string]int
+	valueUSD  float64
+	fromBlock uint64
+	toBlock   uint64
+	from      time.Time
+	to        time.Time
+}
+
+func (s *catchUpSummary) add(event *types.BridgeEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.events == 0 {
+		s.kinds = make(map[string]int)
+		s.assets = make(map[string]int)
+		s.fromBlock, s.toBlock = event.BlockNumber, event.BlockNumber
+		s.from, s.to = event.BlockTime, event.BlockTime
+	}
+
+	s.events++
+	s.kinds[fmt.Sprintf("%s %s", directionLabel(event), statusLabel(event))]++
+	s.assets[assetLabel(event)]++
+	if event.ValueUSD != nil {
+		s.valueUSD += *event.ValueUSD
+	}
+
+	s.fromBlock = min(s.fromBlock, event.BlockNumber)
+	s.toBlock = max(s.toBlock, event.BlockNumber)
+	if event.BlockTime.Before(s.from) {
+		s.from = event.BlockTime
+	}
+	if event.BlockTime.After(s.to) {
+		s.to = event.BlockTime
+	}
+}
+
+// take returns the summary text and resets the summary, empty when there is
+// no stale event.
+func (s *catchUpSummary) take() (int, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.events == 0 {
+		return 0, ""
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Blocks: %d - %d (%s - %s)\n", s.fromBlock, s.toBlock, s.from.UTC().Format(time.RFC3339), s.to.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&text, "Events: %d", s.events)
+	for _, kind := range sortedKeys(s.kinds) {
+		fmt.Fprintf(&text, "\n- %s: %d", kind, s.kinds[kind])
+	}
+	text.WriteString("\nAssets:")
+	for _, asset := range sortedKeys(s.assets) {
+		fmt.Fprintf(&text, "\n- %s: %d", asset, s.assets[asset])
+	}
+	if s.valueUSD > 0 {
+		fmt.Fprintf(&text, "\nValue: ~$%s", formatUSD(s.valueUSD))
+	}
+
+	events := s.events
+	s.events = 0
+	s.valueUSD = 0
+
+	return events, text.String()
+}
+
+// catchUp applies the catch-up policy of the network to the event, returning
+// true when the event must not be notified. The summary of a chain is sent
+// before its first fresh event. The backfilled events, requested by an
+// operator, are always notified.
+func (p *App) catchUp(r *rollup, event *types.BridgeEvent) bool {
+	c := eventChain(r, event)
+	if c.listener != nil && c.listener.Backfilling() {
+		return false
+	}
+
+	cfg := p.networkConfig(c.network).CatchUp
+
+	freshness := cfg.Freshness
+	if freshness == 0 {
+		freshness = defaultCatchUpFreshness
+	}
+
+	if time.Since(event.BlockTime) <= freshness {
+		p.flushCatchUp(c)
+		return false
+	}
+
+	switch cfg.Policy {
+	case CatchUpSuppress:
+		log.GetLogger().Debugw("Suppress the stale event", "chain", c.key(), "tx", event.TxHash, "block_time", event.BlockTime)
+		return true
+	case CatchUpSummary:
+		c.catchUp.add(event)
+		return true
+	default:
+		return false
+	}
+}
+
+// flushCatchUp notifies the summary of the stale events of the chain.
+func (p *App) flushCatchUp(c *chain) {
+	events, text := c.catchUp.take()
+	if events == 0 {
+		return
+	}
+
+	n := c.network
+	msg := &notification.Message{
+		Title:    fmt.Sprintf("[%s] [%s Catch-up Summary]", n.name, c.name),
+		Text:     text,
+		Severity: notification.SeverityInfo,
+		Attributes: map[string]string{
+			"network": n.name,
+			"layer":   c.layer,
+			"chain":   c.name,
+			"alert":   catchUpAlert,
+		},
+	}
+
+	if err := n.notifier.Notify(msg); err != nil {
+		log.GetLogger().Errorw("Failed to notify the catch-up summary", "error", err, "chain", c.key(), "events", events)
+	}
+}
+
+func (p *App) catchUpSyncedHandler(c *chain) func(ctx context.Context) {
+	return func(context.Context) {
+		p.flushCatchUp(c)
+	}
+}
+
+// eventChain returns the chain of the rollup which emitted the event.
+func eventChain(r *rollup, event *types.BridgeEvent) *chain {
+	if event.Layer == types.LayerL2 {
+		return r.l2
+	}
+
+	return r.l1
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (c *CatchUpConfig) validate(v *validator, prefix string) {
+	switch c.Policy {
+	case "", CatchUpNotify, CatchUpSuppress, CatchUpSummary:
+	default:
+		v.add(prefix+".policy", "unknown catch-up policy: %s", c.Policy)
+	}
+
+	if c.Freshness < 0 {
+		v.add(prefix+".freshness", "must not be negative")
+	}
+}
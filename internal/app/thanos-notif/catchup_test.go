@@ -0,0 +1,167 @@
+package thanosnotif
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/listener"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+func TestApp_catchUp(t *testing.T) {
+	staleTime := time.Now().Add(-time.Hour)
+
+	newEvent := func(layer string, block uint64, at time.Time) *types.BridgeEvent {
+		value := 3000.0
+		return &types.BridgeEvent{
+			Layer:       layer,
+			Direction:   types.DirectionDeposit,
+			Status:      types.StatusInitiated,
+			Asset:       "ETH",
+			Amount:      big.NewInt(1),
+			ValueUSD:    &value,
+			BlockNumber: block,
+			BlockTime:   at,
+		}
+	}
+
+	testCases := []struct {
+		policy     string
+		suppressed bool
+	}{
+		{policy: "", suppressed: false},
+		{policy: CatchUpNotify, suppressed: false},
+		{policy: CatchUpSuppress, suppressed: true},
+		{policy: CatchUpSummary, suppressed: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			app := newTestApp(t, &Config{
+				NetworkConfig: NetworkConfig{
+					Network:   "sepolia",
+					Chains:    testChainsConfig(),
+					Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"}},
+					CatchUp:   CatchUpConfig{Policy: tc.policy, Freshness: 10 * time.Minute},
+				},
+			})
+
+			n := testNetwork(app, "sepolia")
+			slack := &testSender{}
+			require.NoError(t, n.notifier.Update(map[string]notification.Sender{"slack": slack}, []string{"slack"}, nil))
+			r := n.rollups["thanos-a"]
+
+			assert.Equal(t, tc.suppressed, app.catchUp(r, newEvent(types.LayerL1, 100, staleTime)))
+			assert.Equal(t, tc.suppressed, app.catchUp(r, newEvent(types.LayerL1, 120, staleTime.Add(time.Minute))))
+			assert.Empty(t, slack.messages)
+
+			// the fresh events are always notified, after the summary
+			assert.False(t, app.catchUp(r, newEvent(types.LayerL1, 200, time.Now())))
+
+			if tc.policy != CatchUpSummary {
+				assert.Empty(t, slack.messages)
+				return
+			}
+
+			require.Len(t, slack.messages, 1)
+			msg := slack.messages[0]
+			assert.Equal(t, "[sepolia] [l1 Catch-up Summary]", msg.Title)
+			assert.Equal(t, catchUpAlert, msg.Attributes["alert"])
+			assert.Contains(t, msg.Text, "Blocks: 100 - 120")
+			assert.Contains(t, msg.Text, "Events: 2\n- Deposit Initialized: 2\nAssets:\n- ETH: 2\nValue: ~$6,000.00")
+
+			// the summary is sent once
+			app.flushCatchUp(n.chains["l1"])
+			assert.Len(t, slack.messages, 1)
+
+			// the summary of a chain is sent once its missed blocks are processed
+			assert.True(t, app.catchUp(r, newEvent(types.LayerL2, 5, staleTime)))
+			app.catchUpSyncedHandler(n.chains["thanos-a"])(nil)
+			require.Len(t, slack.messages, 2)
+			assert.Equal(t, "[sepolia] [thanos-a Catch-up Summary]", slack.messages[1].Title)
+		})
+	}
+}
+
+// testBlockSource serves the blocks of a backfill.
+type testBlockSource struct {
+	blocks []*types.NewBlock
+}
+
+func (s *testBlockSource) SubscribeNewHead(context.Context, chan<- *ethereumTypes.Header) (ethereum.Subscription, error) {
+	return nil, nil
+}
+
+func (s *testBlockSource) BlockNumber(context.Context) (uint64, error) {
+	return 0, nil
+}
+
+func (s *testBlockSource) GetLogs(context.Context, common.Hash) ([]ethereumTypes.Log, error) {
+	return nil, nil
+}
+
+func (s *testBlockSource) GetBlocks(context.Context, bool, uint64, uint64) ([]*types.NewBlock, error) {
+	return s.blocks, nil
+}
+
+func (s *testBlockSource) GetTransactions(context.Context, common.Hash) (ethereumTypes.Transactions, error) {
+	return nil, nil
+}
+
+func TestApp_catchUpBackfill(t *testing.T) {
+	app := newTestApp(t, &Config{
+		NetworkConfig: NetworkConfig{
+			Network:   "sepolia",
+			Chains:    testChainsConfig(),
+			Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"}},
+			CatchUp:   CatchUpConfig{Policy: CatchUpSuppress, Freshness: 10 * time.Minute},
+		},
+	})
+
+	n := testNetwork(app, "sepolia")
+	slack := &testSender{}
+	require.NoError(t, n.notifier.Update(map[string]notification.Sender{"slack": slack}, []string{"slack"}, nil))
+	r := n.rollups["thanos-a"]
+
+	const eventABI = "ETHDepositInitiated(address,address,uint256,bytes)"
+	bridge := common.HexToAddress("0xa1")
+	vLog := ethereumTypes.Log{Address: bridge, Topics: []common.Hash{crypto.Keccak256Hash([]byte(eventABI))}, BlockNumber: 100}
+
+	source := &testBlockSource{blocks: []*types.NewBlock{{Header: &ethereumTypes.Header{Number: big.NewInt(100)}, Logs: []ethereumTypes.Log{vLog}}}}
+	service, err := listener.MakeService("l1", source, nil)
+	require.NoError(t, err)
+	r.l1.listener = service
+
+	handler := app.bridgeEventHandler(r, func(*rollup, *ethereumTypes.Log) (*types.BridgeEvent, error) {
+		return &types.BridgeEvent{
+			Network:     "sepolia",
+			Layer:       types.LayerL1,
+			Bridge:      types.BridgeStandard,
+			Direction:   types.DirectionDeposit,
+			Status:      types.StatusInitiated,
+			Amount:      big.NewInt(1),
+			BlockNumber: 100,
+			BlockTime:   time.Now().Add(-time.Hour),
+		}, nil
+	})
+	service.AddSubscribeRequest(listener.MakeEventRequest(n.notifier, bridge.String(), eventABI, handler))
+
+	// the stale event of the missed blocks is suppressed
+	msg, err := handler(&vLog)
+	require.NoError(t, err)
+	assert.Nil(t, msg)
+
+	// the backfilled event is notified
+	require.NoError(t, service.Backfill(context.Background(), 100, 100))
+	assert.Len(t, slack.messages, 1)
+}
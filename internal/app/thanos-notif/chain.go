@@ -0,0 +1,230 @@
+package thanosnotif
+
+import (
+	"context"
+	"fmt"
+
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/bcclient"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/listener"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/repository"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/watchdog"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+// chain is a listened chain of the network.
+type chain struct {
+	name     string
+	layer    string
+	network  *network
+	chainID  uint64
+	client   *bcclient.Client
+	listener *listener.EventService
+	watchdog *watchdog.Watchdog
+	// production analyses the block production of an L2 chain.
+	production *watchdog.Production
+
+	catchUp  catchUpSummary
+	notified notifiedBlocks
+}
+
+// key identifies the chain across the networks.
+func (c *chain) key() string {
+	return fmt.Sprintf("%s:%s", c.network.name, c.name)
+}
+
+// rollup pairs an L2 chain with the L1 chain it settles to. The bridges of
+// the pair are read from the L2 chain config.
+type rollup struct {
+	l1 *chain
+	l2 *chain
+
+	settlement *watchdog.Settlement
+}
+
+func (r *rollup) network() *network {
+	return r.l2.network
+}
+
+// getClient returns the client of the endpoints, shared by the chains with
+// the same endpoints across the networks.
+func (p *App) getClient(ctx context.Context, wsRpc, httpRpc string) (*bcclient.Client, error) {
+	key := wsRpc + "|" + httpRpc
+	if client, ok := p.clients[key]; ok {
+		return client, nil
+	}
+
+	client, err := bcclient.New(ctx, wsRpc, httpRpc)
+	if err != nil {
+		return nil, err
+	}
+	p.clients[key] = client
+
+	return client, nil
+}
+
+func (p *App) initChains(ctx context.Context, n *network, cfg *NetworkConfig) error {
+	n.chains = make(map[string]*chain, len(cfg.Chains))
+	for _, chainCfg := range cfg.Chains {
+		client, err := p.getClient(ctx, chainCfg.WsRpc, chainCfg.HttpRpc)
+		if err != nil {
+			log.GetLogger().Errorw("Failed to create the chain client", "error", err, "chain", chainCfg.Name)
+			return err
+		}
+
+		c := &chain{
+			name:     chainCfg.Name,
+			layer:    chainCfg.Layer,
+			network:  n,
+			chainID:  client.ChainID().Uint64(),
+			client:   client,
+			watchdog: watchdog.New(),
+		}
+		if c.layer == types.LayerL2 {
+			c.production = watchdog.NewProduction()
+		}
+		p.tokens.AddChain(c.chainID, client.GetClient())
+		p.prefetchTokens(ctx, c, chainCfg.Tokens)
+		n.chains[c.name] = c
+		n.chainNames = append(n.chainNames, c.name)
+	}
+
+	n.rollups = make(map[string]*rollup)
+	for _, chainCfg := range cfg.Chains {
+		if chainCfg.Layer != types.LayerL2 {
+			continue
+		}
+
+		n.rollups[chainCfg.Name] = &rollup{
+			l1:         n.chains[chainCfg.SettlesTo],
+			l2:         n.chains[chainCfg.Name],
+			settlement: watchdog.NewSettlement(),
+		}
+	}
+
+	for _, name := range n.chainNames {
+		c := n.chains[name]
+
+		service, err := p.initListener(ctx, c)
+		if err != nil {
+			log.GetLogger().Errorw("Failed to initialize the listener", "error", err, "chain", c.name)
+			return err
+		}
+		c.listener = service
+	}
+
+	return nil
+}
+
+func (p *App) initListener(ctx context.Context, c *chain) (*listener.EventService, error) {
+	syncBlockMetadataRepo, err := p.newSyncBlockMetadataKeeper(ctx, c.key())
+	if err != nil {
+		log.GetLogger().Errorw("Failed to create sync block metadata keeper", "error", err, "chain", c.name)
+		return nil, err
+	}
+
+	blockKeeper, err := repository.NewBlockKeeper(ctx, c.client, syncBlockMetadataRepo)
+	if err != nil {
+		log.GetLogger().Errorw("Failed to create block keeper", "error", err, "chain", c.name)
+		return nil, err
+	}
+
+	service, err := listener.MakeService(fmt.Sprintf("%s-%s-event-listener", c.network.name, c.name), c.client, blockKeeper)
+	if err != nil {
+		log.GetLogger().Errorw("Failed to make service", "error", err, "chain", c.name)
+		return nil, err
+	}
+
+	service.SetMonitor(c.watchdog)
+	if c.production != nil {
+		service.AddHeadHandler(func(_ context.Context, header *ethereumTypes.Header) {
+			c.production.Observe(header)
+		})
+	}
+	service.SetRetryThreshold(p.networkConfig(c.network).Thresholds.ResubscribeRetries)
+	service.AddReorgHandler(p.reorgHandler(c.client))
+	service.AddReorgHandler(p.retractHandler(c))
+	service.AddSyncedHandler(p.catchUpSyncedHandler(c))
+	service.SetSubscribeRequests(p.subscribeRequests(c))
+
+	return service, nil
+}
+
+// subscribeRequests returns the bridge events to listen to on the chain: the
+// L1 side of every rollup of the network settling to an L1 chain, and the L2
+// side of an L2 chain.
+func (p *App) subscribeRequests(c *chain) []listener.RequestSubscriber {
+	cfg := p.networkConfig(c.network)
+
+	var requests []listener.RequestSubscriber
+	for _, chainCfg := range cfg.Chains {
+		r, ok := c.network.rollups[chainCfg.Name]
+		if !ok {
+			continue
+		}
+
+		switch {
+		case c.layer == types.LayerL1 && r.l1 == c:
+			requests = append(requests, p.l1SubscribeRequests(r, chainCfg.Bridges)...)
+			requests = append(requests, p.settlementRequests(r, &chainCfg)...)
+		case c.layer == types.LayerL2 && r.l2 == c:
+			requests = append(requests, p.l2SubscribeRequests(r, chainCfg.Bridges)...)
+		}
+	}
+
+	return requests
+}
+
+func (p *App) l1SubscribeRequests(r *rollup, bridges BridgesConfig) []listener.RequestSubscriber {
+	notifier := r.network().notifier
+
+	return []listener.RequestSubscriber{
+		// L1StandardBridge ETH deposit and withdrawal
+		listener.MakeEventRequest(notifier, bridges.L1Standard, ETHDepositInitiatedEventABI, p.bridgeEventHandler(r, p.depositETHInitiatedEvent)),
+		listener.MakeEventRequest(notifier, bridges.L1Standard, ETHWithdrawalFinalizedEventABI, p.bridgeEventHandler(r, p.withdrawalETHFinalizedEvent)),
+
+		// L1StandardBridge ERC20 deposit and withdrawal
+		listener.MakeEventRequest(notifier, bridges.L1Standard, ERC20DepositInitiatedEventABI, p.bridgeEventHandler(r, p.depositERC20InitiatedEvent)),
+		listener.MakeEventRequest(notifier, bridges.L1Standard, ERC20WithdrawalFinalizedEventABI, p.bridgeEventHandler(r, p.withdrawalERC20FinalizedEvent)),
+
+		// L1UsdcBridge ERC20 deposit and withdrawal
+		listener.MakeEventRequest(notifier, bridges.L1Usdc, ERC20DepositInitiatedEventABI, p.bridgeEventHandler(r, p.depositUsdcInitiatedEvent)),
+		listener.MakeEventRequest(notifier, bridges.L1Usdc, ERC20WithdrawalFinalizedEventABI, p.bridgeEventHandler(r, p.withdrawalUsdcFinalizedEvent)),
+	}
+}
+
+func (p *App) l2SubscribeRequests(r *rollup, bridges BridgesConfig) []listener.RequestSubscriber {
+	notifier := r.network().notifier
+
+	return []listener.RequestSubscriber{
+		// L2StandardBridge deposit and withdrawal
+		listener.MakeEventRequest(notifier, bridges.L2Standard, DepositFinalizedEventABI, p.bridgeEventHandler(r, p.depositFinalizedEvent)),
+		listener.MakeEventRequest(notifier, bridges.L2Standard, WithdrawalInitiatedEventABI, p.bridgeEventHandler(r, p.withdrawalInitiatedEvent)),
+
+		// L2UsdcBridge ERC20 deposit and withdrawal
+		listener.MakeEventRequest(notifier, bridges.L2Usdc, DepositFinalizedEventABI, p.bridgeEventHandler(r, p.depositUsdcFinalizedEvent)),
+		listener.MakeEventRequest(notifier, bridges.L2Usdc, WithdrawalInitiatedEventABI, p.bridgeEventHandler(r, p.withdrawalUsdcInitiatedEvent)),
+	}
+}
+
+// bridges returns the current bridges of the rollup.
+func (p *App) bridges(r *rollup) BridgesConfig {
+	chainCfg := p.networkConfig(r.network()).Chain(r.l2.name)
+	if chainCfg == nil {
+		return BridgesConfig{}
+	}
+
+	return chainCfg.Bridges
+}
+
+// explorerUrl returns the current explorer url of the chain.
+func (p *App) explorerUrl(c *chain) string {
+	chainCfg := p.networkConfig(c.network).Chain(c.name)
+	if chainCfg == nil {
+		return ""
+	}
+
+	return chainCfg.ExplorerUrl
+}
@@ -2,72 +2,425 @@ package thanosnotif
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tokamak-network/tokamak-thanos/op-bindings/predeploys"
 
 	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/redis"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/repository"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/supervisor"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+var (
+	nativeDecimals = 18
+
+	// defaultAssets classifies the native tokens of every network. The ERC-20
+	// tokens, such as TON on L1, are classified by the config.
+	defaultAssets = map[string]map[common.Address]AssetConfig{
+		types.LayerL1: {
+			common.Address{}: {DisplayName: "ETH", Category: types.CategoryNative, Decimals: &nativeDecimals},
+		},
+		types.LayerL2: {
+			predeploys.ETHAddr:                    {DisplayName: "ETH", Category: types.CategoryNative, Decimals: &nativeDecimals},
+			predeploys.LegacyERC20NativeTokenAddr: {DisplayName: "TON", Category: types.CategoryNative, Decimals: &nativeDecimals},
+		},
+	}
 )
 
 type Config struct {
-	Network string
+	// NetworkConfig is the default network, configured by the flags. It is
+	// listened to when it has chains or when no other network is configured.
+	NetworkConfig `yaml:",inline"`
+
+	// Networks are the other networks listened to by the process, each with
+	// its own chains and notifiers.
+	Networks []NetworkConfig `yaml:"networks" toml:"networks"`
+
+	// TokenLists are the files or URLs of the Uniswap format token lists,
+	// such as the Optimism token list, imported in the token registry.
+	TokenLists []string `yaml:"token_lists" toml:"token_lists"`
 
-	L1HttpRpc string
-	L1WsRpc   string
+	RedisConfig redis.Config `yaml:"redis" toml:"redis"`
 
-	L2HttpRpc string
-	L2WsRpc   string
+	StorageConfig repository.StorageConfig `yaml:"storage" toml:"storage"`
 
-	L1StandardBridge string
-	L2StandardBridge string
+	ArchiveConfig ArchiveConfig `yaml:"archive" toml:"archive"`
 
-	L1UsdcBridge string
-	L2UsdcBridge string
+	PublisherConfig PublisherConfig `yaml:"publisher" toml:"publisher"`
 
-	SlackURL string
+	StreamConfig StreamConfig `yaml:"stream" toml:"stream"`
 
-	L1ExplorerUrl string
-	L2ExplorerUrl string
+	SupervisorConfig SupervisorConfig `yaml:"supervisor" toml:"supervisor"`
 
-	L1TokenAddresses []string
-	L2TokenAddresses []string
+	AdminConfig AdminConfig `yaml:"admin" toml:"admin"`
 
-	RedisConfig redis.Config
+	PricesConfig PricesConfig `yaml:"prices" toml:"prices"`
+
+	AddressBook AddressBookConfig `yaml:"address_book" toml:"address_book"`
+
+	DigestConfig DigestConfig `yaml:"digest" toml:"digest"`
 }
 
+type NetworkConfig struct {
+	// Network names the network in the messages and prefixes the sync block
+	// metadata of its chains.
+	Network string `yaml:"network" toml:"network"`
+
+	Chains []ChainConfig `yaml:"chains" toml:"chains"`
+
+	Notifiers []NotifierConfig `yaml:"notifiers" toml:"notifiers"`
+
+	Routing RoutingConfig `yaml:"routing" toml:"routing"`
+
+	Thresholds ThresholdsConfig `yaml:"thresholds" toml:"thresholds"`
+
+	Whales WhalesConfig `yaml:"whales" toml:"whales"`
+
+	// Watchlist escalates or routes the events of the addresses with the
+	// address book tags, by tag.
+	Watchlist map[string]WatchlistConfig `yaml:"watchlist" toml:"watchlist"`
+
+	CatchUp CatchUpConfig `yaml:"catch_up" toml:"catch_up"`
+
+	Watchdog WatchdogConfig `yaml:"watchdog" toml:"watchdog"`
+
+	Sequencer SequencerConfig `yaml:"sequencer" toml:"sequencer"`
+
+	Settlement SettlementConfig `yaml:"settlement" toml:"settlement"`
+
+	Solvency SolvencyConfig `yaml:"solvency" toml:"solvency"`
+}
+
+type ChainConfig struct {
+	// Name identifies the chain in the network and prefixes its sync block
+	// metadata.
+	Name  string `yaml:"name" toml:"name"`
+	Layer string `yaml:"layer" toml:"layer"`
+	// SettlesTo is the name of the L1 chain of an L2 chain.
+	SettlesTo string `yaml:"settles_to" toml:"settles_to"`
+
+	HttpRpc     string `yaml:"http_rpc" toml:"http_rpc"`
+	WsRpc       string `yaml:"ws_rpc" toml:"ws_rpc"`
+	ExplorerUrl string `yaml:"explorer_url" toml:"explorer_url"`
+	// HeadTimeout overrides the watchdog head timeout of the chain, e.g. a
+	// longer one for a chain with slow blocks.
+	HeadTimeout time.Duration `yaml:"head_timeout" toml:"head_timeout"`
+	// BlockTime overrides the expected block time of an L2 chain.
+	BlockTime time.Duration `yaml:"block_time" toml:"block_time"`
+
+	// Bridges between an L2 chain and its L1 chain.
+	Bridges BridgesConfig `yaml:"bridges" toml:"bridges"`
+	// BatchInbox and L2OutputOracle are the L1 addresses the batches and the
+	// outputs of an L2 chain are posted to, monitored when set. Only the
+	// batches sent by the Batcher address are counted.
+	BatchInbox     string `yaml:"batch_inbox" toml:"batch_inbox"`
+	Batcher        string `yaml:"batcher" toml:"batcher"`
+	L2OutputOracle string `yaml:"l2_output_oracle" toml:"l2_output_oracle"`
+	// OptimismPortal is the L1 address holding the ETH bridged to an L2
+	// chain, read by the solvency checks.
+	OptimismPortal string `yaml:"optimism_portal" toml:"optimism_portal"`
+
+	// Tokens lists the token addresses on the chain to fetch at startup. The
+	// other tokens are fetched on their first event.
+	Tokens []string `yaml:"tokens" toml:"tokens"`
+
+	// Assets classifies the tokens of the chain by address. The zero address
+	// is the native ETH of the L1 chain.
+	Assets map[string]AssetConfig `yaml:"assets" toml:"assets"`
+}
+
+type AssetConfig struct {
+	// DisplayName labels the token in the messages.
+	DisplayName string `yaml:"display_name" toml:"display_name"`
+	Category    string `yaml:"category" toml:"category"`
+	// Decimals overrides the decimals of the token contract.
+	Decimals *int `yaml:"decimals" toml:"decimals"`
+	// PriceID identifies the token in the price providers, the display name
+	// by default. The unclassified tokens are not priced.
+	PriceID string `yaml:"price_id" toml:"price_id"`
+}
+
+type BridgesConfig struct {
+	L1Standard string `yaml:"l1_standard" toml:"l1_standard"`
+	L2Standard string `yaml:"l2_standard" toml:"l2_standard"`
+	L1Usdc     string `yaml:"l1_usdc" toml:"l1_usdc"`
+	L2Usdc     string `yaml:"l2_usdc" toml:"l2_usdc"`
+}
+
+type ThresholdsConfig struct {
+	NotifyRetries      int    `yaml:"notify_retries" toml:"notify_retries"`
+	ResubscribeRetries uint64 `yaml:"resubscribe_retries" toml:"resubscribe_retries"`
+}
+
+type SupervisorConfig struct {
+	// Config configures the restarts of the failed listeners.
+	supervisor.Config `yaml:",inline"`
+	// HTTPAddr serves the restart counts of the listeners when set.
+	HTTPAddr string `yaml:"http_addr" toml:"http_addr"`
+}
+
+// NetworkConfigs returns the listened networks: the default network first,
+// then the other networks in order.
+func (c *Config) NetworkConfigs() []*NetworkConfig {
+	networks := make([]*NetworkConfig, 0, len(c.Networks)+1)
+	if len(c.Chains) > 0 || len(c.Networks) == 0 {
+		networks = append(networks, &c.NetworkConfig)
+	}
+
+	for i := range c.Networks {
+		networks = append(networks, &c.Networks[i])
+	}
+
+	return networks
+}
+
+// NetworkByName returns the listened network named name, or nil.
+func (c *Config) NetworkByName(name string) *NetworkConfig {
+	for _, network := range c.NetworkConfigs() {
+		if network.Network == name {
+			return network
+		}
+	}
+
+	return nil
+}
+
+// Chain returns the chain named name, or nil.
+func (c *NetworkConfig) Chain(name string) *ChainConfig {
+	for i := range c.Chains {
+		if c.Chains[i].Name == name {
+			return &c.Chains[i]
+		}
+	}
+
+	return nil
+}
+
+// Asset returns the classification of the token of the chain, the configured
+// one first, then the built-in one of the layer.
+func (c *ChainConfig) Asset(address common.Address) (AssetConfig, bool) {
+	for key, asset := range c.Assets {
+		if common.HexToAddress(key) == address {
+			return asset, true
+		}
+	}
+
+	asset, ok := defaultAssets[c.Layer][address]
+	return asset, ok
+}
+
+// LayerChain returns the first chain of the layer, adding a chain named after
+// the layer when there is none. It backs the flags of the single L1/L2 pair.
+func (c *NetworkConfig) LayerChain(layer string) *ChainConfig {
+	for i := range c.Chains {
+		if c.Chains[i].Layer == layer {
+			return &c.Chains[i]
+		}
+	}
+
+	chain := ChainConfig{
+		Name:  layer,
+		Layer: layer,
+	}
+	if layer == types.LayerL2 {
+		chain.SettlesTo = c.LayerChain(types.LayerL1).Name
+	}
+	c.Chains = append(c.Chains, chain)
+
+	return &c.Chains[len(c.Chains)-1]
+}
+
+// Validate reports every invalid field of the config at once, each error
+// prefixed with the path of the field in the config file.
 func (c *Config) Validate() error {
-	if c.L1WsRpc == "" {
-		return errors.New("l1 ws rpc address is required")
+	v := &validator{}
+
+	c.validateNetworks(v)
+
+	switch c.StorageConfig.Type {
+	case "", repository.StorageTypeRedis:
+		v.required("redis.addresses", c.RedisConfig.Addresses)
+	case repository.StorageTypeFile:
+		v.required("storage.file_dir", c.StorageConfig.FileDir)
+	case repository.StorageTypePostgres:
+		v.required("storage.postgres_dsn", c.StorageConfig.PostgresDSN)
+	default:
+		v.add("storage.type", "unknown storage type: %s", c.StorageConfig.Type)
 	}
 
-	if c.L1HttpRpc == "" {
-		return errors.New("l1 http rpc address is required")
+	if c.SupervisorConfig.MinBackoff < 0 || c.SupervisorConfig.MaxBackoff < 0 {
+		v.add("supervisor", "backoffs must not be negative")
 	}
 
-	if c.L2WsRpc == "" {
-		return errors.New("l2 ws rpc address is required")
+	c.validateSinks(v)
+
+	c.AdminConfig.validate(v)
+
+	c.PricesConfig.validate(v)
+
+	c.DigestConfig.validate(v)
+
+	c.AddressBook.validate(v)
+
+	return v.err()
+}
+
+func (c *Config) validateNetworks(v *validator) {
+	names := make(map[string]struct{}, len(c.Networks)+1)
+	if len(c.Chains) > 0 || len(c.Networks) == 0 {
+		c.NetworkConfig.validate(v, "")
+		names[c.Network] = struct{}{}
 	}
 
-	if c.L2HttpRpc == "" {
-		return errors.New("l2 http rpc address is required")
+	for i := range c.Networks {
+		network := &c.Networks[i]
+		prefix := fmt.Sprintf("networks[%d].", i)
+
+		if network.Network == "" {
+			v.add(prefix+"network", "is required")
+		} else if _, ok := names[network.Network]; ok {
+			v.add(prefix+"network", "duplicated network name: %s", network.Network)
+		}
+		names[network.Network] = struct{}{}
+
+		network.validate(v, prefix)
 	}
+}
+
+// validate reports the invalid fields of the network, their paths prefixed
+// with prefix.
+func (c *NetworkConfig) validate(v *validator, prefix string) {
+	c.validateChains(v, prefix)
 
-	if c.L1StandardBridge == "" {
-		return errors.New("l1 standard bridge is required")
+	c.validateNotifiers(v, prefix)
+
+	if c.Thresholds.NotifyRetries < 0 {
+		v.add(prefix+"thresholds.notify_retries", "must not be negative")
 	}
 
-	if c.L2StandardBridge == "" {
-		return errors.New("l2 standard bridge is required")
+	c.validateWhales(v, prefix)
+
+	c.CatchUp.validate(v, prefix+"catch_up")
+
+	c.Watchdog.validate(v, prefix+"watchdog")
+
+	c.Sequencer.validate(v, prefix+"sequencer")
+
+	c.Settlement.validate(v, prefix+"settlement")
+
+	c.validateSolvency(v, prefix)
+}
+
+func (c *NetworkConfig) validateChains(v *validator, prefix string) {
+	if len(c.Chains) == 0 {
+		v.add(prefix+"chains", "at least one chain is required")
 	}
 
-	if c.SlackURL == "" {
-		return errors.New("slack url is required")
+	layers := make(map[string]string, len(c.Chains))
+	for _, chain := range c.Chains {
+		if _, ok := layers[chain.Name]; !ok {
+			layers[chain.Name] = chain.Layer
+		}
 	}
 
-	if len(c.L1TokenAddresses) == 0 {
-		return errors.New("token addresses is required")
+	names := make(map[string]struct{}, len(c.Chains))
+	for i, chain := range c.Chains {
+		path := fmt.Sprintf("%schains[%d]", prefix, i)
+
+		if chain.Name == "" {
+			v.add(path+".name", "is required")
+		} else if _, ok := names[chain.Name]; ok {
+			v.add(path+".name", "duplicated chain name: %s", chain.Name)
+		}
+		names[chain.Name] = struct{}{}
+
+		v.required(path+".ws_rpc", chain.WsRpc)
+		v.required(path+".http_rpc", chain.HttpRpc)
+
+		if chain.HeadTimeout < 0 {
+			v.add(path+".head_timeout", "must not be negative")
+		}
+
+		if chain.BlockTime < 0 {
+			v.add(path+".block_time", "must not be negative")
+		}
+
+		addresses := make([]string, 0, len(chain.Assets))
+		for address := range chain.Assets {
+			addresses = append(addresses, address)
+		}
+		sort.Strings(addresses)
+
+		for _, address := range addresses {
+			asset := chain.Assets[address]
+			assetPath := fmt.Sprintf("%s.assets.%s", path, address)
+
+			if !common.IsHexAddress(address) {
+				v.add(assetPath, "invalid token address")
+			}
+
+			switch asset.Category {
+			case "", types.CategoryNative, types.CategoryWrapped, types.CategoryStablecoin, types.CategoryERC20:
+			default:
+				v.add(assetPath+".category", "unknown category: %s", asset.Category)
+			}
+
+			if asset.Decimals != nil && (*asset.Decimals < 0 || *asset.Decimals > 255) {
+				v.add(assetPath+".decimals", "must be between 0 and 255")
+			}
+		}
+
+		switch chain.Layer {
+		case types.LayerL1:
+		case types.LayerL2:
+			if layer, ok := layers[chain.SettlesTo]; !ok || layer != types.LayerL1 {
+				v.add(path+".settles_to", "unknown l1 chain: %s", chain.SettlesTo)
+			}
+			v.required(path+".bridges.l1_standard", chain.Bridges.L1Standard)
+			v.required(path+".bridges.l2_standard", chain.Bridges.L2Standard)
+
+			if chain.BatchInbox != "" {
+				if !common.IsHexAddress(chain.BatchInbox) {
+					v.add(path+".batch_inbox", "invalid address")
+				}
+
+				v.required(path+".batcher", chain.Batcher)
+				if chain.Batcher != "" && !common.IsHexAddress(chain.Batcher) {
+					v.add(path+".batcher", "invalid address")
+				}
+			}
+
+			if chain.L2OutputOracle != "" && !common.IsHexAddress(chain.L2OutputOracle) {
+				v.add(path+".l2_output_oracle", "invalid address")
+			}
+
+			if chain.OptimismPortal != "" && !common.IsHexAddress(chain.OptimismPortal) {
+				v.add(path+".optimism_portal", "invalid address")
+			}
+		default:
+			v.add(path+".layer", "unknown layer: %s", chain.Layer)
+		}
 	}
+}
 
-	if c.RedisConfig.Addresses == "" {
-		return errors.New("redis address is required")
+type validator struct {
+	errs []error
+}
+
+func (v *validator) add(path string, format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) required(path string, value string) {
+	if value == "" {
+		v.add(path, "is required")
 	}
+}
 
-	return nil
+func (v *validator) err() error {
+	return errors.Join(v.errs...)
 }
@@ -0,0 +1,43 @@
+package thanosnotif
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadConfigFile reads a YAML or TOML config file, by its extension, over
+// cfg. Fields missing in the file keep their values in cfg.
+func LoadConfigFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil {
+			return fmt.Errorf("failed to parse the config file %s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("failed to parse the config file %s: %w", path, err)
+		}
+
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("unknown fields in the config file %s: %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("unsupported config file format: %s", path)
+	}
+
+	return nil
+}
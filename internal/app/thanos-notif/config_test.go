@@ -0,0 +1,386 @@
+package thanosnotif
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/database"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/repository"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+const testYAMLConfig = `
+network: sepolia
+chains:
+  - name: l1
+    layer: l1
+    http_rpc: http://l1:8545
+    ws_rpc: ws://l1:8546
+    explorer_url: https://l1.explorer
+    tokens: ["0xa", "0xb"]
+  - name: l2
+    layer: l2
+    settles_to: l1
+    http_rpc: http://l2:9545
+    ws_rpc: ws://l2:9546
+    bridges:
+      l1_standard: "0x1"
+      l2_standard: "0x2"
+notifiers:
+  - name: slack
+    type: slack
+    url: https://hooks.slack.com/main
+  - name: ops
+    type: slack
+    url: https://hooks.slack.com/ops
+routing:
+  routes:
+    - name: withdrawals
+      match:
+        direction: withdrawal
+      notifiers: [ops]
+redis:
+  addresses: localhost:6379
+  read_timeout: 3s
+`
+
+const testTOMLConfig = `
+network = "sepolia"
+
+[[chains]]
+name = "l1"
+layer = "l1"
+http_rpc = "http://l1:8545"
+ws_rpc = "ws://l1:8546"
+explorer_url = "https://l1.explorer"
+tokens = ["0xa", "0xb"]
+
+[[chains]]
+name = "l2"
+layer = "l2"
+settles_to = "l1"
+http_rpc = "http://l2:9545"
+ws_rpc = "ws://l2:9546"
+
+[chains.bridges]
+l1_standard = "0x1"
+l2_standard = "0x2"
+
+[[notifiers]]
+name = "slack"
+type = "slack"
+url = "https://hooks.slack.com/main"
+
+[[notifiers]]
+name = "ops"
+type = "slack"
+url = "https://hooks.slack.com/ops"
+
+[[routing.routes]]
+name = "withdrawals"
+notifiers = ["ops"]
+
+[routing.routes.match]
+direction = "withdrawal"
+
+[redis]
+addresses = "localhost:6379"
+read_timeout = "3s"
+`
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	testCases := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "yaml", file: "config.yaml", content: testYAMLConfig},
+		{name: "toml", file: "config.toml", content: testTOMLConfig},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{NetworkConfig: NetworkConfig{Network: "mainnet"}}
+
+			require.NoError(t, LoadConfigFile(writeConfigFile(t, tc.file, tc.content), cfg))
+			require.NoError(t, cfg.Validate())
+
+			assert.Equal(t, "sepolia", cfg.Network)
+			require.Len(t, cfg.Chains, 2)
+			assert.Equal(t, "ws://l2:9546", cfg.Chain("l2").WsRpc)
+			assert.Equal(t, "0x1", cfg.Chain("l2").Bridges.L1Standard)
+			assert.Equal(t, "https://l1.explorer", cfg.Chain("l1").ExplorerUrl)
+			assert.Equal(t, []string{"0xa", "0xb"}, cfg.Chain("l1").Tokens)
+			assert.Len(t, cfg.Notifiers, 2)
+			require.Len(t, cfg.Routing.Routes, 1)
+			assert.Equal(t, map[string]string{"direction": "withdrawal"}, cfg.Routing.Routes[0].Match)
+			assert.Equal(t, 3*time.Second, cfg.RedisConfig.ReadTimeout)
+		})
+	}
+}
+
+func TestLoadConfigFile_UnknownField(t *testing.T) {
+	cfg := &Config{}
+
+	err := LoadConfigFile(writeConfigFile(t, "config.yaml", "chains:\n  - name: l3\n    rpc: ws://l3\n"), cfg)
+	require.Error(t, err)
+
+	err = LoadConfigFile(writeConfigFile(t, "config.toml", "[[chains]]\nname = \"l3\"\nrpc = \"ws://l3\"\n"), cfg)
+	require.Error(t, err)
+
+	err = LoadConfigFile(writeConfigFile(t, "config.json", "{}"), cfg)
+	require.Error(t, err)
+}
+
+func TestConfig_SetSlackURL(t *testing.T) {
+	cfg := &Config{}
+	require.NoError(t, LoadConfigFile(writeConfigFile(t, "config.yaml", testYAMLConfig), cfg))
+
+	cfg.SetSlackURL("https://hooks.slack.com/override")
+
+	assert.Len(t, cfg.Notifiers, 2)
+	assert.Equal(t, "https://hooks.slack.com/override", cfg.Notifiers[0].URL)
+
+	cfg = &Config{}
+	cfg.SetSlackURL("https://hooks.slack.com/new")
+	assert.Equal(t, []NotifierConfig{{Name: DefaultNotifierName, Type: NotifierTypeSlack, URL: "https://hooks.slack.com/new"}}, cfg.Notifiers)
+
+	cfg.SetSlackBot("xoxb-token", "C0123")
+	assert.Equal(t, []NotifierConfig{{Name: DefaultNotifierName, Type: NotifierTypeSlack, URL: "https://hooks.slack.com/new", Token: "xoxb-token", Channel: "C0123"}}, cfg.Notifiers)
+}
+
+func TestConfig_LayerChain(t *testing.T) {
+	cfg := &Config{}
+
+	l2 := cfg.LayerChain(types.LayerL2)
+	l2.WsRpc = "ws://l2"
+
+	require.Len(t, cfg.Chains, 2)
+	assert.Equal(t, ChainConfig{Name: "l1", Layer: types.LayerL1}, cfg.Chains[0])
+	assert.Equal(t, ChainConfig{Name: "l2", Layer: types.LayerL2, SettlesTo: "l1", WsRpc: "ws://l2"}, cfg.Chains[1])
+
+	cfg = &Config{NetworkConfig: NetworkConfig{Chains: []ChainConfig{{Name: "ethereum", Layer: types.LayerL1}}}}
+	assert.Equal(t, "ethereum", cfg.LayerChain(types.LayerL2).SettlesTo)
+	assert.Len(t, cfg.Chains, 2)
+	assert.Equal(t, "ethereum", cfg.LayerChain(types.LayerL1).Name)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := &Config{
+		NetworkConfig: NetworkConfig{
+			Chains: []ChainConfig{
+				{Name: "l1", Layer: types.LayerL1, WsRpc: "ws://l1", HttpRpc: "http://l1", Assets: map[string]AssetConfig{
+					"ton": {DisplayName: "TON", Category: "meme"},
+				}},
+				{Name: "l1", Layer: types.LayerL2, SettlesTo: "ethereum", BatchInbox: "inbox"},
+				{Name: "l3", Layer: "l3", WsRpc: "ws://l3", HttpRpc: "http://l3", HeadTimeout: -time.Minute},
+			},
+			Notifiers: []NotifierConfig{
+				{Name: "slack", Type: "slack"},
+				{Name: "slack", Type: "irc", RateLimit: -1, QueueSize: -1, BatchWindow: -time.Second},
+				{Name: "bot", Type: "slack", Token: "xoxb-token"},
+				{Name: "mail", Type: "email"},
+				{Name: "incidents", Type: "pagerduty", MinSeverity: "loud"},
+			},
+			Routing: RoutingConfig{
+				Default: []string{"email"},
+				Routes: []RouteConfig{
+					{Name: "whales", MinSeverity: "huge", Notifiers: []string{"pager"}},
+				},
+			},
+			Whales: WhalesConfig{
+				Tokens:    map[string]float64{"ETH": 0},
+				Notifiers: []string{"pager"},
+				Windows:   []WhaleWindowConfig{{Name: "outflow"}},
+			},
+			Watchlist: map[string]WatchlistConfig{
+				"sanctioned": {Severity: "urgent", Notifiers: []string{"compliance"}},
+			},
+			CatchUp:    CatchUpConfig{Policy: "drop", Freshness: -time.Minute},
+			Watchdog:   WatchdogConfig{Notifiers: []string{"oncall"}, RPCErrors: -1},
+			Sequencer:  SequencerConfig{HaltAfter: -time.Second, Tolerance: -1},
+			Settlement: SettlementConfig{OutputTimeout: -time.Hour},
+			Solvency: SolvencyConfig{Tolerance: -0.1, Tokens: []SolvencyTokenConfig{
+				{Chain: "l3", L2Token: "0xe2"},
+				{Chain: "l1", L1Token: "usdc", L2Token: "usdc"},
+			}},
+		},
+		Networks: []NetworkConfig{
+			{Chains: []ChainConfig{{Name: "l1", Layer: types.LayerL1, WsRpc: "ws://l1", HttpRpc: "http://l1", Tokens: []string{"0xa"}}}},
+		},
+		StorageConfig: repository.StorageConfig{Type: "s3"},
+		AdminConfig:   AdminConfig{HTTPAddr: ":8083", DeadLetters: -1},
+		AddressBook: AddressBookConfig{
+			Entries: []AddressConfig{{Address: "0xnope"}},
+		},
+		DigestConfig: DigestConfig{
+			Periods:       []string{"daily", "monthly"},
+			StuckAfter:    map[string]time.Duration{"deposit": 0, "sideways": time.Hour},
+			MaxPendingAge: -time.Hour,
+		},
+		PricesConfig: PricesConfig{
+			Static: map[string]float64{"ETH": -1},
+			HTTP:   PricesHTTPConfig{URL: "ftp://prices", TTL: -time.Second},
+		},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+
+	for _, expected := range []string{
+		"chains[0].assets.ton: invalid token address",
+		"chains[0].assets.ton.category: unknown category: meme",
+		"chains[1].name: duplicated chain name: l1",
+		"chains[1].ws_rpc: is required",
+		"chains[1].http_rpc: is required",
+		"chains[1].settles_to: unknown l1 chain: ethereum",
+		"chains[1].bridges.l1_standard: is required",
+		"chains[1].bridges.l2_standard: is required",
+		"chains[1].batch_inbox: invalid address",
+		"chains[1].batcher: is required",
+		"chains[2].layer: unknown layer: l3",
+		"chains[2].head_timeout: must not be negative",
+		"notifiers[0].url: is required",
+		"notifiers[1].name: duplicated notifier name: slack",
+		"notifiers[1].type: unknown notifier type: irc",
+		"notifiers[1].rate_limit: must not be negative",
+		"notifiers[1].queue_size: must not be negative",
+		"notifiers[1].batch_window: must not be negative",
+		"notifiers[2].channel: is required",
+		"notifiers[3].smtp.addr: is required",
+		"notifiers[3].smtp.from: is required",
+		"notifiers[3].smtp.to: at least one recipient is required",
+		"notifiers[4].token: is required",
+		"notifiers[4].min_severity: unknown severity: loud",
+		"routing.default[0]: unknown notifier: email",
+		"routing.routes[0].notifiers[0]: unknown notifier: pager",
+		"routing.routes[0].min_severity: unknown severity: huge",
+		"whales.tokens.ETH: must be positive",
+		"whales.windows[0].duration: must be positive",
+		"whales.windows[0].usd: must be positive",
+		"whales.notifiers[0]: unknown notifier: pager",
+		"watchlist.sanctioned.severity: unknown severity: urgent",
+		"watchlist.sanctioned.notifiers[0]: unknown notifier: compliance",
+		"catch_up.policy: unknown catch-up policy: drop",
+		"catch_up.freshness: must not be negative",
+		"watchdog.rpc_errors: must not be negative",
+		"watchdog.notifiers[0]: unknown notifier: oncall",
+		"sequencer.halt_after: must not be negative",
+		"sequencer.tolerance: must not be negative",
+		"settlement.output_timeout: must not be negative",
+		"solvency.tolerance: must not be negative",
+		"solvency.tokens[0].chain: unknown l2 chain: l3",
+		"solvency.tokens[1].l1_token: invalid address",
+		"solvency.tokens[1].l2_token: invalid address",
+		"address_book.entries[0].address: invalid address: 0xnope",
+		"address_book.entries[0]: label or tags are required",
+		"storage.type: unknown storage type: s3",
+		"admin.token: is required",
+		"admin.dead_letters: must not be negative",
+		"networks[0].network: is required",
+		"networks[0].notifiers: at least one notifier is required",
+		"digest.periods[1]: unknown digest period: monthly",
+		"digest.stuck_after.deposit: must be positive",
+		"digest.stuck_after.sideways: unknown direction: sideways",
+		"digest.max_pending_age: must not be negative",
+		"prices.static.ETH: must not be negative",
+		"prices.http.url: must be an http(s) url",
+		"prices.http.ttl: must not be negative",
+	} {
+		assert.Contains(t, err.Error(), expected)
+	}
+}
+
+const testNetworksYAMLConfig = `
+networks:
+  - network: mainnet
+    chains:
+      - name: ethereum
+        layer: l1
+        http_rpc: http://ethereum:8545
+        ws_rpc: ws://ethereum:8546
+        tokens: ["0xa"]
+      - name: thanos
+        layer: l2
+        settles_to: ethereum
+        http_rpc: http://thanos:9545
+        ws_rpc: ws://thanos:9546
+        bridges:
+          l1_standard: "0x1"
+          l2_standard: "0x2"
+    notifiers:
+      - name: slack
+        type: slack
+        url: https://hooks.slack.com/mainnet
+  - network: sepolia
+    chains:
+      - name: ethereum
+        layer: l1
+        http_rpc: http://sepolia:8545
+        ws_rpc: ws://sepolia:8546
+        tokens: ["0xb"]
+      - name: thanos
+        layer: l2
+        settles_to: ethereum
+        http_rpc: http://thanos-sepolia:9545
+        ws_rpc: ws://thanos-sepolia:9546
+        bridges:
+          l1_standard: "0x3"
+          l2_standard: "0x4"
+    notifiers:
+      - name: slack
+        type: slack
+        url: https://hooks.slack.com/sepolia
+redis:
+  addresses: localhost:6379
+`
+
+func TestConfig_Networks(t *testing.T) {
+	cfg := &Config{}
+	require.NoError(t, LoadConfigFile(writeConfigFile(t, "config.yaml", testNetworksYAMLConfig), cfg))
+	require.NoError(t, cfg.Validate())
+
+	// the default network has no chains, so only the listed networks are listened to
+	networks := cfg.NetworkConfigs()
+	require.Len(t, networks, 2)
+	assert.Equal(t, "mainnet", networks[0].Network)
+	assert.Equal(t, "ws://sepolia:8546", cfg.NetworkByName("sepolia").Chain("ethereum").WsRpc)
+	assert.Equal(t, "0x3", cfg.NetworkByName("sepolia").Chain("thanos").Bridges.L1Standard)
+	assert.Nil(t, cfg.NetworkByName("holesky"))
+
+	cfg.Networks[1].Network = "mainnet"
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "networks[1].network: duplicated network name: mainnet")
+
+	// the default network is listened to along the listed networks when it has chains
+	cfg.Networks[1].Network = "sepolia"
+	cfg.NetworkConfig = *cfg.NetworkByName("mainnet")
+	cfg.Network = "holesky"
+	assert.Len(t, cfg.NetworkConfigs(), 3)
+	assert.Equal(t, "holesky", cfg.NetworkConfigs()[0].Network)
+}
+
+func TestConfig_LoggedWithoutSecrets(t *testing.T) {
+	cfg := &Config{
+		StorageConfig: repository.StorageConfig{Type: repository.StorageTypePostgres, PostgresDSN: "postgres://user:storage-secret@db/events"},
+		ArchiveConfig: ArchiveConfig{Database: database.Config{Driver: database.DriverPostgres, DSN: "postgres://user:archive-secret@db/archive"}},
+		AdminConfig:   AdminConfig{HTTPAddr: ":8083", Token: "admin-secret"},
+	}
+
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+}
@@ -1,218 +1,135 @@
 package thanosnotif
 
 import (
-	"fmt"
-
+	"github.com/ethereum/go-ethereum/common"
 	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
-	"github.com/tokamak-network/tokamak-thanos/op-bindings/bindings"
+	"github.com/tokamak-network/tokamak-thanos/op-bindings/predeploys"
 
-	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/erc20"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
 )
 
-func (p *App) depositETHInitiatedEvent(vLog *ethereumTypes.Log) (string, string, error) {
+func (p *App) depositETHInitiatedEvent(r *rollup, vLog *ethereumTypes.Log) (*types.BridgeEvent, error) {
 	log.GetLogger().Infow("Got ETH Deposit Event", "event", vLog)
 
-	l1BridgeFilterer, _, err := p.getBridgeFilterers()
+	l1BridgeFilterer, _, err := p.getBridgeFilterers(r)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	event, err := l1BridgeFilterer.ParseETHDepositInitiated(*vLog)
 	if err != nil {
 		log.GetLogger().Errorw("ETHDepositInitiated event parsing fail", "error", err)
-		return "", "", err
-	}
-
-	ethDep := bindings.L1StandardBridgeETHDepositInitiated{
-		From:   event.From,
-		To:     event.To,
-		Amount: event.Amount,
+		return nil, err
 	}
 
-	Amount := formatAmount(ethDep.Amount, 18)
-
-	// Slack notify title and text
-	title := fmt.Sprintf("[" + p.cfg.Network + "] [ETH Deposit Initialized]")
-	text := fmt.Sprintf("Tx: "+p.cfg.L1ExplorerUrl+"/tx/%s\nFrom: "+p.cfg.L1ExplorerUrl+"/address/%s\nTo: "+p.cfg.L2ExplorerUrl+"/address/%s\nAmount: %s ETH", vLog.TxHash, ethDep.From, ethDep.To, Amount)
+	bridgeEvent := p.newBridgeEvent(vLog, r, r.l1, types.BridgeStandard, types.DirectionDeposit, types.StatusInitiated)
+	// the ETH events carry no token, the L2 events having the ETH predeploy
+	bridgeEvent.L2Token = predeploys.ETHAddr
+	bridgeEvent.From = event.From
+	bridgeEvent.To = event.To
+	bridgeEvent.Amount = event.Amount
+	p.setAsset(bridgeEvent, r.l1, common.Address{})
 
-	return title, text, nil
+	return bridgeEvent, nil
 }
 
-func (p *App) depositERC20InitiatedEvent(vLog *ethereumTypes.Log) (string, string, error) {
+func (p *App) depositERC20InitiatedEvent(r *rollup, vLog *ethereumTypes.Log) (*types.BridgeEvent, error) {
 	log.GetLogger().Infow("Got ERC20 Deposit Event", "event", vLog)
 
-	l1BridgeFilterer, _, err := p.getBridgeFilterers()
+	l1BridgeFilterer, _, err := p.getBridgeFilterers(r)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	event, err := l1BridgeFilterer.ParseERC20DepositInitiated(*vLog)
 	if err != nil {
 		log.GetLogger().Errorw("ERC20DepositInitiated event parsing fail", "error", err)
-		return "", "", err
-	}
-
-	erc20Dep := bindings.L1StandardBridgeERC20DepositInitiated{
-		L1Token: event.L1Token,
-		L2Token: event.L2Token,
-		From:    event.From,
-		To:      event.To,
-		Amount:  event.Amount,
-	}
-
-	// get symbol and decimals
-	l1Token := erc20Dep.L1Token
-	l1TokenInfo, found := p.l1TokensInfo[l1Token.Hex()]
-	if !found {
-		newToken, err := erc20.FetchTokenInfo(p.l1Client, l1Token.Hex())
-		if err != nil || newToken == nil {
-			log.GetLogger().Errorw("Token info not found for address", "l1Token", l1Token.Hex())
-			return "", "", err
-		}
-		l1TokenInfo = newToken
-		p.mu.Lock()
-		p.l1TokensInfo[l1Token.Hex()] = l1TokenInfo
-		p.mu.Unlock()
+		return nil, err
 	}
 
-	tokenSymbol := l1TokenInfo.Symbol
-	tokenDecimals := l1TokenInfo.Decimals
-
-	amount := formatAmount(erc20Dep.Amount, tokenDecimals)
-
-	// Slack notify title and text
-	var title string
-
-	isTON := l1TokenInfo.Symbol == "TON"
+	bridgeEvent := p.newBridgeEvent(vLog, r, r.l1, types.BridgeStandard, types.DirectionDeposit, types.StatusInitiated)
+	bridgeEvent.L1Token = event.L1Token
+	bridgeEvent.L2Token = event.L2Token
+	bridgeEvent.From = event.From
+	bridgeEvent.To = event.To
+	bridgeEvent.Amount = event.Amount
+	p.setAsset(bridgeEvent, r.l1, event.L1Token)
 
-	if isTON {
-		title = fmt.Sprintf("[" + p.cfg.Network + "] [TON Deposit Initialized]")
-	} else {
-		title = fmt.Sprintf("[" + p.cfg.Network + "] [ERC-20 Deposit Initialized]")
-	}
-	text := fmt.Sprintf("Tx: "+p.cfg.L1ExplorerUrl+"/tx/%s\nFrom: "+p.cfg.L1ExplorerUrl+"/address/%s\nTo: "+p.cfg.L2ExplorerUrl+"/address/%s\nL1Token: "+p.cfg.L1ExplorerUrl+"/token/%s\nL2Token: "+p.cfg.L2ExplorerUrl+"/token/%s\nAmount: %s %s", vLog.TxHash, erc20Dep.From, erc20Dep.To, erc20Dep.L1Token, erc20Dep.L2Token, amount, tokenSymbol)
-
-	return title, text, nil
+	return bridgeEvent, nil
 }
 
-func (p *App) depositFinalizedEvent(vLog *ethereumTypes.Log) (string, string, error) {
+func (p *App) depositFinalizedEvent(r *rollup, vLog *ethereumTypes.Log) (*types.BridgeEvent, error) {
 	log.GetLogger().Infow("Got L2 Deposit Event", "event", vLog)
 
-	_, l2BridgeFilterer, err := p.getBridgeFilterers()
+	_, l2BridgeFilterer, err := p.getBridgeFilterers(r)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	event, err := l2BridgeFilterer.ParseDepositFinalized(*vLog)
 	if err != nil {
 		log.GetLogger().Errorw("DepositFinalized event parsing fail", "error", err)
-		return "", "", err
+		return nil, err
 	}
 
-	l2Dep := bindings.L2StandardBridgeDepositFinalized{
-		L1Token: event.L1Token,
-		L2Token: event.L2Token,
-		From:    event.From,
-		To:      event.To,
-		Amount:  event.Amount,
-	}
+	bridgeEvent := p.newBridgeEvent(vLog, r, r.l2, types.BridgeStandard, types.DirectionDeposit, types.StatusFinalized)
+	bridgeEvent.L1Token = event.L1Token
+	bridgeEvent.L2Token = event.L2Token
+	bridgeEvent.From = event.From
+	bridgeEvent.To = event.To
+	bridgeEvent.Amount = event.Amount
+	p.setAsset(bridgeEvent, r.l2, event.L2Token)
 
-	// get symbol and decimals
-	l2Token := l2Dep.L2Token
-
-	l2TokenInfo, found := p.l2TokensInfo[l2Token.Hex()]
-	if !found {
-		newToken, err := erc20.FetchTokenInfo(p.l2Client, l2Token.Hex())
-		if err != nil || newToken == nil {
-			log.GetLogger().Errorw("Token info not found for address", "l2Token", l2Token.Hex())
-			return "", "", err
-		}
-		l2TokenInfo = newToken
-		p.mu.Lock()
-		p.l2TokensInfo[l2Token.Hex()] = l2TokenInfo
-		p.mu.Unlock()
-	}
-
-	amount := formatAmount(l2Dep.Amount, l2TokenInfo.Decimals)
-
-	var title string
-	var text string
-
-	isETH := l2TokenInfo.Symbol == "ETH"
-	isTON := l2TokenInfo.Symbol == "TON"
-
-	if isETH {
-		title = fmt.Sprintf("[" + p.cfg.Network + "] [ETH Deposit Finalized]")
-		text = fmt.Sprintf("Tx: "+p.cfg.L2ExplorerUrl+"/tx/%s\nFrom: "+p.cfg.L1ExplorerUrl+"/address/%s\nTo: "+p.cfg.L2ExplorerUrl+"/address/%s\nL1Token: ETH\nL2Token: "+p.cfg.L2ExplorerUrl+"/token/%s\nAmount: %s %s", vLog.TxHash, l2Dep.From, l2Dep.To, l2Dep.L2Token, amount, l2TokenInfo.Symbol)
-	} else if isTON {
-		title = fmt.Sprintf("[" + p.cfg.Network + "] [TON Deposit Finalized]")
-		text = fmt.Sprintf("Tx: "+p.cfg.L2ExplorerUrl+"/tx/%s\nFrom: "+p.cfg.L1ExplorerUrl+"/address/%s\nTo: "+p.cfg.L2ExplorerUrl+"/address/%s\nL1Token: "+p.cfg.L1ExplorerUrl+"/token/%s\nL2Token: "+p.cfg.L2ExplorerUrl+"/token/%s\nAmount: %s %s", vLog.TxHash, l2Dep.From, l2Dep.To, l2Dep.L1Token, l2Dep.L2Token, amount, l2TokenInfo.Symbol)
-	} else {
-		title = fmt.Sprintf("[" + p.cfg.Network + "] [ERC-20 Deposit Finalized]")
-		text = fmt.Sprintf("Tx: "+p.cfg.L2ExplorerUrl+"/tx/%s\nFrom: "+p.cfg.L1ExplorerUrl+"/address/%s\nTo: "+p.cfg.L2ExplorerUrl+"/address/%s\nL1Token: "+p.cfg.L1ExplorerUrl+"/token/%s\nL2Token: "+p.cfg.L2ExplorerUrl+"/token/%s\nAmount: %s %s", vLog.TxHash, l2Dep.From, l2Dep.To, l2Dep.L1Token, l2Dep.L2Token, amount, l2TokenInfo.Symbol)
-	}
-
-	return title, text, nil
+	return bridgeEvent, nil
 }
 
-func (p *App) depositUsdcInitiatedEvent(vLog *ethereumTypes.Log) (string, string, error) {
+func (p *App) depositUsdcInitiatedEvent(r *rollup, vLog *ethereumTypes.Log) (*types.BridgeEvent, error) {
 	log.GetLogger().Infow("Got L1 USDC Deposit Event", "event", vLog)
 
-	l1UsdcBridgeFilterer, _, err := p.getUSDCBridgeFilterers()
+	l1UsdcBridgeFilterer, _, err := p.getUSDCBridgeFilterers(r)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	event, err := l1UsdcBridgeFilterer.ParseERC20DepositInitiated(*vLog)
 	if err != nil {
 		log.GetLogger().Errorw("USDC DepositInitiated event parsing fail", "error", err)
-		return "", "", err
+		return nil, err
 	}
 
-	l1UsdcDep := bindings.L1UsdcBridgeERC20DepositInitiated{
-		L1Token: event.L1Token,
-		L2Token: event.L2Token,
-		From:    event.From,
-		To:      event.To,
-		Amount:  event.Amount,
-	}
-
-	amount := formatAmount(l1UsdcDep.Amount, 6)
-
-	// Slack notify title and text
-	title := fmt.Sprintf("[" + p.cfg.Network + "] [USDC Deposit Initialized]")
-	text := fmt.Sprintf("Tx: "+p.cfg.L1ExplorerUrl+"/tx/%s\nFrom: "+p.cfg.L1ExplorerUrl+"/address/%s\nTo: "+p.cfg.L2ExplorerUrl+"/address/%s\nL1Token: "+p.cfg.L1ExplorerUrl+"/token/%s\nL2Token: "+p.cfg.L2ExplorerUrl+"/token/%s\namount: %s USDC", vLog.TxHash, l1UsdcDep.From, l1UsdcDep.To, l1UsdcDep.L1Token, l1UsdcDep.L2Token, amount)
+	bridgeEvent := p.newBridgeEvent(vLog, r, r.l1, types.BridgeUsdc, types.DirectionDeposit, types.StatusInitiated)
+	bridgeEvent.L1Token = event.L1Token
+	bridgeEvent.L2Token = event.L2Token
+	bridgeEvent.From = event.From
+	bridgeEvent.To = event.To
+	bridgeEvent.Amount = event.Amount
+	p.setAsset(bridgeEvent, r.l1, event.L1Token)
 
-	return title, text, nil
+	return bridgeEvent, nil
 }
 
-func (p *App) depositUsdcFinalizedEvent(vLog *ethereumTypes.Log) (string, string, error) {
+func (p *App) depositUsdcFinalizedEvent(r *rollup, vLog *ethereumTypes.Log) (*types.BridgeEvent, error) {
 	log.GetLogger().Infow("Got L2 USDC Deposit Event", "event", vLog)
 
-	_, l2UsdcBridgeFilterer, err := p.getUSDCBridgeFilterers()
+	_, l2UsdcBridgeFilterer, err := p.getUSDCBridgeFilterers(r)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	event, err := l2UsdcBridgeFilterer.ParseDepositFinalized(*vLog)
 	if err != nil {
 		log.GetLogger().Errorw("USDC DepositFinalized event parsing fail", "error", err)
-		return "", "", err
+		return nil, err
 	}
 
-	l2UsdcDep := bindings.L2UsdcBridgeDepositFinalized{
-		L1Token: event.L1Token,
-		L2Token: event.L2Token,
-		From:    event.From,
-		To:      event.To,
-		Amount:  event.Amount,
-	}
-
-	amount := formatAmount(l2UsdcDep.Amount, 6)
-
-	title := fmt.Sprintf("[" + p.cfg.Network + "] [USDC Deposit Finalized]")
-	text := fmt.Sprintf("Tx: "+p.cfg.L2ExplorerUrl+"/tx/%s\nFrom: "+p.cfg.L1ExplorerUrl+"/address/%s\nTo: "+p.cfg.L2ExplorerUrl+"/address/%s\nL1Token: "+p.cfg.L1ExplorerUrl+"/token/%s\nL2Token: "+p.cfg.L2ExplorerUrl+"/token/%s\nAmount: %s USDC", vLog.TxHash, l2UsdcDep.From, l2UsdcDep.To, l2UsdcDep.L1Token, l2UsdcDep.L2Token, amount)
+	bridgeEvent := p.newBridgeEvent(vLog, r, r.l2, types.BridgeUsdc, types.DirectionDeposit, types.StatusFinalized)
+	bridgeEvent.L1Token = event.L1Token
+	bridgeEvent.L2Token = event.L2Token
+	bridgeEvent.From = event.From
+	bridgeEvent.To = event.To
+	bridgeEvent.Amount = event.Amount
+	p.setAsset(bridgeEvent, r.l2, event.L2Token)
 
-	return title, text, nil
+	return bridgeEvent, nil
 }
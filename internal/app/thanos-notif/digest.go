@@ -0,0 +1,282 @@
+package thanosnotif
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/digest"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	digestAlert = "digest"
+	stuckAlert  = "stuck"
+
+	digestFlushInterval = time.Minute
+	maxDigestStuck      = 10
+)
+
+// DigestConfig sends periodic reports of the bridge events of every network,
+// disabled when no period is configured.
+type DigestConfig struct {
+	// Periods are hourly, daily or weekly.
+	Periods []string `yaml:"periods" toml:"periods"`
+	// Largest is the number of the largest transfers of the reports.
+	Largest int `yaml:"largest" toml:"largest"`
+	// StuckAfter is the duration after which an initiated deposit or
+	// withdrawal which isn't finalized is reported stuck, by direction.
+	StuckAfter map[string]time.Duration `yaml:"stuck_after" toml:"stuck_after"`
+	// MaxPendingAge is the age after which a transfer which isn't finalized
+	// is no longer tracked, 720h by default.
+	MaxPendingAge time.Duration `yaml:"max_pending_age" toml:"max_pending_age"`
+}
+
+// initDigest creates the digest of the network, persisted in Redis when Redis
+// is configured.
+func (p *App) initDigest(ctx context.Context, n *network) error {
+	cfg := p.config().DigestConfig
+	if len(cfg.Periods) == 0 {
+		return nil
+	}
+
+	digestCfg := digest.Config{
+		Largest:       cfg.Largest,
+		StuckAfter:    cfg.StuckAfter,
+		MaxPendingAge: cfg.MaxPendingAge,
+	}
+	for _, name := range cfg.Periods {
+		period, err := digest.ParsePeriod(name)
+		if err != nil {
+			return err
+		}
+		digestCfg.Periods = append(digestCfg.Periods, period)
+	}
+
+	var store digest.Store
+	if p.config().RedisConfig.Addresses != "" {
+		redisClient, err := p.getRedisClient(ctx)
+		if err != nil {
+			return err
+		}
+		store = digest.NewRedisStore(redisClient, n.name)
+	} else {
+		log.GetLogger().Warnw("The digest is kept in memory only without redis", "network", n.name)
+	}
+
+	d, err := digest.New(ctx, digestCfg, store)
+	if err != nil {
+		log.GetLogger().Errorw("Failed to restore the digest", "error", err, "network", n.name)
+		return err
+	}
+	n.digest = d
+
+	return nil
+}
+
+func (p *App) addToDigest(n *network, event *types.BridgeEvent) {
+	if n.digest == nil {
+		return
+	}
+
+	n.digest.Add(event)
+}
+
+// runDigests sends the reports of the ended periods until ctx is done.
+func (p *App) runDigests(ctx context.Context) error {
+	ticker := time.NewTicker(digestFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		p.flushDigests(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *App) flushDigests(ctx context.Context, now time.Time) {
+	for _, n := range p.networks {
+		if n.digest == nil {
+			continue
+		}
+
+		reports, err := n.digest.Flush(ctx, now)
+		if err != nil {
+			log.GetLogger().Errorw("Failed to save the digest", "error", err, "network", n.name)
+		}
+
+		for _, report := range reports {
+			if err := n.notifier.Notify(p.digestMessage(n, report)); err != nil {
+				log.GetLogger().Errorw("Failed to notify the digest", "error", err, "network", n.name, "period", report.Period)
+			}
+		}
+
+		p.alertStuck(ctx, n, now)
+	}
+}
+
+// alertStuck notifies the transfers which became stuck, in the threads of
+// their initiation, and resolves the alerts of the stuck transfers finalized
+// since.
+func (p *App) alertStuck(ctx context.Context, n *network, now time.Time) {
+	stuck, recovered, err := n.digest.Stuck(ctx, now)
+	if err != nil {
+		log.GetLogger().Errorw("Failed to save the digest", "error", err, "network", n.name)
+	}
+
+	for _, transfer := range stuck {
+		msg := stuckMessage(n, transfer)
+		msg.Title = fmt.Sprintf("[%s] [%s %s Stuck]", n.name, transfer.Asset, directionName(transfer.Direction))
+		msg.Text = fmt.Sprintf("%s initiated at %s isn't finalized", formatTransfer(transfer), transfer.Time.UTC().Format("2006-01-02 15:04 MST"))
+
+		if err := n.notifier.Notify(msg); err != nil {
+			log.GetLogger().Errorw("Failed to notify the stuck transfer", "error", err, "network", n.name, "tx", transfer.TxHash)
+		}
+	}
+
+	for _, transfer := range recovered {
+		msg := stuckMessage(n, transfer)
+		msg.Title = fmt.Sprintf("[%s] [%s %s Unstuck]", n.name, transfer.Asset, directionName(transfer.Direction))
+		msg.Text = fmt.Sprintf("%s initiated at %s is finalized", formatTransfer(transfer), transfer.Time.UTC().Format("2006-01-02 15:04 MST"))
+		msg.Resolved = true
+
+		if err := n.notifier.Notify(msg); err != nil {
+			log.GetLogger().Errorw("Failed to notify the unstuck transfer", "error", err, "network", n.name, "tx", transfer.TxHash)
+		}
+	}
+}
+
+// stuckMessage returns the message of the stuck alert of the transfer, whose
+// resolution has the same severity so that it takes the same routes.
+func stuckMessage(n *network, transfer digest.Transfer) *notification.Message {
+	thread := transferThread(n.name, transfer.TransferKey)
+
+	msg := &notification.Message{
+		Severity: notification.SeverityWarning,
+		Attributes: map[string]string{
+			"network":   n.name,
+			"direction": transfer.Direction,
+			"asset":     transfer.Asset,
+			"alert":     stuckAlert,
+		},
+		Thread: thread,
+	}
+	if thread != "" {
+		msg.DedupKey = "stuck:" + thread
+	}
+
+	return msg
+}
+
+func (p *App) digestMessage(n *network, report *digest.Report) *notification.Message {
+	var (
+		text  strings.Builder
+		total float64
+	)
+
+	fmt.Fprintf(&text, "Deposits: %d, Withdrawals: %d, Finalized: %d\n", report.Deposits, report.Withdrawals, report.Finalized)
+	fmt.Fprintf(&text, "Unique addresses: %d\n", len(report.Addresses))
+
+	if volumes := report.SortedVolumes(); len(volumes) > 0 {
+		text.WriteString("Volume:\n")
+		for _, volume := range volumes {
+			fmt.Fprintf(&text, "- %s %s %s%s in %d transfers\n", directionName(volume.Direction), formatAmount(volume.Amount, volume.Decimals), volume.Asset, formatVolumeValue(volume.ValueUSD), volume.Count)
+			total += volume.ValueUSD
+		}
+	}
+
+	if len(report.Largest) > 0 {
+		text.WriteString("Largest transfers:\n")
+		for _, transfer := range report.Largest {
+			fmt.Fprintf(&text, "- %s\n", formatTransfer(transfer))
+		}
+	}
+
+	fmt.Fprintf(&text, "Pending: %d, Stuck: %d", report.Pending, len(report.Stuck))
+	for i, transfer := range report.Stuck {
+		if i == maxDigestStuck {
+			fmt.Fprintf(&text, "\n- and %d more", len(report.Stuck)-maxDigestStuck)
+			break
+		}
+		fmt.Fprintf(&text, "\n- %s since %s", formatTransfer(transfer), transfer.Time.UTC().Format("2006-01-02 15:04 MST"))
+	}
+
+	return &notification.Message{
+		Title:    fmt.Sprintf("[%s] [%s]", n.name, digestTitle(report)),
+		Text:     text.String(),
+		Severity: notification.SeverityInfo,
+		Attributes: map[string]string{
+			"network": n.name,
+			"alert":   digestAlert,
+			"period":  string(report.Period),
+		},
+		// not value_usd, which would match the large transfer routes
+		Values: map[string]float64{
+			"digest_value_usd": total,
+		},
+	}
+}
+
+// formatVolumeValue formats the USD value of a volume, empty when none of its
+// transfers has a price.
+func formatVolumeValue(value float64) string {
+	if value == 0 {
+		return ""
+	}
+
+	return formatValue(&value)
+}
+
+func digestTitle(report *digest.Report) string {
+	switch report.Period {
+	case digest.PeriodHourly:
+		return "Hourly Digest " + report.Start.Format("2006-01-02 15:04 MST")
+	case digest.PeriodWeekly:
+		return "Weekly Digest " + report.Start.Format("2006-01-02")
+	default:
+		return "Daily Digest " + report.Start.Format("2006-01-02")
+	}
+}
+
+func formatTransfer(transfer digest.Transfer) string {
+	return fmt.Sprintf("%s %s %s%s tx %s", directionName(transfer.Direction), formatAmount(transfer.Amount, transfer.Decimals), transfer.Asset, formatValue(transfer.ValueUSD), transfer.TxHash)
+}
+
+func (c *DigestConfig) validate(v *validator) {
+	for i, period := range c.Periods {
+		if _, err := digest.ParsePeriod(period); err != nil {
+			v.add(fmt.Sprintf("digest.periods[%d]", i), "%s", err)
+		}
+	}
+
+	if c.Largest < 0 {
+		v.add("digest.largest", "must not be negative")
+	}
+
+	if c.MaxPendingAge < 0 {
+		v.add("digest.max_pending_age", "must not be negative")
+	}
+
+	directions := make([]string, 0, len(c.StuckAfter))
+	for direction := range c.StuckAfter {
+		directions = append(directions, direction)
+	}
+	sort.Strings(directions)
+
+	for _, direction := range directions {
+		path := "digest.stuck_after." + direction
+		if direction != types.DirectionDeposit && direction != types.DirectionWithdrawal {
+			v.add(path, "unknown direction: %s", direction)
+		} else if c.StuckAfter[direction] <= 0 {
+			v.add(path, "must be positive")
+		}
+	}
+}
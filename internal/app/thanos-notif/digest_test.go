@@ -0,0 +1,101 @@
+package thanosnotif
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+func TestApp_flushDigests(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := &Config{
+		NetworkConfig: NetworkConfig{
+			Network:   "sepolia",
+			Chains:    testChainsConfig(),
+			Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"}},
+		},
+		PricesConfig: PricesConfig{Static: map[string]float64{"ETH": 3000}},
+		DigestConfig: DigestConfig{Periods: []string{"daily"}},
+	}
+	app := newTestApp(t, cfg)
+
+	n := testNetwork(app, "sepolia")
+	require.NoError(t, app.initDigest(ctx, n))
+
+	slack := &testSender{}
+	require.NoError(t, n.notifier.Update(map[string]notification.Sender{"slack": slack}, []string{"slack"}, nil))
+
+	day := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	app.flushDigests(ctx, day)
+
+	for i, eth := range []int64{2, 10} {
+		event := &types.BridgeEvent{
+			Network:   "sepolia",
+			L2ChainID: 111551,
+			Bridge:    types.BridgeStandard,
+			Direction: types.DirectionDeposit,
+			Status:    types.StatusInitiated,
+			TxHash:    common.BigToHash(big.NewInt(int64(i + 1))),
+			From:      common.HexToAddress("0x01"),
+			To:        common.HexToAddress("0x02"),
+			Amount:    new(big.Int).Mul(big.NewInt(eth), big.NewInt(1e18)),
+			BlockTime: day.Add(time.Duration(i) * time.Hour),
+		}
+		app.setAsset(event, n.chains["l1"], common.Address{})
+		app.addToDigest(n, event)
+	}
+
+	// the deposits are stuck after an hour, alerted once in their threads
+	app.flushDigests(ctx, day.Add(12*time.Hour))
+	require.Len(t, slack.messages, 2)
+	assert.Equal(t, "[sepolia] [ETH Deposit Stuck]", slack.messages[0].Title)
+	assert.Equal(t, stuckAlert, slack.messages[0].Attributes["alert"])
+	assert.Equal(t, "sepolia:111551:standard:deposit:0x0000000000000000000000000000000000000000:0x0000000000000000000000000000000000000000:0x0000000000000000000000000000000000000001:0x0000000000000000000000000000000000000002:2000000000000000000", slack.messages[0].Thread)
+	assert.Contains(t, slack.messages[1].Text, "Deposit 10 ETH (~$30,000.00) tx ")
+	assert.Equal(t, "stuck:"+slack.messages[0].Thread, slack.messages[0].DedupKey)
+
+	app.flushDigests(ctx, day.Add(24*time.Hour))
+	require.Len(t, slack.messages, 3)
+
+	msg := slack.messages[2]
+	assert.Equal(t, "[sepolia] [Daily Digest 2024-01-03]", msg.Title)
+	assert.Equal(t, digestAlert, msg.Attributes["alert"])
+	assert.Equal(t, 36000.0, msg.Values["digest_value_usd"])
+	assert.NotContains(t, msg.Values, "value_usd")
+	assert.Contains(t, msg.Text, "Deposits: 2, Withdrawals: 0, Finalized: 0\n")
+	assert.Contains(t, msg.Text, "Unique addresses: 2\n")
+	assert.Contains(t, msg.Text, "- Deposit 12 ETH (~$36,000.00) in 2 transfers\n")
+	assert.Contains(t, msg.Text, "Largest transfers:\n- Deposit 10 ETH (~$30,000.00) tx ")
+	assert.Contains(t, msg.Text, "Pending: 2, Stuck: 2\n- Deposit 2 ETH (~$6,000.00) tx ")
+
+	// the finalization of a stuck deposit resolves its alert
+	finalized := &types.BridgeEvent{
+		Network:   "sepolia",
+		Layer:     types.LayerL2,
+		L2ChainID: 111551,
+		Bridge:    types.BridgeStandard,
+		Direction: types.DirectionDeposit,
+		Status:    types.StatusFinalized,
+		TxHash:    common.BigToHash(big.NewInt(10)),
+		From:      common.HexToAddress("0x01"),
+		To:        common.HexToAddress("0x02"),
+		Amount:    new(big.Int).Mul(big.NewInt(2), big.NewInt(1e18)),
+		BlockTime: day.Add(25 * time.Hour),
+	}
+	app.addToDigest(n, finalized)
+
+	app.flushDigests(ctx, day.Add(25*time.Hour))
+	require.Len(t, slack.messages, 4)
+	assert.Equal(t, "[sepolia] [ETH Deposit Unstuck]", slack.messages[3].Title)
+	assert.True(t, slack.messages[3].Resolved)
+	assert.Equal(t, slack.messages[0].DedupKey, slack.messages[3].DedupKey)
+}
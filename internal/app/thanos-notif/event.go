@@ -0,0 +1,392 @@
+package thanosnotif
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/listener"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/price"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/token"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	eventProcessTimeout = 10 * time.Second
+)
+
+// EventSink receives every decoded bridge event before it is notified.
+type EventSink interface {
+	Write(ctx context.Context, event *types.BridgeEvent) error
+}
+
+// ReorgSink is an EventSink which can retract the events of a re-organized block.
+type ReorgSink interface {
+	Retract(ctx context.Context, chainID uint64, removedBlockHash common.Hash, newHeader *ethereumTypes.Header) error
+}
+
+type bridgeEventDecoder func(r *rollup, vLog *ethereumTypes.Log) (*types.BridgeEvent, error)
+
+func (p *App) AddEventSink(sink EventSink) {
+	p.sinks = append(p.sinks, sink)
+}
+
+func (p *App) bridgeEventHandler(r *rollup, decode bridgeEventDecoder) listener.EventHandler {
+	return func(vLog *ethereumTypes.Log) (*notification.Message, error) {
+		event, err := decode(r, vLog)
+		if err != nil {
+			return nil, err
+		}
+
+		p.setLabels(event)
+
+		p.writeEventSinks(event)
+		p.addToDigest(r.network(), event)
+
+		if p.catchUp(r, event) {
+			return nil, nil
+		}
+
+		title, text := p.formatBridgeEvent(r, event)
+		fields, links := p.bridgeEventFields(r, event)
+
+		msg := &notification.Message{
+			Title:        title,
+			Text:         text,
+			Severity:     notification.SeverityInfo,
+			Attributes:   bridgeEventAttributes(event),
+			Values:       bridgeEventValues(event),
+			Fields:       fields,
+			Links:        links,
+			Thread:       transferThread(event.Network, event.TransferKey()),
+			StartsThread: event.Status == types.StatusInitiated,
+		}
+		p.checkWhale(r.network(), event, msg)
+		p.checkWatchlist(r.network(), event, msg)
+
+		// the events skipped by a paused network aren't retracted
+		if !r.network().notifier.Skips(msg) {
+			p.trackNotified(eventChain(r, event), event, msg)
+		}
+
+		return msg, nil
+	}
+}
+
+// bridgeEventAttributes are the fields of the event the notification routes
+// can match on.
+func bridgeEventAttributes(event *types.BridgeEvent) map[string]string {
+	attributes := map[string]string{
+		"network":   event.Network,
+		"layer":     event.Layer,
+		"chain_id":  strconv.FormatUint(event.ChainID, 10),
+		"bridge":    event.Bridge,
+		"direction": event.Direction,
+		"status":    event.Status,
+		"symbol":    event.Symbol,
+		"asset":     event.Asset,
+		"category":  event.Category,
+	}
+
+	if len(event.Tags) > 0 {
+		attributes["tags"] = strings.Join(event.Tags, ",")
+	}
+	for _, tag := range event.Tags {
+		attributes[tagAttribute(tag)] = "true"
+	}
+
+	return attributes
+}
+
+// bridgeEventValues are the values of the event the notification routes can
+// set minimums of.
+func bridgeEventValues(event *types.BridgeEvent) map[string]float64 {
+	values := make(map[string]float64, 1)
+	if event.ValueUSD != nil {
+		values["value_usd"] = *event.ValueUSD
+	}
+
+	return values
+}
+
+func (p *App) writeEventSinks(event *types.BridgeEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), eventProcessTimeout)
+	defer cancel()
+
+	for _, sink := range p.sinks {
+		if err := sink.Write(ctx, event); err != nil {
+			log.GetLogger().Errorw("Failed to write the bridge event to the sink", "error", err, "tx", event.TxHash, "log_index", event.LogIndex)
+		}
+	}
+}
+
+// newBridgeEvent returns the event of vLog emitted on the chain c of the
+// rollup r.
+func (p *App) newBridgeEvent(vLog *ethereumTypes.Log, r *rollup, c *chain, bridge, direction, status string) *types.BridgeEvent {
+	event := &types.BridgeEvent{
+		Network:     c.network.name,
+		Layer:       c.layer,
+		ChainID:     c.client.ChainID().Uint64(),
+		L2ChainID:   r.l2.chainID,
+		Bridge:      bridge,
+		Direction:   direction,
+		Status:      status,
+		BlockNumber: vLog.BlockNumber,
+		BlockHash:   vLog.BlockHash,
+		TxHash:      vLog.TxHash,
+		LogIndex:    vLog.Index,
+		BlockTime:   time.Now().UTC(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), eventProcessTimeout)
+	defer cancel()
+
+	header, err := c.client.HeaderAtBlockHash(ctx, vLog.BlockHash)
+	if err != nil {
+		log.GetLogger().Warnw("Failed to get the block time of the event, use the current time", "error", err, "block", vLog.BlockHash)
+		return event
+	}
+	event.BlockTime = time.Unix(int64(header.Time), 0).UTC()
+
+	return event
+}
+
+// eventExplorers are the explorer urls of the chains of an event.
+type eventExplorers struct {
+	l1, l2, tx, from, to string
+}
+
+func (p *App) eventExplorers(r *rollup, event *types.BridgeEvent) eventExplorers {
+	e := eventExplorers{
+		l1: p.explorerUrl(r.l1),
+		l2: p.explorerUrl(r.l2),
+	}
+	e.tx, e.from, e.to = e.l1, e.l1, e.l2
+
+	if event.Layer == types.LayerL2 {
+		e.tx = e.l2
+	}
+
+	if event.Direction == types.DirectionWithdrawal {
+		e.from, e.to = e.l2, e.l1
+	}
+
+	return e
+}
+
+func (p *App) formatBridgeEvent(r *rollup, event *types.BridgeEvent) (string, string) {
+	explorers := p.eventExplorers(r, event)
+
+	title := fmt.Sprintf("[%s] [%s %s %s]", p.rollupLabel(r), assetLabel(event), directionLabel(event), statusLabel(event))
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Tx: %s/tx/%s\n", explorers.tx, event.TxHash)
+	fmt.Fprintf(&text, "From: %s/address/%s%s\n", explorers.from, event.From, formatLabel(event.FromLabel))
+	fmt.Fprintf(&text, "To: %s/address/%s%s\n", explorers.to, event.To, formatLabel(event.ToLabel))
+	if len(event.Tags) > 0 {
+		fmt.Fprintf(&text, "Watchlist: %s\n", strings.Join(event.Tags, ", "))
+	}
+
+	zeroAddress := common.Address{}
+	if event.L1Token != zeroAddress || event.L2Token != zeroAddress {
+		if event.L1Token == zeroAddress {
+			text.WriteString("L1Token: ETH\n")
+		} else {
+			fmt.Fprintf(&text, "L1Token: %s/token/%s\n", explorers.l1, event.L1Token)
+		}
+		fmt.Fprintf(&text, "L2Token: %s/token/%s\n", explorers.l2, event.L2Token)
+	}
+
+	fmt.Fprintf(&text, "Amount: %s %s%s", formatAmount(event.Amount, event.Decimals), event.Symbol, formatValue(event.ValueUSD))
+
+	return title, text.String()
+}
+
+// bridgeEventFields returns the structured content of the notification of
+// the event: the amount, addresses and tokens linked to the explorers, and a
+// button to the transaction.
+func (p *App) bridgeEventFields(r *rollup, event *types.BridgeEvent) ([]notification.Field, []notification.Link) {
+	explorers := p.eventExplorers(r, event)
+
+	fields := []notification.Field{
+		{Name: "Amount", Value: fmt.Sprintf("%s %s%s", formatAmount(event.Amount, event.Decimals), event.Symbol, formatValue(event.ValueUSD))},
+		{Name: "From", Value: event.From.Hex() + formatLabel(event.FromLabel), URL: explorerLink(explorers.from, "address", event.From.Hex())},
+		{Name: "To", Value: event.To.Hex() + formatLabel(event.ToLabel), URL: explorerLink(explorers.to, "address", event.To.Hex())},
+	}
+
+	zeroAddress := common.Address{}
+	if event.L1Token != zeroAddress || event.L2Token != zeroAddress {
+		l1Token := notification.Field{Name: "L1 Token", Value: "ETH"}
+		if event.L1Token != zeroAddress {
+			l1Token.Value = event.L1Token.Hex()
+			l1Token.URL = explorerLink(explorers.l1, "token", event.L1Token.Hex())
+		}
+		fields = append(fields, l1Token, notification.Field{
+			Name:  "L2 Token",
+			Value: event.L2Token.Hex(),
+			URL:   explorerLink(explorers.l2, "token", event.L2Token.Hex()),
+		})
+	}
+
+	if len(event.Tags) > 0 {
+		fields = append(fields, notification.Field{Name: "Watchlist", Value: strings.Join(event.Tags, ", ")})
+	}
+
+	var links []notification.Link
+	if url := explorerLink(explorers.tx, "tx", event.TxHash.Hex()); url != "" {
+		links = append(links, notification.Link{Text: "View transaction", URL: url})
+	}
+
+	return fields, links
+}
+
+// explorerLink returns the url of the explorer page, empty without explorer.
+func explorerLink(explorer, kind, id string) string {
+	if explorer == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s/%s/%s", explorer, kind, id)
+}
+
+// transferThread groups the notifications of a transfer, from its initiation
+// to its finalization.
+func transferThread(network, transferKey string) string {
+	if transferKey == "" {
+		return ""
+	}
+
+	return network + ":" + transferKey
+}
+
+func formatLabel(label string) string {
+	if label == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(" (%s)", label)
+}
+
+// rollupLabel names the rollup of the message, adding the L2 chain name to
+// the network when the network has several L2 chains.
+func (p *App) rollupLabel(r *rollup) string {
+	n := r.network()
+	if len(n.rollups) > 1 {
+		return fmt.Sprintf("%s %s", n.name, r.l2.name)
+	}
+
+	return n.name
+}
+
+// setAsset sets the symbol, decimals and classification of the token of the
+// event on the chain. The zero address is the native ETH.
+func (p *App) setAsset(event *types.BridgeEvent, c *chain, address common.Address) {
+	tokenInfo := token.Unknown(address)
+	if address != (common.Address{}) {
+		tokenInfo = p.getToken(c, address)
+	}
+	event.Symbol = tokenInfo.Symbol
+	event.Decimals = tokenInfo.Decimals
+	event.Category = types.CategoryERC20
+
+	var (
+		asset AssetConfig
+		ok    bool
+	)
+	if chainCfg := p.networkConfig(c.network).Chain(c.name); chainCfg != nil {
+		asset, ok = chainCfg.Asset(address)
+	}
+
+	if !ok {
+		// the usdc bridge only bridges the usdc token
+		if event.Bridge == types.BridgeUsdc && !token.IsUnknown(tokenInfo) {
+			event.Asset = tokenInfo.Symbol
+			event.Category = types.CategoryStablecoin
+			p.setValue(event, event.Asset)
+		}
+		return
+	}
+
+	event.Asset = asset.DisplayName
+	if event.Asset == "" {
+		event.Asset = tokenInfo.Symbol
+	}
+
+	if asset.Category != "" {
+		event.Category = asset.Category
+	}
+
+	if asset.Decimals != nil {
+		event.Decimals = *asset.Decimals
+	}
+
+	// the native tokens may have no contract to read the metadata from
+	if token.IsUnknown(tokenInfo) && event.Asset != "" {
+		event.Symbol = event.Asset
+	}
+
+	priceID := asset.PriceID
+	if priceID == "" {
+		priceID = event.Asset
+	}
+	p.setValue(event, priceID)
+}
+
+// setValue sets the USD value of the amount of the event from the price of
+// priceID, leaving it unset when there is no price.
+func (p *App) setValue(event *types.BridgeEvent, priceID string) {
+	prices := p.prices.Load()
+	if prices == nil || priceID == "" || event.Amount == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), eventProcessTimeout)
+	defer cancel()
+
+	usdPrice, err := prices.Price(ctx, priceID)
+	if err != nil {
+		if !errors.Is(err, price.ErrNoPrice) {
+			log.GetLogger().Warnw("Failed to get the price of the asset", "error", err, "price_id", priceID)
+		}
+		return
+	}
+
+	value := price.Value(event.Amount, event.Decimals, usdPrice)
+	event.ValueUSD = &value
+}
+
+// assetLabel names the classified tokens, the other tokens are labelled by
+// their standard only, whatever their symbol.
+func assetLabel(event *types.BridgeEvent) string {
+	if event.Asset != "" {
+		return event.Asset
+	}
+
+	return "ERC-20"
+}
+
+func directionLabel(event *types.BridgeEvent) string {
+	return directionName(event.Direction)
+}
+
+func directionName(direction string) string {
+	if direction == types.DirectionWithdrawal {
+		return "Withdrawal"
+	}
+	return "Deposit"
+}
+
+func statusLabel(event *types.BridgeEvent) string {
+	if event.Status == types.StatusFinalized {
+		return "Finalized"
+	}
+	return "Initialized"
+}
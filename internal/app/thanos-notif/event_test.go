@@ -0,0 +1,285 @@
+package thanosnotif
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tokamak-network/tokamak-thanos/op-bindings/bindings"
+	"github.com/tokamak-network/tokamak-thanos/op-bindings/predeploys"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/bcclient"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+func TestApp_setAsset(t *testing.T) {
+	var (
+		l1Ton   = common.HexToAddress("0xa30fe40285B8f5c0457DbC3B7C8A280373c40044")
+		spoofed = common.HexToAddress("0x00000000000000000000000000000000000000f1")
+		usdc    = common.HexToAddress("0x00000000000000000000000000000000000000f2")
+		six     = 6
+	)
+
+	chains := testChainsConfig()
+	chains[0].Assets = map[string]AssetConfig{
+		"0xa30fe40285b8f5c0457dbc3b7c8a280373c40044": {DisplayName: "TON", Category: types.CategoryNative},
+		usdc.Hex(): {Category: types.CategoryStablecoin, Decimals: &six},
+	}
+
+	app := newTestApp(t, &Config{
+		NetworkConfig: NetworkConfig{
+			Network:   "sepolia",
+			Chains:    chains,
+			Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"}},
+		},
+	})
+	app.tokens.Add(0, &types.Token{Symbol: "TON", Decimals: 18, Address: l1Ton.Hex()})
+	app.tokens.Add(0, &types.Token{Symbol: "TON", Decimals: 18, Address: spoofed.Hex()})
+	app.tokens.Add(0, &types.Token{Symbol: "USDC", Decimals: 18, Address: usdc.Hex()})
+
+	n := testNetwork(app, "sepolia")
+	l1, l2 := n.chains["l1"], n.chains["thanos-a"]
+
+	testCases := []struct {
+		name     string
+		chain    *chain
+		bridge   string
+		address  common.Address
+		asset    string
+		category string
+		symbol   string
+		decimals int
+	}{
+		{name: "native eth", chain: l1, bridge: types.BridgeStandard, address: common.Address{}, asset: "ETH", category: types.CategoryNative, symbol: "ETH", decimals: 18},
+		{name: "configured ton", chain: l1, bridge: types.BridgeStandard, address: l1Ton, asset: "TON", category: types.CategoryNative, symbol: "TON", decimals: 18},
+		{name: "spoofed ton", chain: l1, bridge: types.BridgeStandard, address: spoofed, asset: "", category: types.CategoryERC20, symbol: "TON", decimals: 18},
+		{name: "decimals override", chain: l1, bridge: types.BridgeUsdc, address: usdc, asset: "USDC", category: types.CategoryStablecoin, symbol: "USDC", decimals: 6},
+		{name: "unreadable token", chain: l1, bridge: types.BridgeStandard, address: common.HexToAddress("0x01"), asset: "", category: types.CategoryERC20, symbol: "UNKNOWN", decimals: 0},
+		{name: "l2 native ton", chain: l2, bridge: types.BridgeStandard, address: predeploys.LegacyERC20NativeTokenAddr, asset: "TON", category: types.CategoryNative, symbol: "TON", decimals: 18},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			event := &types.BridgeEvent{Bridge: tc.bridge, Amount: big.NewInt(1)}
+			app.setAsset(event, tc.chain, tc.address)
+
+			assert.Equal(t, tc.asset, event.Asset)
+			assert.Equal(t, tc.category, event.Category)
+			assert.Equal(t, tc.symbol, event.Symbol)
+			assert.Equal(t, tc.decimals, event.Decimals)
+		})
+	}
+
+	event := &types.BridgeEvent{Bridge: types.BridgeStandard}
+	app.setAsset(event, l1, spoofed)
+	assert.Equal(t, "ERC-20", assetLabel(event))
+}
+
+func TestApp_setValue(t *testing.T) {
+	var (
+		l1Ton   = common.HexToAddress("0xa30fe40285B8f5c0457DbC3B7C8A280373c40044")
+		spoofed = common.HexToAddress("0x00000000000000000000000000000000000000f1")
+		usdc    = common.HexToAddress("0x00000000000000000000000000000000000000f2")
+	)
+
+	chains := testChainsConfig()
+	chains[0].Assets = map[string]AssetConfig{
+		l1Ton.Hex(): {DisplayName: "TON", Category: types.CategoryNative, PriceID: "tokamak-network"},
+	}
+
+	app := newTestApp(t, &Config{
+		NetworkConfig: NetworkConfig{
+			Network:   "sepolia",
+			Chains:    chains,
+			Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"}},
+		},
+		PricesConfig: PricesConfig{
+			Static: map[string]float64{"ETH": 3000, "tokamak-network": 1.5, "USDC": 1},
+		},
+	})
+	app.tokens.Add(0, &types.Token{Symbol: "TON", Decimals: 18, Address: l1Ton.Hex()})
+	app.tokens.Add(0, &types.Token{Symbol: "ETH", Decimals: 18, Address: spoofed.Hex()})
+	app.tokens.Add(0, &types.Token{Symbol: "USDC", Decimals: 6, Address: usdc.Hex()})
+
+	l1 := testNetwork(app, "sepolia").chains["l1"]
+	twoTokens, _ := new(big.Int).SetString("2000000000000000000", 10)
+
+	testCases := []struct {
+		name    string
+		bridge  string
+		address common.Address
+		amount  *big.Int
+		value   *float64
+	}{
+		{name: "native eth", bridge: types.BridgeStandard, address: common.Address{}, amount: twoTokens, value: ptr(6000.0)},
+		{name: "price id", bridge: types.BridgeStandard, address: l1Ton, amount: twoTokens, value: ptr(3.0)},
+		{name: "usdc bridge", bridge: types.BridgeUsdc, address: usdc, amount: big.NewInt(2500000), value: ptr(2.5)},
+		{name: "unclassified token", bridge: types.BridgeStandard, address: spoofed, amount: twoTokens, value: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			event := &types.BridgeEvent{Bridge: tc.bridge, Amount: tc.amount}
+			app.setAsset(event, l1, tc.address)
+
+			if tc.value == nil {
+				assert.Nil(t, event.ValueUSD)
+				assert.Empty(t, bridgeEventValues(event))
+				return
+			}
+
+			require.NotNil(t, event.ValueUSD)
+			assert.InDelta(t, *tc.value, *event.ValueUSD, 1e-9)
+			assert.InDelta(t, *tc.value, bridgeEventValues(event)["value_usd"], 1e-9)
+		})
+	}
+}
+
+func Test_formatUSD(t *testing.T) {
+	assert.Equal(t, "0.50", formatUSD(0.5))
+	assert.Equal(t, "999.99", formatUSD(999.99))
+	assert.Equal(t, "1,000.00", formatUSD(1000))
+	assert.Equal(t, "1,234,567.89", formatUSD(1234567.891))
+	assert.Equal(t, "-12,345.00", formatUSD(-12345))
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestApp_bridgeEventFields(t *testing.T) {
+	chains := testChainsConfig()
+	chains[0].ExplorerUrl = "https://l1.explorer"
+	chains[1].ExplorerUrl = "https://a.explorer"
+
+	app := newTestApp(t, &Config{
+		NetworkConfig: NetworkConfig{
+			Network:   "sepolia",
+			Chains:    chains,
+			Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"}},
+		},
+	})
+	r := testNetwork(app, "sepolia").rollups["thanos-a"]
+
+	event := &types.BridgeEvent{
+		Layer:     types.LayerL2,
+		Direction: types.DirectionWithdrawal,
+		Status:    types.StatusInitiated,
+		TxHash:    common.HexToHash("0x01"),
+		From:      common.HexToAddress("0x02"),
+		To:        common.HexToAddress("0x03"),
+		L2Token:   common.HexToAddress("0x04"),
+		Amount:    big.NewInt(1500),
+		Symbol:    "TON",
+		FromLabel: "Treasury",
+		Tags:      []string{"exchange"},
+	}
+
+	fields, links := app.bridgeEventFields(r, event)
+	assert.Equal(t, []notification.Field{
+		{Name: "Amount", Value: "1500 TON"},
+		{Name: "From", Value: event.From.Hex() + " (Treasury)", URL: "https://a.explorer/address/" + event.From.Hex()},
+		{Name: "To", Value: event.To.Hex(), URL: "https://l1.explorer/address/" + event.To.Hex()},
+		{Name: "L1 Token", Value: "ETH"},
+		{Name: "L2 Token", Value: event.L2Token.Hex(), URL: "https://a.explorer/token/" + event.L2Token.Hex()},
+		{Name: "Watchlist", Value: "exchange"},
+	}, fields)
+	assert.Equal(t, []notification.Link{{Text: "View transaction", URL: "https://a.explorer/tx/" + event.TxHash.Hex()}}, links)
+
+	// no link without explorer
+	_, links = app.bridgeEventFields(testNetwork(app, "sepolia").rollups["thanos-b"], event)
+	assert.Empty(t, links)
+}
+
+// testClient is the client of a chain answering its chain id only.
+func testClient(t *testing.T, chainID uint64) *bcclient.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		if req.Method == "eth_chainId" {
+			resp["result"] = hexutil.EncodeUint64(chainID)
+		} else {
+			resp["error"] = map[string]any{"code": -32601, "message": "method not found"}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := bcclient.New(context.Background(), server.URL, server.URL)
+	require.NoError(t, err)
+
+	return client
+}
+
+// testLog encodes the event of the contract ABI emitted by address.
+func testLog(t *testing.T, metaData *bind.MetaData, name string, address common.Address, indexed []common.Address, args ...any) *ethereumTypes.Log {
+	contractABI, err := metaData.GetAbi()
+	require.NoError(t, err)
+
+	event := contractABI.Events[name]
+	data, err := event.Inputs.NonIndexed().Pack(args...)
+	require.NoError(t, err)
+
+	topics := []common.Hash{event.ID}
+	for _, address := range indexed {
+		topics = append(topics, common.BytesToHash(address.Bytes()))
+	}
+
+	return &ethereumTypes.Log{Address: address, Topics: topics, Data: data}
+}
+
+func TestApp_ethTransferKey(t *testing.T) {
+	chains := testChainsConfig()
+	app := newTestApp(t, &Config{
+		NetworkConfig: NetworkConfig{
+			Network:   "sepolia",
+			Chains:    chains,
+			Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"}},
+		},
+	})
+
+	r := testNetwork(app, "sepolia").rollups["thanos-a"]
+	r.l1.client, r.l1.chainID = testClient(t, 11155111), 11155111
+	r.l2.client, r.l2.chainID = testClient(t, 111551), 111551
+
+	var (
+		l1Bridge = common.HexToAddress(chains[1].Bridges.L1Standard)
+		l2Bridge = common.HexToAddress(chains[1].Bridges.L2Standard)
+		from     = common.HexToAddress("0x01")
+		to       = common.HexToAddress("0x02")
+		amount   = big.NewInt(1e18)
+	)
+
+	// the L2 events of the ETH have the ETH predeploy as L2 token
+	depositInitiated, err := app.depositETHInitiatedEvent(r, testLog(t, bindings.L1StandardBridgeMetaData, "ETHDepositInitiated", l1Bridge, []common.Address{from, to}, amount, []byte{}))
+	require.NoError(t, err)
+	depositFinalized, err := app.depositFinalizedEvent(r, testLog(t, bindings.L2StandardBridgeMetaData, "DepositFinalized", l2Bridge, []common.Address{{}, predeploys.ETHAddr, from}, to, amount, []byte{}))
+	require.NoError(t, err)
+	assert.Equal(t, depositInitiated.TransferKey(), depositFinalized.TransferKey())
+
+	withdrawalInitiated, err := app.withdrawalInitiatedEvent(r, testLog(t, bindings.L2StandardBridgeMetaData, "WithdrawalInitiated", l2Bridge, []common.Address{{}, predeploys.ETHAddr, from}, to, amount, []byte{}))
+	require.NoError(t, err)
+	withdrawalFinalized, err := app.withdrawalETHFinalizedEvent(r, testLog(t, bindings.L1StandardBridgeMetaData, "ETHWithdrawalFinalized", l1Bridge, []common.Address{from, to}, amount, []byte{}))
+	require.NoError(t, err)
+	assert.Equal(t, withdrawalInitiated.TransferKey(), withdrawalFinalized.TransferKey())
+
+	assert.NotEqual(t, depositInitiated.TransferKey(), withdrawalInitiated.TransferKey())
+}
@@ -6,14 +6,16 @@ import (
 	"github.com/tokamak-network/tokamak-thanos/op-bindings/bindings"
 )
 
-func (p *App) getBridgeFilterers() (l1BridgeFilterer *bindings.L1StandardBridgeFilterer, l2BridgeFilterer *bindings.L2StandardBridgeFilterer, err error) {
-	l1BridgeFilterer, err = bindings.NewL1StandardBridgeFilterer(common.HexToAddress(p.cfg.L1StandardBridge), p.l1Client.GetClient())
+func (p *App) getBridgeFilterers(r *rollup) (l1BridgeFilterer *bindings.L1StandardBridgeFilterer, l2BridgeFilterer *bindings.L2StandardBridgeFilterer, err error) {
+	bridges := p.bridges(r)
+
+	l1BridgeFilterer, err = bindings.NewL1StandardBridgeFilterer(common.HexToAddress(bridges.L1Standard), r.l1.client.GetClient())
 	if err != nil {
 		log.GetLogger().Errorw("L1StandardBridgeFilterer instance fail", "error", err)
 		return nil, nil, err
 	}
 
-	l2BridgeFilterer, err = bindings.NewL2StandardBridgeFilterer(common.HexToAddress(p.cfg.L2StandardBridge), p.l2Client.GetClient())
+	l2BridgeFilterer, err = bindings.NewL2StandardBridgeFilterer(common.HexToAddress(bridges.L2Standard), r.l2.client.GetClient())
 	if err != nil {
 		log.GetLogger().Errorw("L2StandardBridgeFilterer instance fail", "error", err)
 		return nil, nil, err
@@ -22,14 +24,16 @@ func (p *App) getBridgeFilterers() (l1BridgeFilterer *bindings.L1StandardBridgeF
 	return l1BridgeFilterer, l2BridgeFilterer, nil
 }
 
-func (p *App) getUSDCBridgeFilterers() (l1UsdcBridgeFilterer *bindings.L1UsdcBridgeFilterer, l2UsdcBridgeFilterer *bindings.L2UsdcBridgeFilterer, err error) {
-	l1UsdcBridgeFilterer, err = bindings.NewL1UsdcBridgeFilterer(common.HexToAddress(p.cfg.L1UsdcBridge), p.l1Client.GetClient())
+func (p *App) getUSDCBridgeFilterers(r *rollup) (l1UsdcBridgeFilterer *bindings.L1UsdcBridgeFilterer, l2UsdcBridgeFilterer *bindings.L2UsdcBridgeFilterer, err error) {
+	bridges := p.bridges(r)
+
+	l1UsdcBridgeFilterer, err = bindings.NewL1UsdcBridgeFilterer(common.HexToAddress(bridges.L1Usdc), r.l1.client.GetClient())
 	if err != nil {
 		log.GetLogger().Errorw("Failed to init the L1UsdcBridgeFilterer", "error", err)
 		return nil, nil, err
 	}
 
-	l2UsdcBridgeFilterer, err = bindings.NewL2UsdcBridgeFilterer(common.HexToAddress(p.cfg.L2UsdcBridge), p.l2Client.GetClient())
+	l2UsdcBridgeFilterer, err = bindings.NewL2UsdcBridgeFilterer(common.HexToAddress(bridges.L2Usdc), r.l2.client.GetClient())
 	if err != nil {
 		log.GetLogger().Errorw("Failed to init the L2UsdcBridgeFilterer", "error", err)
 		return nil, nil, err
@@ -1,12 +1,14 @@
 package thanosnotif
 
 import (
-	"errors"
+	"context"
+	"fmt"
 	"math/big"
+	"strconv"
 	"strings"
 
-	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/bcclient"
-	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/erc20"
+	"github.com/ethereum/go-ethereum/common"
+
 	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
 
 	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
@@ -20,24 +22,55 @@ func formatAmount(amount *big.Int, tokenDecimals int) string {
 	return formattedAmount
 }
 
-func fetchTokensInfo(bcClient *bcclient.Client, tokenAddresses []string) (map[string]*types.Token, error) {
-	tokenInfoMap := make(map[string]*types.Token)
-	for _, tokenAddress := range tokenAddresses {
-		tokenInfo, err := erc20.FetchTokenInfo(bcClient, tokenAddress)
-		if err != nil {
-			log.GetLogger().Errorw("Failed to fetch token info", "error", err, "address", tokenAddress)
-			return nil, err
-		}
+// formatUSD formats a USD value with two decimals and thousands separators.
+func formatUSD(value float64) string {
+	text := strconv.FormatFloat(value, 'f', 2, 64)
+
+	integer, fraction, _ := strings.Cut(text, ".")
+	sign := ""
+	if strings.HasPrefix(integer, "-") {
+		sign, integer = "-", integer[1:]
+	}
 
-		if tokenInfo == nil {
-			log.GetLogger().Errorw("Token info empty", "address", tokenAddress)
-			return nil, errors.New("token info is empty")
+	var grouped strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteByte(',')
 		}
+		grouped.WriteRune(digit)
+	}
 
-		log.GetLogger().Infow("Got token info", "token", tokenInfo)
+	return sign + grouped.String() + "." + fraction
+}
 
-		tokenInfoMap[tokenAddress] = tokenInfo
+// formatValue formats the USD value of an amount, empty when it has no value.
+func formatValue(value *float64) string {
+	if value == nil {
+		return ""
 	}
 
-	return tokenInfoMap, nil
+	return fmt.Sprintf(" (~$%s)", formatUSD(*value))
+}
+
+// getToken returns the metadata of the token on the chain, UNKNOWN with the
+// raw units when it can't be read.
+func (p *App) getToken(c *chain, address common.Address) *types.Token {
+	ctx, cancel := context.WithTimeout(context.Background(), eventProcessTimeout)
+	defer cancel()
+
+	return p.tokens.Token(ctx, c.chainID, address)
+}
+
+// prefetchTokens reads the configured tokens of the chain ahead of their
+// events.
+func (p *App) prefetchTokens(ctx context.Context, c *chain, addresses []string) {
+	for _, address := range addresses {
+		tokenInfo, err := p.tokens.Lookup(ctx, c.chainID, common.HexToAddress(address))
+		if err != nil {
+			log.GetLogger().Warnw("Failed to fetch token info", "error", err, "chain", c.name, "address", address)
+			continue
+		}
+
+		log.GetLogger().Infow("Got token info", "chain", c.name, "token", tokenInfo)
+	}
 }
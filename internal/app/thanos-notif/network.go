@@ -0,0 +1,185 @@
+package thanosnotif
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/digest"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+// network is a listened network with its own chains and notifiers. The chain
+// clients are shared with the other networks.
+type network struct {
+	name       string
+	chains     map[string]*chain
+	chainNames []string
+	rollups    map[string]*rollup
+	notifier   *notification.Router
+
+	mu           sync.Mutex
+	whaleWindows []*whaleWindow
+
+	digest *digest.Digest
+
+	// solvency holds the raised reconciliation conditions by token, and
+	// solvencyChecked the time of the last reconciliation.
+	solvency        map[string]bool
+	solvencyChecked time.Time
+}
+
+func (p *App) initNetworks(ctx context.Context) error {
+	for _, networkCfg := range p.config().NetworkConfigs() {
+		n := &network{name: networkCfg.Network}
+
+		notifier, err := newNotifier(networkCfg, p.threads, p.deadLetters)
+		if err != nil {
+			log.GetLogger().Errorw("Failed to create the notifier", "error", err, "network", n.name)
+			return err
+		}
+		n.notifier = notifier
+		n.updateWhaleWindows(&networkCfg.Whales)
+
+		if err := p.initDigest(ctx, n); err != nil {
+			log.GetLogger().Errorw("Failed to initialize the digest", "error", err, "network", n.name)
+			return err
+		}
+
+		if err := p.initChains(ctx, n, networkCfg); err != nil {
+			log.GetLogger().Errorw("Failed to initialize the chains", "error", err, "network", n.name)
+			return err
+		}
+
+		p.networks = append(p.networks, n)
+	}
+
+	return nil
+}
+
+// networkConfig returns the current config of the network.
+func (p *App) networkConfig(n *network) *NetworkConfig {
+	if networkCfg := p.config().NetworkByName(n.name); networkCfg != nil {
+		return networkCfg
+	}
+
+	return &NetworkConfig{Network: n.name}
+}
+
+// alertTaskFailure notifies a supervised task failing repeatedly: a listener,
+// named network:chain, to its network and a service to every network.
+func (p *App) alertTaskFailure(name string, failures int, err error) {
+	if strings.Contains(name, ":") {
+		p.alertListenerFailure(name, failures, err)
+		return
+	}
+
+	for _, n := range p.networks {
+		msg := serviceFailureMessage(n, name)
+		msg.Title = fmt.Sprintf("[%s] [%s Service Failure]", n.name, name)
+		msg.Text = fmt.Sprintf("The %s service failed %d times in a row and is restarting, the listeners still running.\nError: %s", name, failures, err)
+
+		if err := n.notifier.Notify(msg); err != nil {
+			log.GetLogger().Errorw("Failed to notify the service failure", "error", err, "network", n.name, "service", name)
+		}
+	}
+}
+
+// resolveTaskFailure notifies an alerted task running again.
+func (p *App) resolveTaskFailure(name string) {
+	if strings.Contains(name, ":") {
+		p.resolveListenerFailure(name)
+		return
+	}
+
+	for _, n := range p.networks {
+		msg := serviceFailureMessage(n, name)
+		msg.Title = fmt.Sprintf("[%s] [%s Service Recovered]", n.name, name)
+		msg.Text = fmt.Sprintf("The %s service is running again.", name)
+		msg.Resolved = true
+
+		if err := n.notifier.Notify(msg); err != nil {
+			log.GetLogger().Errorw("Failed to notify the service recovery", "error", err, "network", n.name, "service", name)
+		}
+	}
+}
+
+// serviceFailureMessage returns the message of the failure alert of the
+// service, as listenerFailureMessage.
+func serviceFailureMessage(n *network, name string) *notification.Message {
+	return &notification.Message{
+		Severity: notification.SeverityCritical,
+		Attributes: map[string]string{
+			"network": n.name,
+			"service": name,
+			"alert":   "service_failure",
+		},
+		DedupKey: "service:" + name,
+	}
+}
+
+// alertListenerFailure notifies the network of a listener failing repeatedly.
+func (p *App) alertListenerFailure(key string, failures int, err error) {
+	c := p.chainByKey(key)
+	if c == nil {
+		return
+	}
+
+	msg := listenerFailureMessage(c)
+	msg.Title = fmt.Sprintf("[%s] [%s Listener Failure]", c.network.name, c.name)
+	msg.Text = fmt.Sprintf("The %s listener failed %d times in a row and is restarting.\nError: %s", c.name, failures, err)
+
+	if err := c.network.notifier.Notify(msg); err != nil {
+		log.GetLogger().Errorw("Failed to notify the listener failure", "error", err, "listener", key)
+	}
+}
+
+// resolveListenerFailure notifies the network of an alerted listener running
+// again.
+func (p *App) resolveListenerFailure(key string) {
+	c := p.chainByKey(key)
+	if c == nil {
+		return
+	}
+
+	msg := listenerFailureMessage(c)
+	msg.Title = fmt.Sprintf("[%s] [%s Listener Recovered]", c.network.name, c.name)
+	msg.Text = fmt.Sprintf("The %s listener is running again.", c.name)
+	msg.Resolved = true
+
+	if err := c.network.notifier.Notify(msg); err != nil {
+		log.GetLogger().Errorw("Failed to notify the listener recovery", "error", err, "listener", key)
+	}
+}
+
+// listenerFailureMessage returns the message of the listener failure alert
+// of the chain, whose resolution has the same severity so that it takes the
+// same routes.
+func listenerFailureMessage(c *chain) *notification.Message {
+	return &notification.Message{
+		Severity: notification.SeverityCritical,
+		Attributes: map[string]string{
+			"network": c.network.name,
+			"layer":   c.layer,
+			"chain":   c.name,
+			"alert":   "listener_failure",
+		},
+		DedupKey: "listener:" + c.key(),
+	}
+}
+
+// chainByKey returns the chain of the key, nil when unknown.
+func (p *App) chainByKey(key string) *chain {
+	for _, n := range p.networks {
+		for _, c := range n.chains {
+			if c.key() == key {
+				return c
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package thanosnotif
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+)
+
+type testSender struct {
+	messages []*notification.Message
+}
+
+func (s *testSender) Notify(msg *notification.Message) error {
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+func TestApp_alertListenerFailure(t *testing.T) {
+	cfg := &Config{
+		Networks: []NetworkConfig{
+			{Network: "mainnet", Chains: testChainsConfig(), Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/mainnet"}}},
+			{Network: "sepolia", Chains: testChainsConfig(), Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/sepolia"}}},
+		},
+	}
+	app := newTestApp(t, cfg)
+
+	mainnet, sepolia := &testSender{}, &testSender{}
+	require.NoError(t, testNetwork(app, "mainnet").notifier.Update(map[string]notification.Sender{"slack": mainnet}, []string{"slack"}, nil))
+	require.NoError(t, testNetwork(app, "sepolia").notifier.Update(map[string]notification.Sender{"slack": sepolia}, []string{"slack"}, nil))
+
+	app.alertListenerFailure("sepolia:thanos-b", 3, errors.New("subscription failed"))
+
+	assert.Empty(t, mainnet.messages)
+	require.Len(t, sepolia.messages, 1)
+	assert.Equal(t, notification.SeverityCritical, sepolia.messages[0].Severity)
+	assert.Equal(t, "[sepolia] [thanos-b Listener Failure]", sepolia.messages[0].Title)
+	assert.Contains(t, sepolia.messages[0].Text, "subscription failed")
+	assert.Equal(t, "thanos-b", sepolia.messages[0].Attributes["chain"])
+	assert.Equal(t, "listener:sepolia:thanos-b", sepolia.messages[0].DedupKey)
+
+	app.resolveListenerFailure("sepolia:thanos-b")
+	require.Len(t, sepolia.messages, 2)
+	assert.Equal(t, "[sepolia] [thanos-b Listener Recovered]", sepolia.messages[1].Title)
+	assert.True(t, sepolia.messages[1].Resolved)
+	assert.Equal(t, notification.SeverityCritical, sepolia.messages[1].Severity)
+	assert.Equal(t, sepolia.messages[0].DedupKey, sepolia.messages[1].DedupKey)
+
+	app.alertListenerFailure("unknown:l1", 3, errors.New("subscription failed"))
+	assert.Empty(t, mainnet.messages)
+
+	// the services are alerted to every network
+	app.alertTaskFailure("admin", 3, errors.New("address already in use"))
+	require.Len(t, mainnet.messages, 1)
+	require.Len(t, sepolia.messages, 3)
+	assert.Equal(t, "[mainnet] [admin Service Failure]", mainnet.messages[0].Title)
+	assert.Equal(t, "service_failure", mainnet.messages[0].Attributes["alert"])
+	assert.Equal(t, "service:admin", sepolia.messages[2].DedupKey)
+
+	app.resolveTaskFailure("admin")
+	require.Len(t, mainnet.messages, 2)
+	assert.True(t, mainnet.messages[1].Resolved)
+
+	app.resolveTaskFailure("sepolia:thanos-b")
+	require.Len(t, sepolia.messages, 5)
+	assert.Equal(t, "[sepolia] [thanos-b Listener Recovered]", sepolia.messages[4].Title)
+}
@@ -0,0 +1,376 @@
+package thanosnotif
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+)
+
+const (
+	// notificationSource names the listener in the incidents, with the
+	// network.
+	notificationSource = "thanos-event-listener"
+
+	NotifierTypeSlack     = "slack"
+	NotifierTypeEmail     = "email"
+	NotifierTypePagerDuty = "pagerduty"
+	NotifierTypeOpsgenie  = "opsgenie"
+
+	// DefaultNotifierName is the notifier configured by the slack url flag.
+	DefaultNotifierName = "slack"
+
+	defaultNotifyRetries = 5
+
+	// defaultRateLimit is the rate of the Slack webhooks, in messages per
+	// second.
+	defaultRateLimit = 1
+)
+
+type NotifierConfig struct {
+	Name string `yaml:"name" toml:"name"`
+	Type string `yaml:"type" toml:"type"`
+	// URL is the Slack webhook, or overrides the API of PagerDuty and
+	// Opsgenie, e.g. for the EU instances.
+	URL string `yaml:"url" toml:"url"`
+	// Token and Channel post the Slack messages with chat.postMessage
+	// instead of the webhook URL, threading the follow-ups of a transfer
+	// under its first message. Token is the routing key of PagerDuty and the
+	// API key of Opsgenie.
+	Token   string     `json:"-" yaml:"token" toml:"token"`
+	Channel string     `yaml:"channel" toml:"channel"`
+	SMTP    SMTPConfig `yaml:"smtp" toml:"smtp"`
+	// MinSeverity drops the messages below it, critical by default for
+	// PagerDuty and Opsgenie.
+	MinSeverity string `yaml:"min_severity" toml:"min_severity"`
+
+	// RateLimit is the number of messages per second sent to the notifier,
+	// 1 by default as the Slack webhooks, with bursts of Burst messages.
+	RateLimit float64 `yaml:"rate_limit" toml:"rate_limit"`
+	Burst     int     `yaml:"burst" toml:"burst"`
+	// QueueSize is the number of messages waiting for the rate limit, 1000
+	// by default. The messages are dropped when the queue is full.
+	QueueSize int `yaml:"queue_size" toml:"queue_size"`
+	// BatchWindow coalesces the messages of the window into a single message
+	// of at most BatchMax messages. Disabled when 0.
+	BatchWindow time.Duration `yaml:"batch_window" toml:"batch_window"`
+	BatchMax    int           `yaml:"batch_max" toml:"batch_max"`
+}
+
+// SMTPConfig is the SMTP server and the addresses of an email notifier.
+type SMTPConfig struct {
+	Addr     string   `yaml:"addr" toml:"addr"`
+	Username string   `yaml:"username" toml:"username"`
+	Password string   `json:"-" yaml:"password" toml:"password"`
+	From     string   `yaml:"from" toml:"from"`
+	To       []string `yaml:"to" toml:"to"`
+}
+
+type RoutingConfig struct {
+	// Default lists the notifiers of the messages matching no route. All the
+	// notifiers are used when empty.
+	Default []string      `yaml:"default" toml:"default"`
+	Routes  []RouteConfig `yaml:"routes" toml:"routes"`
+}
+
+type RouteConfig struct {
+	Name  string            `yaml:"name" toml:"name"`
+	Match map[string]string `yaml:"match" toml:"match"`
+	// Min holds the minimum values of the messages, e.g. value_usd.
+	Min         map[string]float64 `yaml:"min" toml:"min"`
+	MinSeverity string             `yaml:"min_severity" toml:"min_severity"`
+	Notifiers   []string           `yaml:"notifiers" toml:"notifiers"`
+}
+
+func newNotifier(cfg *NetworkConfig, threads notification.ThreadStore, deadLetters notification.DeadLetterStore) (*notification.Router, error) {
+	senders, defaultTargets, routes, err := newNotifierRoutes(cfg, threads, deadLetters)
+	if err != nil {
+		return nil, err
+	}
+
+	return notification.NewRouter(senders, defaultTargets, routes)
+}
+
+// limitSender rate limits the sender of the notifier, batching its messages
+// first when configured. The messages failing to be sent, or dropped by a
+// full queue, are kept in deadLetters when set.
+func limitSender(network string, sender notification.Sender, cfg NotifierConfig, deadLetters notification.DeadLetterStore) notification.Sender {
+	rateLimit := cfg.RateLimit
+	if rateLimit == 0 {
+		rateLimit = defaultRateLimit
+	}
+
+	if deadLetters != nil {
+		sender = notification.NewDeadLetterSender(network, cfg.Name, sender, deadLetters)
+	}
+	sender = notification.NewRateLimiter(sender, rateLimit, cfg.Burst, cfg.QueueSize)
+	if deadLetters != nil {
+		sender = notification.NewDeadLetterSender(network, cfg.Name, sender, deadLetters)
+	}
+
+	if cfg.BatchWindow > 0 {
+		sender = notification.NewBatcher(sender, cfg.BatchWindow, cfg.BatchMax)
+	}
+
+	return sender
+}
+
+// newNotifierRoutes builds the senders and the routes of the notifiers of the
+// network. The Slack bots keep their threads in threads.
+func newNotifierRoutes(cfg *NetworkConfig, threads notification.ThreadStore, deadLetters notification.DeadLetterStore) (map[string]notification.Sender, []string, []notification.Route, error) {
+	retries := cfg.Thresholds.NotifyRetries
+	if retries == 0 {
+		retries = defaultNotifyRetries
+	}
+
+	source := fmt.Sprintf("%s:%s", notificationSource, cfg.Network)
+
+	senders := make(map[string]notification.Sender, len(cfg.Notifiers))
+	names := make([]string, 0, len(cfg.Notifiers))
+	for _, notifier := range cfg.Notifiers {
+		var sender notification.Sender
+		minSeverity := notifier.MinSeverity
+		switch notifier.Type {
+		case NotifierTypeSlack:
+			if notifier.Token != "" {
+				sender = notification.MakeSlackBotNotificationService(notifier.Token, notifier.Channel, retries, threads)
+			} else {
+				sender = notification.MakeSlackNotificationService(notifier.URL, retries)
+			}
+		case NotifierTypeEmail:
+			sender = notification.MakeEmailNotificationService(notification.EmailConfig{
+				Addr:     notifier.SMTP.Addr,
+				Username: notifier.SMTP.Username,
+				Password: notifier.SMTP.Password,
+				From:     notifier.SMTP.From,
+				To:       notifier.SMTP.To,
+			}, retries)
+		case NotifierTypePagerDuty:
+			sender = notification.MakePagerDutyNotificationService(notifier.URL, notifier.Token, source, retries)
+		case NotifierTypeOpsgenie:
+			sender = notification.MakeOpsgenieNotificationService(notifier.URL, notifier.Token, source, retries)
+		default:
+			return nil, nil, nil, fmt.Errorf("unknown notifier type: %s", notifier.Type)
+		}
+
+		// the incident destinations only page the critical alerts by default
+		if minSeverity == "" && (notifier.Type == NotifierTypePagerDuty || notifier.Type == NotifierTypeOpsgenie) {
+			minSeverity = string(notification.SeverityCritical)
+		}
+		severity, err := notification.ParseSeverity(minSeverity)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		sender = limitSender(cfg.Network, sender, notifier, deadLetters)
+		if severity != notification.SeverityInfo {
+			sender = notification.NewSeverityFilter(sender, severity)
+		}
+		senders[notifier.Name] = sender
+		names = append(names, notifier.Name)
+	}
+
+	defaultTargets := cfg.Routing.Default
+	if len(defaultTargets) == 0 {
+		defaultTargets = names
+	}
+
+	routes := make([]notification.Route, 0, len(cfg.Routing.Routes))
+	for _, route := range cfg.Routing.Routes {
+		minSeverity, err := notification.ParseSeverity(route.MinSeverity)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		routes = append(routes, notification.Route{
+			Name:        route.Name,
+			Match:       route.Match,
+			Min:         route.Min,
+			MinSeverity: minSeverity,
+			Notifiers:   route.Notifiers,
+		})
+	}
+
+	if len(cfg.Whales.Notifiers) > 0 {
+		routes = append(routes, notification.Route{
+			Name:      whaleRouteName,
+			Match:     map[string]string{"alert": whaleAlert},
+			Notifiers: cfg.Whales.Notifiers,
+		})
+	}
+
+	if len(cfg.Watchdog.Notifiers) > 0 {
+		routes = append(routes, notification.Route{
+			Name:      watchdogRouteName,
+			Match:     map[string]string{"alert": watchdogAlert},
+			Notifiers: cfg.Watchdog.Notifiers,
+		})
+	}
+
+	tags := make([]string, 0, len(cfg.Watchlist))
+	for tag, watchlist := range cfg.Watchlist {
+		if len(watchlist.Notifiers) > 0 {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+
+	for _, tag := range tags {
+		routes = append(routes, notification.Route{
+			Name:      "watchlist " + tag,
+			Match:     map[string]string{tagAttribute(tag): "true"},
+			Notifiers: cfg.Watchlist[tag].Notifiers,
+		})
+	}
+
+	return senders, defaultTargets, routes, nil
+}
+
+// SetSlackURL points the default slack notifier to url, adding the notifier
+// when it is not configured yet.
+func (c *NetworkConfig) SetSlackURL(url string) {
+	c.defaultSlackNotifier().URL = url
+}
+
+// SetSlackBot posts the messages of the default slack notifier to channel
+// with the bot token, adding the notifier when it is not configured yet.
+func (c *NetworkConfig) SetSlackBot(token, channel string) {
+	notifier := c.defaultSlackNotifier()
+	notifier.Token = token
+	notifier.Channel = channel
+}
+
+func (c *NetworkConfig) defaultSlackNotifier() *NotifierConfig {
+	for i := range c.Notifiers {
+		if c.Notifiers[i].Name == DefaultNotifierName {
+			c.Notifiers[i].Type = NotifierTypeSlack
+			return &c.Notifiers[i]
+		}
+	}
+
+	c.Notifiers = append(c.Notifiers, NotifierConfig{
+		Name: DefaultNotifierName,
+		Type: NotifierTypeSlack,
+	})
+
+	return &c.Notifiers[len(c.Notifiers)-1]
+}
+
+func (c *NetworkConfig) validateNotifiers(v *validator, prefix string) {
+	if len(c.Notifiers) == 0 {
+		v.add(prefix+"notifiers", "at least one notifier is required")
+	}
+
+	names := make(map[string]struct{}, len(c.Notifiers))
+	for i, notifier := range c.Notifiers {
+		path := fmt.Sprintf("%snotifiers[%d]", prefix, i)
+
+		if notifier.Name == "" {
+			v.add(path+".name", "is required")
+		} else if _, ok := names[notifier.Name]; ok {
+			v.add(path+".name", "duplicated notifier name: %s", notifier.Name)
+		}
+		names[notifier.Name] = struct{}{}
+
+		switch notifier.Type {
+		case NotifierTypeSlack:
+			if notifier.Token == "" {
+				v.required(path+".url", notifier.URL)
+			} else {
+				v.required(path+".channel", notifier.Channel)
+			}
+		case NotifierTypeEmail:
+			v.required(path+".smtp.addr", notifier.SMTP.Addr)
+			v.required(path+".smtp.from", notifier.SMTP.From)
+			if len(notifier.SMTP.To) == 0 {
+				v.add(path+".smtp.to", "at least one recipient is required")
+			}
+		case NotifierTypePagerDuty, NotifierTypeOpsgenie:
+			v.required(path+".token", notifier.Token)
+		default:
+			v.add(path+".type", "unknown notifier type: %s", notifier.Type)
+		}
+
+		if _, err := notification.ParseSeverity(notifier.MinSeverity); err != nil {
+			v.add(path+".min_severity", "%s", err)
+		}
+
+		if notifier.RateLimit < 0 {
+			v.add(path+".rate_limit", "must not be negative")
+		}
+
+		if notifier.Burst < 0 {
+			v.add(path+".burst", "must not be negative")
+		}
+
+		if notifier.QueueSize < 0 {
+			v.add(path+".queue_size", "must not be negative")
+		}
+
+		if notifier.BatchWindow < 0 {
+			v.add(path+".batch_window", "must not be negative")
+		}
+
+		if notifier.BatchMax < 0 {
+			v.add(path+".batch_max", "must not be negative")
+		}
+	}
+
+	for i, name := range c.Routing.Default {
+		if _, ok := names[name]; !ok {
+			v.add(fmt.Sprintf("%srouting.default[%d]", prefix, i), "unknown notifier: %s", name)
+		}
+	}
+
+	for i, route := range c.Routing.Routes {
+		path := fmt.Sprintf("%srouting.routes[%d]", prefix, i)
+
+		if len(route.Notifiers) == 0 {
+			v.add(path+".notifiers", "is required")
+		}
+
+		for j, name := range route.Notifiers {
+			if _, ok := names[name]; !ok {
+				v.add(fmt.Sprintf("%s.notifiers[%d]", path, j), "unknown notifier: %s", name)
+			}
+		}
+
+		if _, err := notification.ParseSeverity(route.MinSeverity); err != nil {
+			v.add(path+".min_severity", "%s", err)
+		}
+	}
+
+	for i, name := range c.Whales.Notifiers {
+		if _, ok := names[name]; !ok {
+			v.add(fmt.Sprintf("%swhales.notifiers[%d]", prefix, i), "unknown notifier: %s", name)
+		}
+	}
+
+	for i, name := range c.Watchdog.Notifiers {
+		if _, ok := names[name]; !ok {
+			v.add(fmt.Sprintf("%swatchdog.notifiers[%d]", prefix, i), "unknown notifier: %s", name)
+		}
+	}
+
+	tags := make([]string, 0, len(c.Watchlist))
+	for tag := range c.Watchlist {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	for _, tag := range tags {
+		watchlist := c.Watchlist[tag]
+		path := prefix + "watchlist." + tag
+
+		if _, err := notification.ParseSeverity(watchlist.Severity); err != nil {
+			v.add(path+".severity", "%s", err)
+		}
+
+		for i, name := range watchlist.Notifiers {
+			if _, ok := names[name]; !ok {
+				v.add(fmt.Sprintf("%s.notifiers[%d]", path, i), "unknown notifier: %s", name)
+			}
+		}
+	}
+}
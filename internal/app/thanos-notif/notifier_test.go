@@ -0,0 +1,70 @@
+package thanosnotif
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+)
+
+func Test_newNotifierRoutes(t *testing.T) {
+	senders, defaultTargets, _, err := newNotifierRoutes(&NetworkConfig{
+		Notifiers: []NotifierConfig{
+			{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"},
+			{Name: "ops", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/ops", RateLimit: 0.5, BatchWindow: 5 * time.Second, BatchMax: 10},
+			{Name: "bot", Type: NotifierTypeSlack, Token: "xoxb-token", Channel: "C0123"},
+			{Name: "mail", Type: NotifierTypeEmail, SMTP: SMTPConfig{Addr: "localhost:25", From: "listener@example.com", To: []string{"ops@example.com"}}},
+			{Name: "pager", Type: NotifierTypePagerDuty, Token: "routing-key"},
+			{Name: "genie", Type: NotifierTypeOpsgenie, Token: "api-key", MinSeverity: "info"},
+		},
+	}, nil, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"slack", "ops", "bot", "mail", "pager", "genie"}, defaultTargets)
+	assert.IsType(t, &notification.RateLimiter{}, senders["slack"])
+	assert.IsType(t, &notification.Batcher{}, senders["ops"])
+	assert.IsType(t, &notification.RateLimiter{}, senders["mail"])
+	// the incident destinations only receive the critical alerts by default
+	assert.IsType(t, &notification.SeverityFilter{}, senders["pager"])
+	assert.IsType(t, &notification.RateLimiter{}, senders["genie"])
+
+	// the failed messages are kept in the dead letters
+	senders, _, _, err = newNotifierRoutes(&NetworkConfig{
+		Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"}},
+	}, nil, notification.NewMemoryDeadLetterStore(0))
+	require.NoError(t, err)
+	assert.IsType(t, &notification.DeadLetterSender{}, senders["slack"])
+
+	_, _, _, err = newNotifierRoutes(&NetworkConfig{Notifiers: []NotifierConfig{{Name: "irc", Type: "irc"}}}, nil, nil)
+	require.Error(t, err)
+}
+
+// failingSender fails to send every message.
+type failingSender struct{}
+
+func (failingSender) Notify(*notification.Message) error {
+	return errors.New("webhook failed")
+}
+
+func Test_limitSender(t *testing.T) {
+	ctx := context.Background()
+	deadLetters := notification.NewMemoryDeadLetterStore(0)
+	sender := limitSender("sepolia", failingSender{}, NotifierConfig{Name: "slack", RateLimit: 100}, deadLetters)
+
+	// the messages failing in the background are kept
+	require.NoError(t, sender.Notify(&notification.Message{Title: "failed"}))
+	require.Eventually(t, func() bool {
+		letters, err := deadLetters.List(ctx)
+		return err == nil && len(letters) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	letters, err := deadLetters.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "slack", letters[0].Notifier)
+	assert.Equal(t, "webhook failed", letters[0].Error)
+}
@@ -0,0 +1,64 @@
+package thanosnotif
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/price"
+)
+
+// PricesConfig configures the USD prices of the assets. The providers are
+// asked in order: the HTTP API, the file, then the static table.
+type PricesConfig struct {
+	// Static maps the price ids to a fixed USD price.
+	Static map[string]float64 `yaml:"static" toml:"static"`
+	// File is a JSON file of the prices, such as a file updated by a cron job.
+	File string           `yaml:"file" toml:"file"`
+	HTTP PricesHTTPConfig `yaml:"http" toml:"http"`
+}
+
+type PricesHTTPConfig struct {
+	// URL returns the prices in the format of the price file, such as the
+	// CoinGecko simple price API.
+	URL string        `yaml:"url" toml:"url"`
+	TTL time.Duration `yaml:"ttl" toml:"ttl"`
+}
+
+// newPrices chains the configured price providers, the live ones first.
+func newPrices(cfg *PricesConfig) *price.Chain {
+	var prices price.Chain
+	if cfg.HTTP.URL != "" {
+		prices = append(prices, price.NewHTTP(cfg.HTTP.URL, cfg.HTTP.TTL))
+	}
+	if cfg.File != "" {
+		prices = append(prices, price.NewFile(cfg.File))
+	}
+	if len(cfg.Static) > 0 {
+		prices = append(prices, price.Static(cfg.Static))
+	}
+
+	return &prices
+}
+
+func (c *PricesConfig) validate(v *validator) {
+	ids := make([]string, 0, len(c.Static))
+	for id := range c.Static {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if c.Static[id] < 0 {
+			v.add("prices.static."+id, "must not be negative")
+		}
+	}
+
+	if url := c.HTTP.URL; url != "" && !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		v.add("prices.http.url", "must be an http(s) url")
+	}
+
+	if c.HTTP.TTL < 0 {
+		v.add("prices.http.ttl", "must not be negative")
+	}
+}
@@ -0,0 +1,173 @@
+package thanosnotif
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+func (p *App) config() *Config {
+	return p.cfg.Load()
+}
+
+// Reload applies the token lists, tokens, prices, address book, bridges,
+// explorers, notifiers, routing, whale thresholds and watchlists of cfg
+// without restarting the listeners. The block keepers and the connections are
+// kept, so changing the networks, the chain endpoints, storage, archive,
+// publisher, stream, supervisor or digest requires a restart. The current
+// config is kept when cfg can't be applied.
+func (p *App) Reload(cfg *Config) error {
+	current := p.config()
+
+	next := *cfg
+	next.Chains = append([]ChainConfig(nil), cfg.Chains...)
+	next.Networks = make([]NetworkConfig, len(cfg.Networks))
+	for i, networkCfg := range cfg.Networks {
+		next.Networks[i] = networkCfg
+		next.Networks[i].Chains = append([]ChainConfig(nil), networkCfg.Chains...)
+	}
+	if err := keepRestartOnlyFields(current, &next); err != nil {
+		return err
+	}
+
+	if err := p.importTokenLists(context.Background(), next.TokenLists); err != nil {
+		return err
+	}
+
+	addresses, err := newAddressBook(&next.AddressBook)
+	if err != nil {
+		return err
+	}
+
+	type networkUpdate struct {
+		senders        map[string]notification.Sender
+		defaultTargets []string
+		routes         []notification.Route
+	}
+
+	updates := make([]networkUpdate, len(p.networks))
+	for i, networkCfg := range next.NetworkConfigs() {
+		n := p.networks[i]
+
+		for _, chainCfg := range networkCfg.Chains {
+			p.prefetchTokens(context.Background(), n.chains[chainCfg.Name], chainCfg.Tokens)
+		}
+
+		var (
+			update networkUpdate
+			err    error
+		)
+		update.senders, update.defaultTargets, update.routes, err = newNotifierRoutes(networkCfg, p.threads, p.deadLetters)
+		if err != nil {
+			log.GetLogger().Errorw("Failed to create the notifiers", "error", err, "network", n.name)
+			return err
+		}
+		updates[i] = update
+	}
+
+	for i, n := range p.networks {
+		if err := n.notifier.Update(updates[i].senders, updates[i].defaultTargets, updates[i].routes); err != nil {
+			log.GetLogger().Errorw("Failed to update the notifier routes", "error", err, "network", n.name)
+			return err
+		}
+	}
+
+	if !reflect.DeepEqual(current.PricesConfig, next.PricesConfig) {
+		p.prices.Store(newPrices(&next.PricesConfig))
+	}
+
+	p.addresses.Store(addresses)
+	p.cfg.Store(&next)
+
+	for i, networkCfg := range next.NetworkConfigs() {
+		p.networks[i].updateWhaleWindows(&networkCfg.Whales)
+	}
+
+	for _, n := range p.networks {
+		for _, name := range n.chainNames {
+			c := n.chains[name]
+			c.listener.SetSubscribeRequests(p.subscribeRequests(c))
+		}
+	}
+
+	log.GetLogger().Infow("Reloaded configuration", "config", &next)
+
+	return nil
+}
+
+// keepRestartOnlyFields copies the fields which can't change at runtime from
+// current to next, warning about the ignored changes. Adding, removing or
+// renaming networks and adding, removing or re-pairing chains is refused.
+func keepRestartOnlyFields(current, next *Config) error {
+	currentNetworks, nextNetworks := current.NetworkConfigs(), next.NetworkConfigs()
+	if len(currentNetworks) != len(nextNetworks) {
+		return errors.New("adding or removing networks requires a restart")
+	}
+
+	for i := range nextNetworks {
+		if currentNetworks[i].Network != nextNetworks[i].Network {
+			return fmt.Errorf("renaming the network %s requires a restart", currentNetworks[i].Network)
+		}
+
+		if err := keepRestartOnlyNetworkFields(currentNetworks[i], nextNetworks[i]); err != nil {
+			return err
+		}
+	}
+
+	fields := []struct {
+		name    string
+		current any
+		next    any
+	}{
+		{"redis", &current.RedisConfig, &next.RedisConfig},
+		{"storage", &current.StorageConfig, &next.StorageConfig},
+		{"archive", &current.ArchiveConfig, &next.ArchiveConfig},
+		{"publisher", &current.PublisherConfig, &next.PublisherConfig},
+		{"stream", &current.StreamConfig, &next.StreamConfig},
+		{"supervisor", &current.SupervisorConfig, &next.SupervisorConfig},
+		{"admin", &current.AdminConfig, &next.AdminConfig},
+		{"digest", &current.DigestConfig, &next.DigestConfig},
+	}
+
+	for _, field := range fields {
+		currentValue := reflect.ValueOf(field.current).Elem()
+		nextValue := reflect.ValueOf(field.next).Elem()
+
+		if !reflect.DeepEqual(currentValue.Interface(), nextValue.Interface()) {
+			log.GetLogger().Warnw("The config change requires a restart, ignored", "field", field.name)
+			nextValue.Set(currentValue)
+		}
+	}
+
+	return nil
+}
+
+func keepRestartOnlyNetworkFields(current, next *NetworkConfig) error {
+	if len(current.Chains) != len(next.Chains) {
+		return fmt.Errorf("adding or removing chains of the network %s requires a restart", current.Network)
+	}
+
+	for i := range next.Chains {
+		currentChain, nextChain := &current.Chains[i], &next.Chains[i]
+
+		if currentChain.Name != nextChain.Name || currentChain.Layer != nextChain.Layer || currentChain.SettlesTo != nextChain.SettlesTo {
+			return fmt.Errorf("changing the chain %s requires a restart", currentChain.Name)
+		}
+
+		if currentChain.WsRpc != nextChain.WsRpc || currentChain.HttpRpc != nextChain.HttpRpc {
+			log.GetLogger().Warnw("The config change requires a restart, ignored", "network", current.Network, "field", fmt.Sprintf("chains[%d] rpc", i))
+			nextChain.WsRpc, nextChain.HttpRpc = currentChain.WsRpc, currentChain.HttpRpc
+		}
+	}
+
+	if current.Thresholds.ResubscribeRetries != next.Thresholds.ResubscribeRetries {
+		log.GetLogger().Warnw("The config change requires a restart, ignored", "network", current.Network, "field", "thresholds.resubscribe_retries")
+		next.Thresholds.ResubscribeRetries = current.Thresholds.ResubscribeRetries
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package thanosnotif
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/listener"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/token"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/watchdog"
+)
+
+// newTestApp builds the networks of cfg without connecting to the chains.
+func newTestApp(t *testing.T, cfg *Config) *App {
+	app := &App{tokens: token.NewRegistry(nil), threads: notification.NewMemoryThreadStore(0)}
+	app.cfg.Store(cfg)
+	app.prices.Store(newPrices(&cfg.PricesConfig))
+
+	addresses, err := newAddressBook(&cfg.AddressBook)
+	require.NoError(t, err)
+	app.addresses.Store(addresses)
+
+	for _, networkCfg := range cfg.NetworkConfigs() {
+		notifier, err := newNotifier(networkCfg, nil, nil)
+		require.NoError(t, err)
+
+		n := &network{
+			name:     networkCfg.Network,
+			chains:   make(map[string]*chain),
+			rollups:  make(map[string]*rollup),
+			notifier: notifier,
+		}
+		n.updateWhaleWindows(&networkCfg.Whales)
+
+		for _, chainCfg := range networkCfg.Chains {
+			c := &chain{name: chainCfg.Name, layer: chainCfg.Layer, network: n, watchdog: watchdog.New()}
+			if c.layer == types.LayerL2 {
+				c.production = watchdog.NewProduction()
+			}
+			n.chains[c.name] = c
+			n.chainNames = append(n.chainNames, chainCfg.Name)
+		}
+
+		for _, chainCfg := range networkCfg.Chains {
+			if chainCfg.Layer == types.LayerL2 {
+				n.rollups[chainCfg.Name] = &rollup{l1: n.chains[chainCfg.SettlesTo], l2: n.chains[chainCfg.Name], settlement: watchdog.NewSettlement()}
+			}
+		}
+
+		for _, name := range n.chainNames {
+			c := n.chains[name]
+			c.listener, err = listener.MakeService(name, nil, nil)
+			require.NoError(t, err)
+			c.listener.SetSubscribeRequests(app.subscribeRequests(c))
+		}
+
+		app.networks = append(app.networks, n)
+	}
+
+	return app
+}
+
+func testNetwork(app *App, name string) *network {
+	for _, n := range app.networks {
+		if n.name == name {
+			return n
+		}
+	}
+
+	return nil
+}
+
+func subscribed(app *App, networkName, chainName, bridge, eventABI string) bool {
+	key := listener.MakeEventRequest(nil, bridge, eventABI, nil).SerializeEventRequest()
+	return testNetwork(app, networkName).chains[chainName].listener.RequestByKey(key) != nil
+}
+
+func testChainsConfig() []ChainConfig {
+	return []ChainConfig{
+		{Name: "l1", Layer: types.LayerL1, WsRpc: "ws://l1"},
+		{
+			Name:      "thanos-a",
+			Layer:     types.LayerL2,
+			SettlesTo: "l1",
+			Bridges: BridgesConfig{
+				L1Standard: "0x00000000000000000000000000000000000000a1",
+				L2Standard: "0x00000000000000000000000000000000000000a2",
+			},
+		},
+		{
+			Name:      "thanos-b",
+			Layer:     types.LayerL2,
+			SettlesTo: "l1",
+			Bridges: BridgesConfig{
+				L1Standard: "0x00000000000000000000000000000000000000b1",
+				L2Standard: "0x00000000000000000000000000000000000000b2",
+			},
+		},
+	}
+}
+
+func TestApp_subscribeRequests(t *testing.T) {
+	cfg := &Config{
+		NetworkConfig: NetworkConfig{
+			Network:   "sepolia",
+			Chains:    testChainsConfig(),
+			Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"}},
+		},
+	}
+	app := newTestApp(t, cfg)
+
+	// the l1 listener watches the l1 bridges of both rollups
+	assert.True(t, subscribed(app, "sepolia", "l1", "0x00000000000000000000000000000000000000a1", ETHDepositInitiatedEventABI))
+	assert.True(t, subscribed(app, "sepolia", "l1", "0x00000000000000000000000000000000000000b1", ETHDepositInitiatedEventABI))
+	assert.False(t, subscribed(app, "sepolia", "l1", "0x00000000000000000000000000000000000000a2", DepositFinalizedEventABI))
+
+	assert.True(t, subscribed(app, "sepolia", "thanos-a", "0x00000000000000000000000000000000000000a2", DepositFinalizedEventABI))
+	assert.False(t, subscribed(app, "sepolia", "thanos-a", "0x00000000000000000000000000000000000000b2", DepositFinalizedEventABI))
+	assert.True(t, subscribed(app, "sepolia", "thanos-b", "0x00000000000000000000000000000000000000b2", DepositFinalizedEventABI))
+
+	assert.Equal(t, "sepolia thanos-b", app.rollupLabel(testNetwork(app, "sepolia").rollups["thanos-b"]))
+}
+
+func TestApp_Reload(t *testing.T) {
+	cfg := &Config{
+		NetworkConfig: NetworkConfig{
+			Network:   "sepolia",
+			Chains:    testChainsConfig(),
+			Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"}},
+		},
+	}
+	app := newTestApp(t, cfg)
+
+	next := *cfg
+	next.Chains = testChainsConfig()
+	next.Chains[0].WsRpc = "ws://other"
+	next.Chains[1].Bridges.L1Standard = "0x00000000000000000000000000000000000000c1"
+	next.Notifiers = append(next.Notifiers, NotifierConfig{Name: "ops", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/ops"})
+	next.Routing.Default = []string{"ops"}
+	next.AdminConfig = AdminConfig{HTTPAddr: ":9090", Token: "other"}
+
+	require.NoError(t, app.Reload(&next))
+
+	assert.False(t, subscribed(app, "sepolia", "l1", "0x00000000000000000000000000000000000000a1", ETHDepositInitiatedEventABI))
+	assert.True(t, subscribed(app, "sepolia", "l1", "0x00000000000000000000000000000000000000c1", ETHDepositInitiatedEventABI))
+	assert.True(t, subscribed(app, "sepolia", "l1", "0x00000000000000000000000000000000000000b1", ETHDepositInitiatedEventABI))
+
+	// the endpoints can't change without a restart
+	assert.Equal(t, "ws://l1", app.config().NetworkByName("sepolia").Chain("l1").WsRpc)
+	assert.Equal(t, AdminConfig{}, app.config().AdminConfig)
+	assert.Equal(t, "0x00000000000000000000000000000000000000c1", app.bridges(testNetwork(app, "sepolia").rollups["thanos-a"]).L1Standard)
+
+	// an unknown notifier keeps the current config
+	invalid := next
+	invalid.Chains = testChainsConfig()
+	invalid.Routing.Default = []string{"email"}
+
+	require.Error(t, app.Reload(&invalid))
+	assert.Equal(t, "0x00000000000000000000000000000000000000c1", app.bridges(testNetwork(app, "sepolia").rollups["thanos-a"]).L1Standard)
+
+	// the chains can't be added or removed
+	removed := next
+	removed.Chains = testChainsConfig()[:2]
+
+	require.Error(t, app.Reload(&removed))
+}
+
+func TestApp_Networks(t *testing.T) {
+	mainnet := NetworkConfig{
+		Network:   "mainnet",
+		Chains:    testChainsConfig(),
+		Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/mainnet"}},
+	}
+	sepolia := NetworkConfig{
+		Network:   "sepolia",
+		Chains:    testChainsConfig()[:2],
+		Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/sepolia"}},
+	}
+	sepolia.Chains[1].Bridges.L1Standard = "0x00000000000000000000000000000000000000d1"
+
+	cfg := &Config{Networks: []NetworkConfig{mainnet, sepolia}}
+	app := newTestApp(t, cfg)
+	require.Len(t, app.networks, 2)
+
+	// each network listens to its own bridges
+	assert.True(t, subscribed(app, "mainnet", "l1", "0x00000000000000000000000000000000000000a1", ETHDepositInitiatedEventABI))
+	assert.False(t, subscribed(app, "mainnet", "l1", "0x00000000000000000000000000000000000000d1", ETHDepositInitiatedEventABI))
+	assert.True(t, subscribed(app, "sepolia", "l1", "0x00000000000000000000000000000000000000d1", ETHDepositInitiatedEventABI))
+	assert.False(t, subscribed(app, "sepolia", "l1", "0x00000000000000000000000000000000000000b1", ETHDepositInitiatedEventABI))
+
+	assert.Equal(t, "mainnet thanos-a", app.rollupLabel(testNetwork(app, "mainnet").rollups["thanos-a"]))
+	assert.Equal(t, "sepolia", app.rollupLabel(testNetwork(app, "sepolia").rollups["thanos-a"]))
+
+	// the networks can't be added or removed
+	removed := *cfg
+	removed.Networks = []NetworkConfig{mainnet}
+
+	require.Error(t, app.Reload(&removed))
+}
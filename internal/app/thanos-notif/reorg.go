@@ -0,0 +1,111 @@
+package thanosnotif
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/listener"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	// maxNotifiedBlocks is the number of the recent blocks of a chain whose
+	// notifications can be retracted.
+	maxNotifiedBlocks = 256
+
+	reorgAlert = "reorg"
+)
+
+type notifiedEvent struct {
+	title  string
+	thread string
+}
+
+// notifiedBlocks keeps the notifications of the recent blocks of a chain.
+type notifiedBlocks struct {
+	mu     sync.Mutex
+	events map[common.Hash][]notifiedEvent
+	order  []common.Hash
+}
+
+func (b *notifiedBlocks) add(blockHash common.Hash, event notifiedEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.events == nil {
+		b.events = make(map[common.Hash][]notifiedEvent)
+	}
+
+	if _, ok := b.events[blockHash]; !ok {
+		b.order = append(b.order, blockHash)
+		if len(b.order) > maxNotifiedBlocks {
+			delete(b.events, b.order[0])
+			b.order = b.order[1:]
+		}
+	}
+	b.events[blockHash] = append(b.events[blockHash], event)
+}
+
+// take returns and forgets the notifications of the block.
+func (b *notifiedBlocks) take(blockHash common.Hash) []notifiedEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events, ok := b.events[blockHash]
+	if !ok {
+		return nil
+	}
+	delete(b.events, blockHash)
+
+	for i, hash := range b.order {
+		if hash == blockHash {
+			b.order = append(b.order[:i], b.order[i+1:]...)
+			break
+		}
+	}
+
+	return events
+}
+
+// trackNotified keeps the notification of the event to retract it when its
+// block is re-organized.
+func (p *App) trackNotified(c *chain, event *types.BridgeEvent, msg *notification.Message) {
+	c.notified.add(event.BlockHash, notifiedEvent{title: msg.Title, thread: msg.Thread})
+}
+
+// retractHandler notifies the retraction of the events notified from a
+// re-organized block, in the threads of their transfers.
+func (p *App) retractHandler(c *chain) listener.ReorgHandler {
+	return func(ctx context.Context, removedBlockHash common.Hash, newHeader *ethereumTypes.Header) {
+		for _, event := range c.notified.take(removedBlockHash) {
+			p.notifyRetraction(c, removedBlockHash, newHeader, event)
+		}
+	}
+}
+
+func (p *App) notifyRetraction(c *chain, removedBlockHash common.Hash, newHeader *ethereumTypes.Header, event notifiedEvent) {
+	n := c.network
+	msg := &notification.Message{
+		Title: fmt.Sprintf("[%s] [%s Event Retracted]", n.name, c.name),
+		Text: fmt.Sprintf("The block %s was re-organized, replaced by the block %d %s.\nRetracted: %s",
+			removedBlockHash, newHeader.Number.Uint64(), newHeader.Hash(), event.title),
+		Severity: notification.SeverityWarning,
+		Attributes: map[string]string{
+			"network": n.name,
+			"layer":   c.layer,
+			"chain":   c.name,
+			"alert":   reorgAlert,
+		},
+		Thread: event.thread,
+	}
+
+	if err := n.notifier.Notify(msg); err != nil {
+		log.GetLogger().Errorw("Failed to notify the retracted event", "error", err, "chain", c.key(), "block", removedBlockHash)
+	}
+}
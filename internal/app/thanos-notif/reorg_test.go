@@ -0,0 +1,61 @@
+package thanosnotif
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+func TestApp_retractHandler(t *testing.T) {
+	app := newTestApp(t, &Config{
+		NetworkConfig: NetworkConfig{
+			Network:   "sepolia",
+			Chains:    testChainsConfig(),
+			Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"}},
+		},
+	})
+
+	n := testNetwork(app, "sepolia")
+	slack := &testSender{}
+	require.NoError(t, n.notifier.Update(map[string]notification.Sender{"slack": slack}, []string{"slack"}, nil))
+
+	c := n.chains["l1"]
+	removed := common.HexToHash("0xdead")
+	app.trackNotified(c, &types.BridgeEvent{BlockHash: removed}, &notification.Message{Title: "[sepolia] [ETH Deposit Initialized]", Thread: "sepolia:transfer"})
+	app.trackNotified(c, &types.BridgeEvent{BlockHash: common.HexToHash("0xbeef")}, &notification.Message{Title: "kept"})
+
+	handler := app.retractHandler(c)
+	handler(context.Background(), removed, &ethereumTypes.Header{Number: big.NewInt(100)})
+
+	require.Len(t, slack.messages, 1)
+	msg := slack.messages[0]
+	assert.Equal(t, "[sepolia] [l1 Event Retracted]", msg.Title)
+	assert.Contains(t, msg.Text, "Retracted: [sepolia] [ETH Deposit Initialized]")
+	assert.Equal(t, "sepolia:transfer", msg.Thread)
+	assert.Equal(t, reorgAlert, msg.Attributes["alert"])
+	assert.Equal(t, notification.SeverityWarning, msg.Severity)
+
+	// the notifications are retracted once
+	handler(context.Background(), removed, &ethereumTypes.Header{Number: big.NewInt(101)})
+	assert.Len(t, slack.messages, 1)
+}
+
+func Test_notifiedBlocks(t *testing.T) {
+	var blocks notifiedBlocks
+	for i := 0; i <= maxNotifiedBlocks; i++ {
+		blocks.add(common.BigToHash(big.NewInt(int64(i))), notifiedEvent{title: "event"})
+	}
+
+	// the oldest block is dropped
+	assert.Empty(t, blocks.take(common.BigToHash(big.NewInt(0))))
+	assert.Len(t, blocks.take(common.BigToHash(big.NewInt(maxNotifiedBlocks))), 1)
+	assert.Len(t, blocks.order, maxNotifiedBlocks-1)
+}
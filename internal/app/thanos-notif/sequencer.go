@@ -0,0 +1,143 @@
+package thanosnotif
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/watchdog"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	// sequencerAlert is the alert attribute of the block production alerts of
+	// the L2 chains.
+	sequencerAlert = "sequencer"
+
+	defaultHaltAfter = time.Minute
+	defaultMaxDrift  = time.Minute
+	defaultBlockTime = 2 * time.Second
+	defaultTolerance = 0.5
+	defaultWindow    = 5 * time.Minute
+)
+
+// SequencerConfig alerts when the blocks of an L2 chain stop advancing, their
+// timestamps drift from the wall clock or their block time departs from the
+// expected one, and when the production recovers.
+type SequencerConfig struct {
+	Disabled bool `yaml:"disabled" toml:"disabled"`
+	// HaltAfter is the time without new block after which a chain is halted,
+	// 1m by default.
+	HaltAfter time.Duration `yaml:"halt_after" toml:"halt_after"`
+	// MaxDrift is the difference tolerated between the timestamp of the
+	// latest block and the wall clock, 1m by default.
+	MaxDrift time.Duration `yaml:"max_drift" toml:"max_drift"`
+	// BlockTime is the expected block time, 2s by default. The chains can
+	// override it.
+	BlockTime time.Duration `yaml:"block_time" toml:"block_time"`
+	// Tolerance is the fraction of the block time the average block time of
+	// the last Window can depart from it, 0.5 in 5m by default.
+	Tolerance float64       `yaml:"tolerance" toml:"tolerance"`
+	Window    time.Duration `yaml:"window" toml:"window"`
+}
+
+// sequencerTitles are the titles of the conditions, raised and resolved.
+var sequencerTitles = map[watchdog.Condition][2]string{
+	watchdog.ConditionHalt:      {"Sequencer Halted", "Block Production Resumed"},
+	watchdog.ConditionDrift:     {"Timestamp Drift", "Timestamp Drift Recovered"},
+	watchdog.ConditionBlockTime: {"Block Time Deviation", "Block Time Recovered"},
+}
+
+// productionConfig returns the block production thresholds of the chain,
+// defaulted.
+func (p *App) productionConfig(c *chain) watchdog.ProductionConfig {
+	networkCfg := p.networkConfig(c.network)
+	cfg := watchdog.ProductionConfig{
+		HaltAfter: networkCfg.Sequencer.HaltAfter,
+		MaxDrift:  networkCfg.Sequencer.MaxDrift,
+		BlockTime: networkCfg.Sequencer.BlockTime,
+		Tolerance: networkCfg.Sequencer.Tolerance,
+		Window:    networkCfg.Sequencer.Window,
+	}
+	if chainCfg := networkCfg.Chain(c.name); chainCfg != nil && chainCfg.BlockTime > 0 {
+		cfg.BlockTime = chainCfg.BlockTime
+	}
+
+	if cfg.HaltAfter == 0 {
+		cfg.HaltAfter = defaultHaltAfter
+	}
+	if cfg.MaxDrift == 0 {
+		cfg.MaxDrift = defaultMaxDrift
+	}
+	if cfg.BlockTime == 0 {
+		cfg.BlockTime = defaultBlockTime
+	}
+	if cfg.Tolerance == 0 {
+		cfg.Tolerance = defaultTolerance
+	}
+	if cfg.Window == 0 {
+		cfg.Window = defaultWindow
+	}
+
+	return cfg
+}
+
+func (p *App) notifySequencer(c *chain, alerts []watchdog.Alert) {
+	for _, alert := range alerts {
+		titles := sequencerTitles[alert.Condition]
+		title := titles[0]
+		if alert.Resolved {
+			title = titles[1]
+		}
+
+		msg := &notification.Message{
+			Title:    fmt.Sprintf("[%s] [%s %s]", c.network.name, c.name, title),
+			Text:     alert.Detail,
+			Severity: sequencerSeverity(alert.Condition),
+			Attributes: map[string]string{
+				"network":   c.network.name,
+				"layer":     c.layer,
+				"chain":     c.name,
+				"alert":     sequencerAlert,
+				"condition": string(alert.Condition),
+			},
+			DedupKey: fmt.Sprintf("sequencer:%s:%s", alert.Condition, c.key()),
+			Resolved: alert.Resolved,
+		}
+
+		if err := c.network.notifier.Notify(msg); err != nil {
+			log.GetLogger().Errorw("Failed to notify the sequencer alert", "error", err, "chain", c.key(), "condition", alert.Condition)
+		}
+	}
+}
+
+// sequencerSeverity is critical for a halted chain, warning for the others.
+func sequencerSeverity(condition watchdog.Condition) notification.Severity {
+	if condition == watchdog.ConditionHalt {
+		return notification.SeverityCritical
+	}
+
+	return notification.SeverityWarning
+}
+
+func (c *SequencerConfig) validate(v *validator, prefix string) {
+	if c.HaltAfter < 0 {
+		v.add(prefix+".halt_after", "must not be negative")
+	}
+
+	if c.MaxDrift < 0 {
+		v.add(prefix+".max_drift", "must not be negative")
+	}
+
+	if c.BlockTime < 0 {
+		v.add(prefix+".block_time", "must not be negative")
+	}
+
+	if c.Window < 0 {
+		v.add(prefix+".window", "must not be negative")
+	}
+
+	if c.Tolerance < 0 {
+		v.add(prefix+".tolerance", "must not be negative")
+	}
+}
@@ -0,0 +1,52 @@
+package thanosnotif
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/watchdog"
+)
+
+func TestApp_notifySequencer(t *testing.T) {
+	chains := testChainsConfig()
+	chains[2].BlockTime = time.Second
+
+	app := newTestApp(t, &Config{
+		NetworkConfig: NetworkConfig{
+			Network:   "sepolia",
+			Chains:    chains,
+			Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"}},
+			Sequencer: SequencerConfig{HaltAfter: 30 * time.Second},
+		},
+	})
+
+	n := testNetwork(app, "sepolia")
+	slack := &testSender{}
+	require.NoError(t, n.notifier.Update(map[string]notification.Sender{"slack": slack}, []string{"slack"}, nil))
+
+	assert.Nil(t, n.chains["l1"].production)
+	assert.Equal(t, watchdog.ProductionConfig{HaltAfter: 30 * time.Second, MaxDrift: time.Minute, BlockTime: 2 * time.Second, Tolerance: 0.5, Window: 5 * time.Minute}, app.productionConfig(n.chains["thanos-a"]))
+	assert.Equal(t, time.Second, app.productionConfig(n.chains["thanos-b"]).BlockTime)
+
+	c := n.chains["thanos-a"]
+	app.notifySequencer(c, []watchdog.Alert{{Condition: watchdog.ConditionHalt, Detail: "No new block since #100"}})
+	app.notifySequencer(c, []watchdog.Alert{{Condition: watchdog.ConditionHalt, Resolved: true, Detail: "Blocks are produced again"}})
+
+	require.Len(t, slack.messages, 2)
+	msg := slack.messages[0]
+	assert.Equal(t, "[sepolia] [thanos-a Sequencer Halted]", msg.Title)
+	assert.Equal(t, "No new block since #100", msg.Text)
+	assert.Equal(t, notification.SeverityCritical, msg.Severity)
+	assert.Equal(t, sequencerAlert, msg.Attributes["alert"])
+	assert.Equal(t, "halt", msg.Attributes["condition"])
+	assert.Equal(t, "sequencer:halt:sepolia:thanos-a", msg.DedupKey)
+
+	assert.Equal(t, "[sepolia] [thanos-a Block Production Resumed]", slack.messages[1].Title)
+	assert.True(t, slack.messages[1].Resolved)
+	assert.Equal(t, notification.SeverityCritical, slack.messages[1].Severity)
+	assert.Equal(t, msg.DedupKey, slack.messages[1].DedupKey)
+}
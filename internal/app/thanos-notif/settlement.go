@@ -0,0 +1,195 @@
+package thanosnotif
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/tokamak-network/tokamak-thanos/op-bindings/bindings"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/listener"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/watchdog"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	OutputProposedEventABI = "OutputProposed(bytes32,uint256,uint256,uint256)"
+
+	// settlementAlert is the alert attribute of the batch and output alerts
+	// of the L2 chains.
+	settlementAlert = "settlement"
+
+	defaultBatchTimeout  = time.Hour
+	defaultOutputTimeout = 2 * time.Hour
+	defaultMaxOutputLag  = 7200
+)
+
+// SettlementConfig alerts when the batcher or the proposer of an L2 chain
+// stops posting to L1, and when the outputs lag behind the L2 head.
+type SettlementConfig struct {
+	// BatchTimeout is the time without batch after which the batcher is
+	// alerted, 1h by default.
+	BatchTimeout time.Duration `yaml:"batch_timeout" toml:"batch_timeout"`
+	// OutputTimeout is the time without OutputProposed after which the
+	// proposer is alerted, 2h by default.
+	OutputTimeout time.Duration `yaml:"output_timeout" toml:"output_timeout"`
+	// MaxOutputLag is the number of L2 blocks the latest output can be behind
+	// the L2 head, 7200 by default.
+	MaxOutputLag uint64 `yaml:"max_output_lag" toml:"max_output_lag"`
+}
+
+// settlementTitles are the titles of the conditions, raised and resolved.
+var settlementTitles = map[watchdog.Condition][2]string{
+	watchdog.ConditionBatchStale:  {"Batches Stalled", "Batches Resumed"},
+	watchdog.ConditionOutputStale: {"Outputs Stalled", "Outputs Resumed"},
+	watchdog.ConditionOutputLag:   {"Output Lag", "Output Lag Recovered"},
+}
+
+// settlementRequests returns the subscriptions to the batch inbox and the
+// L2OutputOracle of the L2 chain configured with them.
+func (p *App) settlementRequests(r *rollup, chainCfg *ChainConfig) []listener.RequestSubscriber {
+	var requests []listener.RequestSubscriber
+	if chainCfg.BatchInbox != "" {
+		requests = append(requests, listener.MakeTransactionRequest(chainCfg.BatchInbox, p.batchHandler(r, common.HexToAddress(chainCfg.Batcher))))
+	}
+
+	if chainCfg.L2OutputOracle != "" {
+		requests = append(requests, listener.MakeEventRequest(r.network().notifier, chainCfg.L2OutputOracle, OutputProposedEventABI, p.outputProposedHandler(r)))
+	}
+
+	return requests
+}
+
+// batchHandler records the batches sent to the inbox by the batcher, the
+// inbox accepting transactions from anyone.
+func (p *App) batchHandler(r *rollup, batcher common.Address) listener.TransactionHandler {
+	signer := ethereumTypes.LatestSignerForChainID(new(big.Int).SetUint64(r.l1.chainID))
+
+	return func(tx *listener.Transaction) {
+		sender, err := ethereumTypes.Sender(signer, tx.Tx)
+		if err != nil {
+			log.GetLogger().Errorw("Failed to get the sender of the batch", "error", err, "tx", tx.Tx.Hash(), "chain", r.l2.key())
+			return
+		}
+
+		if sender != batcher {
+			log.GetLogger().Warnw("Ignored the batch inbox transaction of another sender", "tx", tx.Tx.Hash(), "sender", sender, "chain", r.l2.key())
+			return
+		}
+
+		r.settlement.BatchPosted(tx.Header.Number.Uint64(), time.Unix(int64(tx.Header.Time), 0))
+	}
+}
+
+// outputProposedHandler records the outputs, which are notified by their
+// absence only.
+func (p *App) outputProposedHandler(r *rollup) listener.EventHandler {
+	return func(vLog *ethereumTypes.Log) (*notification.Message, error) {
+		// parsing the log needs no client
+		filterer, err := bindings.NewL2OutputOracleFilterer(vLog.Address, nil)
+		if err != nil {
+			log.GetLogger().Errorw("L2OutputOracleFilterer instance fail", "error", err)
+			return nil, err
+		}
+
+		event, err := filterer.ParseOutputProposed(*vLog)
+		if err != nil {
+			log.GetLogger().Errorw("OutputProposed event parsing fail", "error", err)
+			return nil, err
+		}
+
+		log.GetLogger().Infow("Got OutputProposed Event", "output_root", common.Hash(event.OutputRoot), "l2_block", event.L2BlockNumber, "index", event.L2OutputIndex)
+		r.settlement.OutputProposed(event.L2BlockNumber.Uint64(), time.Unix(event.L1Timestamp.Int64(), 0))
+
+		return nil, nil
+	}
+}
+
+// settlementConfig returns the settlement thresholds of the rollup, defaulted,
+// without the conditions of the addresses not configured.
+func (p *App) settlementConfig(r *rollup) watchdog.SettlementConfig {
+	networkCfg := p.networkConfig(r.network())
+	chainCfg := networkCfg.Chain(r.l2.name)
+	if chainCfg == nil {
+		return watchdog.SettlementConfig{}
+	}
+
+	var cfg watchdog.SettlementConfig
+	if chainCfg.BatchInbox != "" {
+		cfg.BatchTimeout = networkCfg.Settlement.BatchTimeout
+		if cfg.BatchTimeout == 0 {
+			cfg.BatchTimeout = defaultBatchTimeout
+		}
+	}
+
+	if chainCfg.L2OutputOracle != "" {
+		cfg.OutputTimeout = networkCfg.Settlement.OutputTimeout
+		if cfg.OutputTimeout == 0 {
+			cfg.OutputTimeout = defaultOutputTimeout
+		}
+
+		cfg.MaxOutputLag = networkCfg.Settlement.MaxOutputLag
+		if cfg.MaxOutputLag == 0 {
+			cfg.MaxOutputLag = defaultMaxOutputLag
+		}
+	}
+
+	return cfg
+}
+
+// checkSettlement notifies the batch and output conditions of the rollup
+// raised or resolved since the last check.
+func (p *App) checkSettlement(r *rollup) {
+	r.settlement.L2Head(r.l2.watchdog.Head())
+
+	for _, alert := range r.settlement.Check(p.settlementConfig(r)) {
+		titles := settlementTitles[alert.Condition]
+		title := titles[0]
+		if alert.Resolved {
+			title = titles[1]
+		}
+
+		n := r.network()
+		msg := &notification.Message{
+			Title:    fmt.Sprintf("[%s] [%s %s]", n.name, r.l2.name, title),
+			Text:     alert.Detail,
+			Severity: settlementSeverity(alert.Condition),
+			Attributes: map[string]string{
+				"network":   n.name,
+				"layer":     r.l2.layer,
+				"chain":     r.l2.name,
+				"alert":     settlementAlert,
+				"condition": string(alert.Condition),
+			},
+			DedupKey: fmt.Sprintf("settlement:%s:%s", alert.Condition, r.l2.key()),
+			Resolved: alert.Resolved,
+		}
+
+		if err := n.notifier.Notify(msg); err != nil {
+			log.GetLogger().Errorw("Failed to notify the settlement alert", "error", err, "chain", r.l2.key(), "condition", alert.Condition)
+		}
+	}
+}
+
+// settlementSeverity is critical for a stalled batcher, whose L2 blocks
+// aren't safe anymore, warning for the outputs which only delay withdrawals.
+func settlementSeverity(condition watchdog.Condition) notification.Severity {
+	if condition == watchdog.ConditionBatchStale {
+		return notification.SeverityCritical
+	}
+
+	return notification.SeverityWarning
+}
+
+func (c *SettlementConfig) validate(v *validator, prefix string) {
+	if c.BatchTimeout < 0 {
+		v.add(prefix+".batch_timeout", "must not be negative")
+	}
+
+	if c.OutputTimeout < 0 {
+		v.add(prefix+".output_timeout", "must not be negative")
+	}
+}
@@ -0,0 +1,117 @@
+package thanosnotif
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/listener"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/watchdog"
+)
+
+func TestApp_settlement(t *testing.T) {
+	chains := testChainsConfig()
+	batcherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	chains[1].BatchInbox = "0xff00000000000000000000000000000000111551"
+	chains[1].Batcher = crypto.PubkeyToAddress(batcherKey.PublicKey).Hex()
+	chains[1].L2OutputOracle = "0x00000000000000000000000000000000000000a3"
+
+	app := newTestApp(t, &Config{
+		NetworkConfig: NetworkConfig{
+			Network:    "sepolia",
+			Chains:     chains,
+			Notifiers:  []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"}},
+			Settlement: SettlementConfig{OutputTimeout: time.Hour},
+		},
+	})
+
+	n := testNetwork(app, "sepolia")
+	slack := &testSender{}
+	require.NoError(t, n.notifier.Update(map[string]notification.Sender{"slack": slack}, []string{"slack"}, nil))
+
+	// the L1 listener subscribes to the batch inbox and the oracle of thanos-a
+	assert.True(t, subscribed(app, "sepolia", "l1", chains[1].L2OutputOracle, OutputProposedEventABI))
+	assert.NotNil(t, n.chains["l1"].listener.RequestByKey(listener.MakeTransactionRequest(chains[1].BatchInbox, nil).SerializeEventRequest()))
+	assert.False(t, subscribed(app, "sepolia", "thanos-a", chains[1].L2OutputOracle, OutputProposedEventABI))
+
+	a, b := n.rollups["thanos-a"], n.rollups["thanos-b"]
+	assert.Equal(t, watchdog.SettlementConfig{BatchTimeout: time.Hour, OutputTimeout: time.Hour, MaxOutputLag: 7200}, app.settlementConfig(a))
+	assert.Equal(t, watchdog.SettlementConfig{}, app.settlementConfig(b))
+
+	// an output proposed for the L2 block 100, far behind the L2 head
+	l1Timestamp := common.BigToHash(big.NewInt(time.Now().Unix()))
+	msg, err := app.outputProposedHandler(a)(&ethereumTypes.Log{
+		Address: common.HexToAddress(chains[1].L2OutputOracle),
+		Topics: []common.Hash{
+			crypto.Keccak256Hash([]byte(OutputProposedEventABI)),
+			common.HexToHash("0xabcd"),
+			common.BigToHash(big.NewInt(1)),
+			common.BigToHash(big.NewInt(100)),
+		},
+		Data: l1Timestamp.Bytes(),
+	})
+	require.NoError(t, err)
+	assert.Nil(t, msg)
+
+	a.l1.chainID = 11155111
+	inbox := common.HexToAddress(chains[1].BatchInbox)
+	batch := ethereumTypes.MustSignNewTx(batcherKey, ethereumTypes.LatestSignerForChainID(big.NewInt(11155111)), &ethereumTypes.DynamicFeeTx{ChainID: big.NewInt(11155111), To: &inbox})
+	app.batchHandler(a, common.HexToAddress(chains[1].Batcher))(&listener.Transaction{Tx: batch, Header: &ethereumTypes.Header{Number: big.NewInt(10), Time: uint64(time.Now().Unix())}})
+
+	a.l2.watchdog.HeadStored(&ethereumTypes.Header{Number: big.NewInt(10000)}, nil)
+	app.checkSettlement(a)
+	app.checkSettlement(b)
+
+	require.Len(t, slack.messages, 1)
+	msg = slack.messages[0]
+	assert.Equal(t, "[sepolia] [thanos-a Output Lag]", msg.Title)
+	assert.Equal(t, "The latest output of L2 block #100 is 9900 blocks behind the L2 head #10000", msg.Text)
+	assert.Equal(t, notification.SeverityWarning, msg.Severity)
+	assert.Equal(t, settlementAlert, msg.Attributes["alert"])
+	assert.Equal(t, "settlement:output_lag:sepolia:thanos-a", msg.DedupKey)
+}
+
+func TestApp_batchHandler(t *testing.T) {
+	app := newTestApp(t, &Config{
+		NetworkConfig: NetworkConfig{
+			Network:   "sepolia",
+			Chains:    testChainsConfig(),
+			Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"}},
+		},
+	})
+
+	a := testNetwork(app, "sepolia").rollups["thanos-a"]
+	a.l1.chainID = 11155111
+	r := &rollup{l1: a.l1, l2: a.l2, settlement: watchdog.NewSettlement()}
+	cfg := watchdog.SettlementConfig{BatchTimeout: time.Hour}
+
+	batcherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	inbox := common.HexToAddress("0xff00000000000000000000000000000000111551")
+	signer := ethereumTypes.LatestSignerForChainID(big.NewInt(11155111))
+	handler := app.batchHandler(r, crypto.PubkeyToAddress(batcherKey.PublicKey))
+	stale := &ethereumTypes.Header{Number: big.NewInt(10), Time: uint64(time.Now().Add(-2 * time.Hour).Unix())}
+
+	// the inbox transactions of another sender aren't batches
+	spam := ethereumTypes.MustSignNewTx(otherKey, signer, &ethereumTypes.DynamicFeeTx{ChainID: big.NewInt(11155111), To: &inbox})
+	handler(&listener.Transaction{Tx: spam, Header: stale})
+	assert.Empty(t, r.settlement.Check(cfg))
+
+	batch := ethereumTypes.MustSignNewTx(batcherKey, signer, &ethereumTypes.DynamicFeeTx{ChainID: big.NewInt(11155111), To: &inbox, Nonce: 1})
+	handler(&listener.Transaction{Tx: batch, Header: stale})
+	alerts := r.settlement.Check(cfg)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, watchdog.ConditionBatchStale, alerts[0].Condition)
+	assert.Contains(t, alerts[0].Detail, "L1 block #10")
+}
@@ -0,0 +1,133 @@
+package thanosnotif
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/archive"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/bcclient"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/database"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/listener"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/publisher"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/stream"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+type ArchiveConfig struct {
+	Database database.Config `yaml:"database" toml:"database"`
+	HTTPAddr string          `yaml:"http_addr" toml:"http_addr"`
+}
+
+type StreamConfig struct {
+	// GRPCAddr serves the JSON encoded gRPC stream for the Go clients of
+	// stream.GRPCClient, the other clients using WebSocketAddr.
+	GRPCAddr      string `yaml:"grpc_addr" toml:"grpc_addr"`
+	WebSocketAddr string `yaml:"websocket_addr" toml:"websocket_addr"`
+	BufferSize    int    `yaml:"buffer_size" toml:"buffer_size"`
+}
+
+type PublisherConfig struct {
+	RedisStream       string   `yaml:"redis_stream" toml:"redis_stream"`
+	RedisStreamMaxLen int64    `yaml:"redis_stream_max_len" toml:"redis_stream_max_len"`
+	KafkaBrokers      []string `yaml:"kafka_brokers" toml:"kafka_brokers"`
+	KafkaTopic        string   `yaml:"kafka_topic" toml:"kafka_topic"`
+}
+
+func (p *App) initArchive(ctx context.Context) error {
+	if p.config().ArchiveConfig.Database.Driver == "" {
+		return nil
+	}
+
+	db, err := database.New(ctx, p.config().ArchiveConfig.Database)
+	if err != nil {
+		log.GetLogger().Errorw("Failed to connect to the archive database", "error", err)
+		return err
+	}
+
+	store, err := archive.NewStore(ctx, db, p.config().ArchiveConfig.Database.Driver)
+	if err != nil {
+		log.GetLogger().Errorw("Failed to create the archive store", "error", err)
+		return err
+	}
+
+	p.archive = store
+	p.AddEventSink(store)
+
+	return nil
+}
+
+func (p *App) initPublisher(ctx context.Context) error {
+	transports := make([]publisher.Transport, 0)
+
+	if p.config().PublisherConfig.RedisStream != "" {
+		redisClient, err := p.getRedisClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		transports = append(transports, publisher.NewRedisStreamTransport(redisClient, p.config().PublisherConfig.RedisStream, p.config().PublisherConfig.RedisStreamMaxLen))
+	}
+
+	if p.config().PublisherConfig.KafkaTopic != "" {
+		transports = append(transports, publisher.NewKafkaTransport(p.config().PublisherConfig.KafkaBrokers, p.config().PublisherConfig.KafkaTopic))
+	}
+
+	if len(transports) == 0 {
+		return nil
+	}
+
+	p.AddEventSink(publisher.New(transports...))
+
+	return nil
+}
+
+func (p *App) initStream() {
+	if p.config().StreamConfig.GRPCAddr == "" && p.config().StreamConfig.WebSocketAddr == "" {
+		return
+	}
+
+	p.streamHub = stream.NewHub(p.config().StreamConfig.BufferSize)
+	p.AddEventSink(p.streamHub)
+}
+
+// reorgHandler retracts the events of a re-organized block from the sinks
+// supporting it.
+func (p *App) reorgHandler(bcClient *bcclient.Client) listener.ReorgHandler {
+	chainID := bcClient.ChainID().Uint64()
+
+	return func(ctx context.Context, removedBlockHash common.Hash, newHeader *ethereumTypes.Header) {
+		for _, sink := range p.sinks {
+			reorgSink, ok := sink.(ReorgSink)
+			if !ok {
+				continue
+			}
+
+			if err := reorgSink.Retract(ctx, chainID, removedBlockHash, newHeader); err != nil {
+				log.GetLogger().Errorw("Failed to retract the events of the re-organized block", "error", err, "block", removedBlockHash)
+			}
+		}
+	}
+}
+
+func (c *Config) validateSinks(v *validator) {
+	switch c.ArchiveConfig.Database.Driver {
+	case "":
+		if c.ArchiveConfig.HTTPAddr != "" {
+			v.add("archive.database.driver", "archive database is required to serve the archive api")
+		}
+	case database.DriverPostgres, database.DriverSQLite:
+		v.required("archive.database.dsn", c.ArchiveConfig.Database.DSN)
+	default:
+		v.add("archive.database.driver", "unknown archive database driver: %s", c.ArchiveConfig.Database.Driver)
+	}
+
+	if c.PublisherConfig.RedisStream != "" && c.RedisConfig.Addresses == "" {
+		v.add("redis.addresses", "redis address is required to publish to the redis stream")
+	}
+
+	if c.PublisherConfig.KafkaTopic != "" && len(c.PublisherConfig.KafkaBrokers) == 0 {
+		v.add("publisher.kafka_brokers", "kafka brokers are required to publish to the kafka topic")
+	}
+}
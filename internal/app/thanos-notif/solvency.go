@@ -0,0 +1,224 @@
+package thanosnotif
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/solvency"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	// solvencyAlert is the alert attribute of the reconciliation alerts.
+	solvencyAlert = "solvency"
+
+	solvencyTick            = time.Minute
+	defaultSolvencyInterval = 10 * time.Minute
+)
+
+// SolvencyConfig reconciles the L1 custody of the bridged tokens with their L2
+// supply periodically, disabled when no token is configured.
+type SolvencyConfig struct {
+	// Interval is the time between the reconciliations, 10m by default.
+	Interval time.Duration `yaml:"interval" toml:"interval"`
+	// Tolerance is the fraction of the custody by which the L2 supply, or the
+	// deposits the bridge balance, can exceed it, 0 by default.
+	Tolerance float64 `yaml:"tolerance" toml:"tolerance"`
+	// Surplus is the fraction of the L2 supply by which the custody can
+	// exceed it, e.g. with the withdrawals waiting for their finalization.
+	// Not checked when 0.
+	Surplus float64               `yaml:"surplus" toml:"surplus"`
+	Tokens  []SolvencyTokenConfig `yaml:"tokens" toml:"tokens"`
+}
+
+type SolvencyTokenConfig struct {
+	// Chain is the L2 chain the token is bridged to.
+	Chain string `yaml:"chain" toml:"chain"`
+	// L1Token is the token on L1, the ETH held by the OptimismPortal when
+	// empty, and L2Token its OptimismMintableERC20.
+	L1Token string `yaml:"l1_token" toml:"l1_token"`
+	L2Token string `yaml:"l2_token" toml:"l2_token"`
+}
+
+// solvencyTitles are the titles of the conditions, raised and resolved.
+var solvencyTitles = map[solvency.Condition][2]string{
+	solvency.ConditionDeficit:   {"Bridge Deficit", "Bridge Deficit Resolved"},
+	solvency.ConditionSurplus:   {"Bridge Surplus", "Bridge Surplus Resolved"},
+	solvency.ConditionShortfall: {"Bridge Shortfall", "Bridge Shortfall Resolved"},
+}
+
+// runSolvency reconciles the bridged tokens of the networks at their interval
+// until ctx is done.
+func (p *App) runSolvency(ctx context.Context) error {
+	ticker := time.NewTicker(solvencyTick)
+	defer ticker.Stop()
+
+	for {
+		p.reconcile(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *App) reconcile(ctx context.Context, now time.Time) {
+	for _, n := range p.networks {
+		cfg := p.networkConfig(n).Solvency
+		if len(cfg.Tokens) == 0 {
+			continue
+		}
+
+		interval := cfg.Interval
+		if interval == 0 {
+			interval = defaultSolvencyInterval
+		}
+		if now.Sub(n.solvencyChecked) < interval {
+			continue
+		}
+		n.solvencyChecked = now
+
+		for _, tokenCfg := range cfg.Tokens {
+			r, ok := n.rollups[tokenCfg.Chain]
+			if !ok {
+				continue
+			}
+
+			balance, err := p.fetchBalance(ctx, r, tokenCfg)
+			if err != nil {
+				log.GetLogger().Errorw("Failed to reconcile the bridged token", "error", err, "chain", r.l2.key(), "l1_token", tokenCfg.L1Token, "l2_token", tokenCfg.L2Token)
+				continue
+			}
+
+			p.checkSolvency(r, tokenCfg, balance, &cfg)
+		}
+	}
+}
+
+func (p *App) fetchBalance(ctx context.Context, r *rollup, tokenCfg SolvencyTokenConfig) (*solvency.Balance, error) {
+	ctx, cancel := context.WithTimeout(ctx, eventProcessTimeout)
+	defer cancel()
+
+	bridge := solvency.Bridge{L1StandardBridge: common.HexToAddress(p.bridges(r).L1Standard)}
+	if chainCfg := p.networkConfig(r.network()).Chain(r.l2.name); chainCfg != nil {
+		bridge.OptimismPortal = common.HexToAddress(chainCfg.OptimismPortal)
+	}
+
+	token := solvency.Token{
+		L1Token: common.HexToAddress(tokenCfg.L1Token),
+		L2Token: common.HexToAddress(tokenCfg.L2Token),
+	}
+
+	return solvency.Fetch(ctx, r.l1.client.GetClient(), r.l2.client.GetClient(), bridge, token)
+}
+
+// checkSolvency notifies the conditions of the balance of the token raised or
+// resolved since the last reconciliation.
+func (p *App) checkSolvency(r *rollup, tokenCfg SolvencyTokenConfig, balance *solvency.Balance, cfg *SolvencyConfig) {
+	n := r.network()
+	if n.solvency == nil {
+		n.solvency = make(map[string]bool)
+	}
+
+	// the token is labelled as in the bridge events
+	asset := &types.BridgeEvent{}
+	p.setAsset(asset, r.l1, common.HexToAddress(tokenCfg.L1Token))
+	label := asset.Asset
+	if label == "" {
+		label = asset.Symbol
+	}
+
+	conditions := balance.Check(cfg.Tolerance, cfg.Surplus)
+	for _, condition := range solvency.Conditions {
+		key := fmt.Sprintf("%s:%s:%s:%s", condition, r.l2.key(), common.HexToAddress(tokenCfg.L1Token).Hex(), common.HexToAddress(tokenCfg.L2Token).Hex())
+		if conditions[condition] == n.solvency[key] {
+			continue
+		}
+		n.solvency[key] = conditions[condition]
+
+		titles := solvencyTitles[condition]
+		title := titles[0]
+		if !conditions[condition] {
+			title = titles[1]
+		}
+
+		var text strings.Builder
+		fmt.Fprintf(&text, "L1 custody: %s %s\n", formatAmount(balance.Custody, asset.Decimals), label)
+		if balance.Held != nil {
+			fmt.Fprintf(&text, "L1 bridge balance: %s %s\n", formatAmount(balance.Held, asset.Decimals), label)
+		}
+		fmt.Fprintf(&text, "L2 supply: %s %s\n", formatAmount(balance.Supply, asset.Decimals), label)
+		fmt.Fprintf(&text, "Difference: %s %s", formatAmount(balance.Difference(), asset.Decimals), label)
+
+		msg := &notification.Message{
+			Title:    fmt.Sprintf("[%s] [%s %s %s]", n.name, r.l2.name, label, title),
+			Text:     text.String(),
+			Severity: solvencySeverity(condition),
+			Attributes: map[string]string{
+				"network":   n.name,
+				"layer":     r.l2.layer,
+				"chain":     r.l2.name,
+				"asset":     label,
+				"alert":     solvencyAlert,
+				"condition": string(condition),
+			},
+			DedupKey: "solvency:" + key,
+			Resolved: !conditions[condition],
+		}
+
+		if err := n.notifier.Notify(msg); err != nil {
+			log.GetLogger().Errorw("Failed to notify the solvency alert", "error", err, "chain", r.l2.key(), "condition", condition)
+		}
+	}
+}
+
+// solvencySeverity is critical for the tokens missing on L1, warning for a
+// surplus.
+func solvencySeverity(condition solvency.Condition) notification.Severity {
+	if condition == solvency.ConditionSurplus {
+		return notification.SeverityWarning
+	}
+
+	return notification.SeverityCritical
+}
+
+func (c *NetworkConfig) validateSolvency(v *validator, prefix string) {
+	if c.Solvency.Interval < 0 {
+		v.add(prefix+"solvency.interval", "must not be negative")
+	}
+
+	if c.Solvency.Tolerance < 0 {
+		v.add(prefix+"solvency.tolerance", "must not be negative")
+	}
+
+	if c.Solvency.Surplus < 0 {
+		v.add(prefix+"solvency.surplus", "must not be negative")
+	}
+
+	for i, token := range c.Solvency.Tokens {
+		path := fmt.Sprintf("%ssolvency.tokens[%d]", prefix, i)
+
+		chain := c.Chain(token.Chain)
+		if chain == nil || chain.Layer != types.LayerL2 {
+			v.add(path+".chain", "unknown l2 chain: %s", token.Chain)
+		} else if token.L1Token == "" && chain.OptimismPortal == "" {
+			v.add(path+".l1_token", "the ETH needs the optimism_portal of the chain %s", chain.Name)
+		}
+
+		if token.L1Token != "" && !common.IsHexAddress(token.L1Token) {
+			v.add(path+".l1_token", "invalid address")
+		}
+
+		if !common.IsHexAddress(token.L2Token) {
+			v.add(path+".l2_token", "invalid address")
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package thanosnotif
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/solvency"
+)
+
+func TestApp_checkSolvency(t *testing.T) {
+	chains := testChainsConfig()
+	chains[1].OptimismPortal = "0x00000000000000000000000000000000000000a4"
+
+	solvencyCfg := SolvencyConfig{
+		Tolerance: 0.01,
+		Tokens:    []SolvencyTokenConfig{{Chain: "thanos-a", L2Token: "0xDeadDeAddeAddEAddeadDEaDDEAdDeaDDeAD0000"}},
+	}
+	app := newTestApp(t, &Config{
+		NetworkConfig: NetworkConfig{
+			Network:   "sepolia",
+			Chains:    chains,
+			Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"}},
+			Solvency:  solvencyCfg,
+		},
+	})
+
+	n := testNetwork(app, "sepolia")
+	slack := &testSender{}
+	require.NoError(t, n.notifier.Update(map[string]notification.Sender{"slack": slack}, []string{"slack"}, nil))
+
+	r := n.rollups["thanos-a"]
+	ether := big.NewInt(1e18)
+	balance := func(custody, supply int64) *solvency.Balance {
+		return &solvency.Balance{
+			Custody: new(big.Int).Mul(big.NewInt(custody), ether),
+			Supply:  new(big.Int).Mul(big.NewInt(supply), ether),
+		}
+	}
+
+	// within the tolerance
+	app.checkSolvency(r, solvencyCfg.Tokens[0], balance(1000, 1005), &solvencyCfg)
+	require.Empty(t, slack.messages)
+
+	app.checkSolvency(r, solvencyCfg.Tokens[0], balance(1000, 1100), &solvencyCfg)
+	app.checkSolvency(r, solvencyCfg.Tokens[0], balance(1000, 1200), &solvencyCfg)
+	require.Len(t, slack.messages, 1)
+
+	msg := slack.messages[0]
+	assert.Equal(t, "[sepolia] [thanos-a ETH Bridge Deficit]", msg.Title)
+	assert.Equal(t, "L1 custody: 1000 ETH\nL2 supply: 1100 ETH\nDifference: -100 ETH", msg.Text)
+	assert.Equal(t, notification.SeverityCritical, msg.Severity)
+	assert.Equal(t, solvencyAlert, msg.Attributes["alert"])
+	assert.Equal(t, "deficit", msg.Attributes["condition"])
+	assert.Equal(t, "solvency:deficit:sepolia:thanos-a:0x0000000000000000000000000000000000000000:0xDeadDeAddeAddEAddeadDEaDDEAdDeaDDeAD0000", msg.DedupKey)
+	assert.False(t, msg.Resolved)
+
+	app.checkSolvency(r, solvencyCfg.Tokens[0], balance(1200, 1200), &solvencyCfg)
+	require.Len(t, slack.messages, 2)
+	assert.Equal(t, "[sepolia] [thanos-a ETH Bridge Deficit Resolved]", slack.messages[1].Title)
+	assert.True(t, slack.messages[1].Resolved)
+	assert.Equal(t, msg.DedupKey, slack.messages[1].DedupKey)
+}
@@ -0,0 +1,197 @@
+package thanosnotif
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/watchdog"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	// watchdogAlert is the alert attribute of the watchdog alerts, routed to
+	// the watchdog notifiers.
+	watchdogAlert     = "watchdog"
+	watchdogRouteName = "watchdog"
+
+	watchdogInterval = 30 * time.Second
+
+	defaultHeadTimeout    = 10 * time.Minute
+	defaultMaxLag         = 50
+	defaultRPCErrors      = 10
+	defaultRPCErrorWindow = 5 * time.Minute
+)
+
+// WatchdogConfig alerts the operators when a listener stops receiving heads,
+// lags behind its chain, fails to save its head or meets a burst of RPC
+// errors. Enabled when notifiers are configured.
+type WatchdogConfig struct {
+	// Notifiers receive the watchdog alerts instead of the routed notifiers.
+	Notifiers []string `yaml:"notifiers" toml:"notifiers"`
+	// HeadTimeout is the time without new head after which a chain is
+	// alerted, 10m by default. The chains can override it.
+	HeadTimeout time.Duration `yaml:"head_timeout" toml:"head_timeout"`
+	// MaxLag is the number of blocks the processed head can be behind the
+	// chain head, 50 by default.
+	MaxLag uint64 `yaml:"max_lag" toml:"max_lag"`
+	// RPCErrors is the number of RPC errors of a chain in RPCErrorWindow
+	// which is alerted, 10 in 5m by default.
+	RPCErrors      int           `yaml:"rpc_errors" toml:"rpc_errors"`
+	RPCErrorWindow time.Duration `yaml:"rpc_error_window" toml:"rpc_error_window"`
+}
+
+// watchdogTitles are the titles of the conditions, raised and resolved.
+var watchdogTitles = map[watchdog.Condition][2]string{
+	watchdog.ConditionNoHead:    {"Head Timeout", "Heads Resumed"},
+	watchdog.ConditionLag:       {"Head Lag", "Head Caught Up"},
+	watchdog.ConditionHeadStore: {"Head Store Failure", "Head Store Recovered"},
+	watchdog.ConditionRPCErrors: {"RPC Errors", "RPC Errors Recovered"},
+}
+
+// enabled reports whether the watchdog alerts have notifiers.
+func (c *WatchdogConfig) enabled() bool {
+	return len(c.Notifiers) > 0
+}
+
+// watchdogConfig returns the thresholds of the chain, defaulted.
+func (p *App) watchdogConfig(c *chain) watchdog.Config {
+	networkCfg := p.networkConfig(c.network)
+	cfg := watchdog.Config{
+		HeadTimeout:    networkCfg.Watchdog.HeadTimeout,
+		MaxLag:         networkCfg.Watchdog.MaxLag,
+		RPCErrors:      networkCfg.Watchdog.RPCErrors,
+		RPCErrorWindow: networkCfg.Watchdog.RPCErrorWindow,
+	}
+	if chainCfg := networkCfg.Chain(c.name); chainCfg != nil && chainCfg.HeadTimeout > 0 {
+		cfg.HeadTimeout = chainCfg.HeadTimeout
+	}
+
+	if cfg.HeadTimeout == 0 {
+		cfg.HeadTimeout = defaultHeadTimeout
+	}
+	if cfg.MaxLag == 0 {
+		cfg.MaxLag = defaultMaxLag
+	}
+	if cfg.RPCErrors == 0 {
+		cfg.RPCErrors = defaultRPCErrors
+	}
+	if cfg.RPCErrorWindow == 0 {
+		cfg.RPCErrorWindow = defaultRPCErrorWindow
+	}
+
+	return cfg
+}
+
+// runWatchdogs checks the listeners, the block production and the settlement
+// of the chains until ctx is done.
+func (p *App) runWatchdogs(ctx context.Context) error {
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		for _, n := range p.networks {
+			for _, name := range n.chainNames {
+				p.checkWatchdog(ctx, n.chains[name])
+
+				if r, ok := n.rollups[name]; ok {
+					p.checkSettlement(r)
+				}
+			}
+		}
+	}
+}
+
+// checkWatchdog polls the chain head of the chain and notifies the conditions
+// raised or resolved since the last check.
+func (p *App) checkWatchdog(ctx context.Context, c *chain) {
+	cfg := p.networkConfig(c.network)
+	watched := cfg.Watchdog.enabled()
+	sequenced := c.production != nil && !cfg.Sequencer.Disabled
+	if !watched && !sequenced {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, eventProcessTimeout)
+	defer cancel()
+
+	header, err := c.client.GetHeader(ctx)
+	if err != nil {
+		log.GetLogger().Errorw("Failed to get the chain head", "error", err, "chain", c.key())
+		c.watchdog.RPCFailed(err)
+	} else {
+		c.watchdog.ChainHead(header.Number.Uint64())
+		if c.production != nil {
+			c.production.Observe(header)
+		}
+	}
+
+	if watched {
+		p.notifyWatchdog(c, c.watchdog.Check(p.watchdogConfig(c)))
+	}
+
+	if sequenced {
+		p.notifySequencer(c, c.production.Check(p.productionConfig(c)))
+	}
+}
+
+func (p *App) notifyWatchdog(c *chain, alerts []watchdog.Alert) {
+	for _, alert := range alerts {
+		titles := watchdogTitles[alert.Condition]
+		title := titles[0]
+		if alert.Resolved {
+			title = titles[1]
+		}
+
+		msg := &notification.Message{
+			Title:    fmt.Sprintf("[%s] [%s %s]", c.network.name, c.name, title),
+			Text:     alert.Detail,
+			Severity: watchdogSeverity(alert.Condition),
+			Attributes: map[string]string{
+				"network":   c.network.name,
+				"layer":     c.layer,
+				"chain":     c.name,
+				"alert":     watchdogAlert,
+				"condition": string(alert.Condition),
+			},
+			DedupKey: fmt.Sprintf("watchdog:%s:%s", alert.Condition, c.key()),
+			Resolved: alert.Resolved,
+		}
+
+		if err := c.network.notifier.Notify(msg); err != nil {
+			log.GetLogger().Errorw("Failed to notify the watchdog alert", "error", err, "chain", c.key(), "condition", alert.Condition)
+		}
+	}
+}
+
+// watchdogSeverity is critical for the listeners missing the new heads or
+// unable to save them, warning for the others.
+func watchdogSeverity(condition watchdog.Condition) notification.Severity {
+	switch condition {
+	case watchdog.ConditionNoHead, watchdog.ConditionHeadStore:
+		return notification.SeverityCritical
+	default:
+		return notification.SeverityWarning
+	}
+}
+
+func (c *WatchdogConfig) validate(v *validator, prefix string) {
+	if c.HeadTimeout < 0 {
+		v.add(prefix+".head_timeout", "must not be negative")
+	}
+
+	if c.RPCErrors < 0 {
+		v.add(prefix+".rpc_errors", "must not be negative")
+	}
+
+	if c.RPCErrorWindow < 0 {
+		v.add(prefix+".rpc_error_window", "must not be negative")
+	}
+}
@@ -0,0 +1,66 @@
+package thanosnotif
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/watchdog"
+)
+
+func TestApp_notifyWatchdog(t *testing.T) {
+	chains := testChainsConfig()
+	chains[0].HeadTimeout = time.Hour
+
+	app := newTestApp(t, &Config{
+		NetworkConfig: NetworkConfig{
+			Network: "sepolia",
+			Chains:  chains,
+			Notifiers: []NotifierConfig{
+				{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"},
+				{Name: "ops", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/ops"},
+			},
+			Watchdog: WatchdogConfig{Notifiers: []string{"ops"}, MaxLag: 5},
+		},
+	})
+
+	n := testNetwork(app, "sepolia")
+	slack, ops := &testSender{}, &testSender{}
+	senders, defaultTargets, routes, err := newNotifierRoutes(app.networkConfig(n), nil, nil)
+	require.NoError(t, err)
+	senders["slack"], senders["ops"] = slack, ops
+	require.NoError(t, n.notifier.Update(senders, defaultTargets, routes))
+
+	assert.Equal(t, watchdog.Config{HeadTimeout: time.Hour, MaxLag: 5, RPCErrors: 10, RPCErrorWindow: 5 * time.Minute}, app.watchdogConfig(n.chains["l1"]))
+	assert.Equal(t, 10*time.Minute, app.watchdogConfig(n.chains["thanos-a"]).HeadTimeout)
+
+	c := n.chains["thanos-a"]
+	c.watchdog.HeadStored(&ethereumTypes.Header{Number: big.NewInt(100)}, nil)
+	c.watchdog.ChainHead(110)
+	app.notifyWatchdog(c, c.watchdog.Check(app.watchdogConfig(c)))
+
+	c.watchdog.HeadStored(&ethereumTypes.Header{Number: big.NewInt(110)}, nil)
+	app.notifyWatchdog(c, c.watchdog.Check(app.watchdogConfig(c)))
+
+	// the alerts only go to the ops channel
+	assert.Empty(t, slack.messages)
+	require.Len(t, ops.messages, 2)
+
+	msg := ops.messages[0]
+	assert.Equal(t, "[sepolia] [thanos-a Head Lag]", msg.Title)
+	assert.Equal(t, "The processed head 100 is 10 blocks behind the chain head 110", msg.Text)
+	assert.Equal(t, notification.SeverityWarning, msg.Severity)
+	assert.Equal(t, watchdogAlert, msg.Attributes["alert"])
+	assert.Equal(t, "lag", msg.Attributes["condition"])
+	assert.Equal(t, "watchdog:lag:sepolia:thanos-a", msg.DedupKey)
+	assert.False(t, msg.Resolved)
+
+	assert.Equal(t, "[sepolia] [thanos-a Head Caught Up]", ops.messages[1].Title)
+	assert.True(t, ops.messages[1].Resolved)
+	assert.Equal(t, msg.DedupKey, ops.messages[1].DedupKey)
+}
@@ -0,0 +1,132 @@
+package thanosnotif
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/addressbook"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+// WatchlistConfig handles the events from or to the addresses of a tag.
+type WatchlistConfig struct {
+	// Severity is the minimum severity of the events, e.g. critical.
+	Severity string `yaml:"severity" toml:"severity"`
+	// Notifiers receive the events in addition to the other matching routes.
+	Notifiers []string `yaml:"notifiers" toml:"notifiers"`
+}
+
+// AddressBookConfig labels and tags the addresses shown in the messages.
+type AddressBookConfig struct {
+	Entries []AddressConfig `yaml:"entries" toml:"entries"`
+	// Files are CSV files with the address, label and tags columns, the tags
+	// separated by semicolons.
+	Files []string `yaml:"files" toml:"files"`
+}
+
+type AddressConfig struct {
+	Address string   `yaml:"address" toml:"address"`
+	Label   string   `yaml:"label" toml:"label"`
+	Tags    []string `yaml:"tags" toml:"tags"`
+}
+
+// newAddressBook builds the address book of the configured entries and CSV
+// files, the files overriding the labels of the entries.
+func newAddressBook(cfg *AddressBookConfig) (*addressbook.Book, error) {
+	entries := make([]addressbook.Entry, 0, len(cfg.Entries))
+	for _, entry := range cfg.Entries {
+		entries = append(entries, addressbook.Entry{
+			Address: common.HexToAddress(entry.Address),
+			Label:   entry.Label,
+			Tags:    entry.Tags,
+		})
+	}
+
+	for _, file := range cfg.Files {
+		fileEntries, err := addressbook.LoadCSV(file)
+		if err != nil {
+			log.GetLogger().Errorw("Failed to load the address book", "error", err, "file", file)
+			return nil, err
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	return addressbook.New(entries...), nil
+}
+
+// setLabels sets the address book labels of the addresses of the event and
+// the tags of both addresses.
+func (p *App) setLabels(event *types.BridgeEvent) {
+	book := p.addresses.Load()
+
+	var tags []string
+	if entry, ok := book.Lookup(event.From); ok {
+		event.FromLabel = entry.Label
+		tags = append(tags, entry.Tags...)
+	}
+
+	if entry, ok := book.Lookup(event.To); ok {
+		event.ToLabel = entry.Label
+		for _, tag := range entry.Tags {
+			if !containsString(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	event.Tags = tags
+}
+
+// checkWatchlist raises msg to the severity of the watchlists of the tags of
+// the event. The watchlist routes match the tag attributes.
+func (p *App) checkWatchlist(n *network, event *types.BridgeEvent, msg *notification.Message) {
+	watchlists := p.networkConfig(n).Watchlist
+
+	for _, tag := range event.Tags {
+		watchlist, ok := watchlists[tag]
+		if !ok {
+			continue
+		}
+
+		severity, err := notification.ParseSeverity(watchlist.Severity)
+		if err != nil {
+			continue
+		}
+
+		if !msg.Severity.AtLeast(severity) {
+			msg.Severity = severity
+		}
+	}
+}
+
+// tagAttribute is the message attribute of a watchlist tag, set to "true".
+func tagAttribute(tag string) string {
+	return "tag." + tag
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *AddressBookConfig) validate(v *validator) {
+	for i, entry := range c.Entries {
+		path := fmt.Sprintf("address_book.entries[%d]", i)
+
+		if !common.IsHexAddress(entry.Address) {
+			v.add(path+".address", "invalid address: %s", entry.Address)
+		}
+
+		if entry.Label == "" && len(entry.Tags) == 0 {
+			v.add(path, "label or tags are required")
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package thanosnotif
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+func TestApp_checkWatchlist(t *testing.T) {
+	var (
+		hotWallet  = common.HexToAddress("0x28C6c06298d514Db089934071355E5743bf21d60")
+		sanctioned = common.HexToAddress("0x00000000000000000000000000000000000000b1")
+		user       = common.HexToAddress("0x00000000000000000000000000000000000000c1")
+	)
+
+	path := filepath.Join(t.TempDir(), "addresses.csv")
+	require.NoError(t, os.WriteFile(path, []byte("address,label,tags\n"+sanctioned.Hex()+",Flagged,sanctioned\n"), 0o600))
+
+	cfg := &Config{
+		NetworkConfig: NetworkConfig{
+			Network: "sepolia",
+			Chains:  testChainsConfig(),
+			Notifiers: []NotifierConfig{
+				{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"},
+				{Name: "compliance", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/compliance"},
+			},
+			Routing: RoutingConfig{Default: []string{"slack"}},
+			Watchlist: map[string]WatchlistConfig{
+				"exchange":   {Severity: "warning"},
+				"sanctioned": {Severity: "critical", Notifiers: []string{"compliance"}},
+			},
+		},
+		AddressBook: AddressBookConfig{
+			Entries: []AddressConfig{{Address: hotWallet.Hex(), Label: "Binance Hot Wallet", Tags: []string{"exchange"}}},
+			Files:   []string{path},
+		},
+	}
+	app := newTestApp(t, cfg)
+
+	n := testNetwork(app, "sepolia")
+	slack, compliance := &testSender{}, &testSender{}
+	_, defaultTargets, routes, err := newNotifierRoutes(&cfg.NetworkConfig, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, n.notifier.Update(map[string]notification.Sender{"slack": slack, "compliance": compliance}, defaultTargets, routes))
+
+	notify := func(from, to common.Address) (*types.BridgeEvent, *notification.Message) {
+		event := &types.BridgeEvent{Direction: types.DirectionDeposit, Layer: types.LayerL1, From: from, To: to, Amount: big.NewInt(1)}
+		app.setLabels(event)
+
+		title, text := app.formatBridgeEvent(n.rollups["thanos-a"], event)
+		msg := &notification.Message{Title: title, Text: text, Severity: notification.SeverityInfo, Attributes: bridgeEventAttributes(event)}
+		app.checkWatchlist(n, event, msg)
+		require.NoError(t, n.notifier.Notify(msg))
+
+		return event, msg
+	}
+
+	event, msg := notify(hotWallet, user)
+	assert.Equal(t, "Binance Hot Wallet", event.FromLabel)
+	assert.Empty(t, event.ToLabel)
+	assert.Equal(t, []string{"exchange"}, event.Tags)
+	assert.Contains(t, msg.Text, hotWallet.Hex()+" (Binance Hot Wallet)\n")
+	assert.Contains(t, msg.Text, "Watchlist: exchange\n")
+	assert.Equal(t, notification.SeverityWarning, msg.Severity)
+	assert.Equal(t, []*notification.Message{msg}, slack.messages)
+
+	event, msg = notify(sanctioned, hotWallet)
+	assert.Equal(t, "Flagged", event.FromLabel)
+	assert.Equal(t, []string{"sanctioned", "exchange"}, event.Tags)
+	assert.Equal(t, "true", msg.Attributes["tag.sanctioned"])
+	assert.Equal(t, notification.SeverityCritical, msg.Severity)
+	assert.Equal(t, []*notification.Message{msg}, compliance.messages)
+
+	_, msg = notify(user, user)
+	assert.Equal(t, notification.SeverityInfo, msg.Severity)
+	assert.NotContains(t, msg.Text, "Watchlist")
+}
@@ -0,0 +1,221 @@
+package thanosnotif
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/aggregate"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/price"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	// whaleAlert is the alert attribute of the large transfers and volumes,
+	// routed to the whale notifiers.
+	whaleAlert     = "whale"
+	whaleRouteName = "whales"
+
+	defaultWhaleMention = "<!here>"
+)
+
+// WhalesConfig raises the large transfers to the warning severity and alerts
+// on the large volumes bridged in a rolling window.
+type WhalesConfig struct {
+	// USD is the USD value from which a transfer is large, disabled when 0.
+	USD float64 `yaml:"usd" toml:"usd"`
+	// Tokens are the amounts from which a transfer is large, in token units
+	// by asset display name, e.g. ETH: 100.
+	Tokens map[string]float64 `yaml:"tokens" toml:"tokens"`
+	// Notifiers receive the whale alerts instead of the routed notifiers. The
+	// alerts are routed as the other messages when empty.
+	Notifiers []string `yaml:"notifiers" toml:"notifiers"`
+	// Mention starts the whale alerts, <!here> by default.
+	Mention string              `yaml:"mention" toml:"mention"`
+	Windows []WhaleWindowConfig `yaml:"windows" toml:"windows"`
+}
+
+// WhaleWindowConfig alerts when the USD value of the transfers matching Match
+// in the last Duration reaches USD, e.g. the withdrawals of more than 1M USD
+// in an hour.
+type WhaleWindowConfig struct {
+	Name string `yaml:"name" toml:"name"`
+	// Match holds the attribute values of the counted events, as the routes.
+	Match    map[string]string `yaml:"match" toml:"match"`
+	Duration time.Duration     `yaml:"duration" toml:"duration"`
+	USD      float64           `yaml:"usd" toml:"usd"`
+}
+
+type whaleWindow struct {
+	cfg    WhaleWindowConfig
+	window *aggregate.Window
+}
+
+// updateWhaleWindows replaces the rolling windows of the network, keeping the
+// sums of the windows whose duration and threshold didn't change.
+func (n *network) updateWhaleWindows(cfg *WhalesConfig) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	current := make(map[string]*whaleWindow, len(n.whaleWindows))
+	for _, w := range n.whaleWindows {
+		current[w.cfg.Name] = w
+	}
+
+	windows := make([]*whaleWindow, 0, len(cfg.Windows))
+	for _, windowCfg := range cfg.Windows {
+		w, ok := current[windowCfg.Name]
+		if !ok || w.cfg.Duration != windowCfg.Duration || w.cfg.USD != windowCfg.USD {
+			w = &whaleWindow{window: aggregate.NewWindow(windowCfg.Duration, windowCfg.USD)}
+		}
+		w.cfg = windowCfg
+		windows = append(windows, w)
+	}
+	n.whaleWindows = windows
+}
+
+// checkWhale raises msg to a whale alert when the event is a large transfer,
+// and alerts on the windows the event makes cross their threshold.
+func (p *App) checkWhale(n *network, event *types.BridgeEvent, msg *notification.Message) {
+	cfg := &p.networkConfig(n).Whales
+
+	if isWhale(cfg, event) {
+		msg.Severity = notification.SeverityWarning
+		msg.Attributes["alert"] = whaleAlert
+		msg.Text = fmt.Sprintf("%s Large transfer\n%s", whaleMention(cfg), msg.Text)
+		msg.Mention = whaleMention(cfg)
+		msg.Fields = append([]notification.Field{{Name: "Alert", Value: "Large transfer"}}, msg.Fields...)
+	}
+
+	if event.ValueUSD == nil {
+		return
+	}
+
+	attributes := bridgeEventAttributes(event)
+
+	n.mu.Lock()
+	windows := n.whaleWindows
+	n.mu.Unlock()
+
+	for _, w := range windows {
+		if !matchAttributes(w.cfg.Match, attributes) {
+			continue
+		}
+
+		sum, crossed := w.window.Add(event.Key(), event.BlockTime, *event.ValueUSD)
+		if !crossed {
+			continue
+		}
+
+		p.alertWhaleWindow(n, cfg, w.cfg, sum)
+	}
+}
+
+// isWhale reports whether the event reaches the USD threshold or the amount
+// threshold of its asset. The unclassified tokens have no amount threshold.
+func isWhale(cfg *WhalesConfig, event *types.BridgeEvent) bool {
+	if cfg.USD > 0 && event.ValueUSD != nil && *event.ValueUSD >= cfg.USD {
+		return true
+	}
+
+	threshold, ok := cfg.Tokens[event.Asset]
+	if !ok || event.Asset == "" || event.Amount == nil {
+		return false
+	}
+
+	return price.Value(event.Amount, event.Decimals, 1) >= threshold
+}
+
+func (p *App) alertWhaleWindow(n *network, cfg *WhalesConfig, windowCfg WhaleWindowConfig, sum float64) {
+	msg := &notification.Message{
+		Title: fmt.Sprintf("[%s] [Large Volume %s]", n.name, windowCfg.Name),
+		Text: fmt.Sprintf("%s $%s bridged in the last %s, over the $%s threshold.\nMatch: %s",
+			whaleMention(cfg), formatUSD(sum), windowCfg.Duration, formatUSD(windowCfg.USD), formatMatch(windowCfg.Match)),
+		Severity: notification.SeverityWarning,
+		Attributes: map[string]string{
+			"network": n.name,
+			"alert":   whaleAlert,
+			"window":  windowCfg.Name,
+		},
+		Values: map[string]float64{
+			"value_usd": sum,
+		},
+	}
+
+	if err := n.notifier.Notify(msg); err != nil {
+		log.GetLogger().Errorw("Failed to notify the large volume", "error", err, "network", n.name, "window", windowCfg.Name)
+	}
+}
+
+func whaleMention(cfg *WhalesConfig) string {
+	if cfg.Mention == "" {
+		return defaultWhaleMention
+	}
+
+	return cfg.Mention
+}
+
+func matchAttributes(match, attributes map[string]string) bool {
+	for key, value := range match {
+		if attributes[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func formatMatch(match map[string]string) string {
+	if len(match) == 0 {
+		return "all events"
+	}
+
+	pairs := make([]string, 0, len(match))
+	for key, value := range match {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ", ")
+}
+
+func (c *NetworkConfig) validateWhales(v *validator, prefix string) {
+	if c.Whales.USD < 0 {
+		v.add(prefix+"whales.usd", "must not be negative")
+	}
+
+	assets := make([]string, 0, len(c.Whales.Tokens))
+	for asset := range c.Whales.Tokens {
+		assets = append(assets, asset)
+	}
+	sort.Strings(assets)
+
+	for _, asset := range assets {
+		if c.Whales.Tokens[asset] <= 0 {
+			v.add(prefix+"whales.tokens."+asset, "must be positive")
+		}
+	}
+
+	names := make(map[string]struct{}, len(c.Whales.Windows))
+	for i, window := range c.Whales.Windows {
+		path := fmt.Sprintf("%swhales.windows[%d]", prefix, i)
+
+		if window.Name == "" {
+			v.add(path+".name", "is required")
+		} else if _, ok := names[window.Name]; ok {
+			v.add(path+".name", "duplicated window name: %s", window.Name)
+		}
+		names[window.Name] = struct{}{}
+
+		if window.Duration <= 0 {
+			v.add(path+".duration", "must be positive")
+		}
+
+		if window.USD <= 0 {
+			v.add(path+".usd", "must be positive")
+		}
+	}
+}
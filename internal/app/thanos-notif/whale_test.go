@@ -0,0 +1,114 @@
+package thanosnotif
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+func TestApp_checkWhale(t *testing.T) {
+	cfg := &Config{
+		NetworkConfig: NetworkConfig{
+			Network: "sepolia",
+			Chains:  testChainsConfig(),
+			Notifiers: []NotifierConfig{
+				{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/main"},
+				{Name: "whales", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/whales"},
+			},
+			Routing: RoutingConfig{Default: []string{"slack"}},
+			Whales: WhalesConfig{
+				USD:       100000,
+				Tokens:    map[string]float64{"ETH": 10},
+				Notifiers: []string{"whales"},
+				Windows: []WhaleWindowConfig{
+					{Name: "outflow", Match: map[string]string{"direction": types.DirectionWithdrawal}, Duration: time.Hour, USD: 1000000},
+				},
+			},
+		},
+		PricesConfig: PricesConfig{Static: map[string]float64{"ETH": 3000}},
+	}
+	app := newTestApp(t, cfg)
+
+	n := testNetwork(app, "sepolia")
+	slack, whales := &testSender{}, &testSender{}
+	_, defaultTargets, routes, err := newNotifierRoutes(&cfg.NetworkConfig, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, n.notifier.Update(map[string]notification.Sender{"slack": slack, "whales": whales}, defaultTargets, routes))
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	notify := func(direction string, eth int64, at time.Time, logIndex uint) *notification.Message {
+		event := &types.BridgeEvent{
+			Network:   "sepolia",
+			Bridge:    types.BridgeStandard,
+			Direction: direction,
+			Amount:    new(big.Int).Mul(big.NewInt(eth), big.NewInt(1e18)),
+			BlockTime: at,
+			TxHash:    common.HexToHash("0x01"),
+			LogIndex:  logIndex,
+		}
+		app.setAsset(event, n.chains["l1"], common.Address{})
+
+		msg := &notification.Message{
+			Title:      "event",
+			Text:       "Amount",
+			Severity:   notification.SeverityInfo,
+			Attributes: bridgeEventAttributes(event),
+			Values:     bridgeEventValues(event),
+		}
+		app.checkWhale(n, event, msg)
+		require.NoError(t, n.notifier.Notify(msg))
+
+		return msg
+	}
+
+	small := notify(types.DirectionDeposit, 1, start, 0)
+	assert.Equal(t, notification.SeverityInfo, small.Severity)
+	assert.Equal(t, []*notification.Message{small}, slack.messages)
+
+	// 10 ETH reaches the token threshold, not the USD one
+	large := notify(types.DirectionDeposit, 10, start, 1)
+	assert.Equal(t, notification.SeverityWarning, large.Severity)
+	assert.Equal(t, whaleAlert, large.Attributes["alert"])
+	assert.Contains(t, large.Text, "<!here> Large transfer")
+	assert.Equal(t, []*notification.Message{large}, whales.messages)
+
+	// the small withdrawals stay under the 1M USD window
+	notify(types.DirectionWithdrawal, 5, start, 2)
+	notify(types.DirectionWithdrawal, 5, start.Add(10*time.Minute), 3)
+	assert.Len(t, whales.messages, 1)
+
+	// 600 ETH more within the hour, the re-delivered event is counted once
+	notify(types.DirectionWithdrawal, 300, start.Add(20*time.Minute), 4)
+	notify(types.DirectionWithdrawal, 300, start.Add(30*time.Minute), 5)
+	notify(types.DirectionWithdrawal, 300, start.Add(30*time.Minute), 5)
+
+	var volumes []*notification.Message
+	for _, msg := range whales.messages {
+		if msg.Attributes["window"] == "outflow" {
+			volumes = append(volumes, msg)
+		}
+	}
+	require.Len(t, volumes, 1)
+	assert.Equal(t, "[sepolia] [Large Volume outflow]", volumes[0].Title)
+	assert.Contains(t, volumes[0].Text, "$1,830,000.00 bridged in the last 1h0m0s")
+	assert.Len(t, slack.messages, 3)
+}
+
+func Test_isWhale(t *testing.T) {
+	value := 150000.0
+	cfg := &WhalesConfig{USD: 100000, Tokens: map[string]float64{"TON": 1000}}
+
+	assert.True(t, isWhale(cfg, &types.BridgeEvent{ValueUSD: &value}))
+	assert.True(t, isWhale(cfg, &types.BridgeEvent{Asset: "TON", Amount: new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18)), Decimals: 18}))
+	assert.False(t, isWhale(cfg, &types.BridgeEvent{Asset: "TON", Amount: big.NewInt(999), Decimals: 0}))
+	// an unclassified token has no amount threshold, whatever its symbol
+	assert.False(t, isWhale(cfg, &types.BridgeEvent{Symbol: "TON", Amount: big.NewInt(1e18), Decimals: 0}))
+	assert.False(t, isWhale(&WhalesConfig{}, &types.BridgeEvent{ValueUSD: &value}))
+}
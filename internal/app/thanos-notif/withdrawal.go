@@ -1,224 +1,136 @@
 package thanosnotif
 
 import (
-	"fmt"
-
+	"github.com/ethereum/go-ethereum/common"
 	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
-	"github.com/tokamak-network/tokamak-thanos/op-bindings/bindings"
+	"github.com/tokamak-network/tokamak-thanos/op-bindings/predeploys"
 
-	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/erc20"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
 )
 
-func (p *App) withdrawalETHFinalizedEvent(vLog *ethereumTypes.Log) (string, string, error) {
+func (p *App) withdrawalETHFinalizedEvent(r *rollup, vLog *ethereumTypes.Log) (*types.BridgeEvent, error) {
 	log.GetLogger().Infow("Got ETH Withdrawal Event", "event", vLog)
 
-	l1BridgeFilterer, _, err := p.getBridgeFilterers()
+	l1BridgeFilterer, _, err := p.getBridgeFilterers(r)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	event, err := l1BridgeFilterer.ParseETHWithdrawalFinalized(*vLog)
 	if err != nil {
 		log.GetLogger().Errorw("ETHWithdrawalFinalized event log parsing fail", "error", err)
-		return "", "", err
-	}
-
-	ethWith := bindings.L1StandardBridgeETHWithdrawalFinalized{
-		From:   event.From,
-		To:     event.To,
-		Amount: event.Amount,
+		return nil, err
 	}
 
-	Amount := formatAmount(ethWith.Amount, 18)
-
-	// Slack notify title and text
-	title := fmt.Sprintf("[" + p.cfg.Network + "] [ETH Withdrawal Finalized]")
-	text := fmt.Sprintf("Tx: "+p.cfg.L1ExplorerUrl+"/tx/%s\nFrom: "+p.cfg.L2ExplorerUrl+"/address/%s\nTo: "+p.cfg.L1ExplorerUrl+"/address/%s\nAmount: %s ETH", vLog.TxHash, ethWith.From, ethWith.To, Amount)
+	bridgeEvent := p.newBridgeEvent(vLog, r, r.l1, types.BridgeStandard, types.DirectionWithdrawal, types.StatusFinalized)
+	// the ETH events carry no token, the L2 events having the ETH predeploy
+	bridgeEvent.L2Token = predeploys.ETHAddr
+	bridgeEvent.From = event.From
+	bridgeEvent.To = event.To
+	bridgeEvent.Amount = event.Amount
+	p.setAsset(bridgeEvent, r.l1, common.Address{})
 
-	return title, text, nil
+	return bridgeEvent, nil
 }
 
-func (p *App) withdrawalERC20FinalizedEvent(vLog *ethereumTypes.Log) (string, string, error) {
+func (p *App) withdrawalERC20FinalizedEvent(r *rollup, vLog *ethereumTypes.Log) (*types.BridgeEvent, error) {
 	log.GetLogger().Infow("Got ERC20 Withdrawal Event", "event", vLog)
 
-	l1BridgeFilterer, _, err := p.getBridgeFilterers()
+	l1BridgeFilterer, _, err := p.getBridgeFilterers(r)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	event, err := l1BridgeFilterer.ParseERC20WithdrawalFinalized(*vLog)
 	if err != nil {
 		log.GetLogger().Errorw("ERC20WithdrawalFinalized event parsing fail", "error", err)
-		return "", "", err
+		return nil, err
 	}
 
-	erc20With := bindings.L1StandardBridgeERC20WithdrawalFinalized{
-		L1Token: event.L1Token,
-		L2Token: event.L2Token,
-		From:    event.From,
-		To:      event.To,
-		Amount:  event.Amount,
-	}
-
-	// get symbol and decimals
-	l1Token := erc20With.L1Token
-	l1TokenInfo, found := p.l1TokensInfo[l1Token.Hex()]
-	if !found {
-		newToken, err := erc20.FetchTokenInfo(p.l1Client, l1Token.Hex())
-		if err != nil || newToken == nil {
-			log.GetLogger().Errorw("Token info not found for address", "l1Token", l1Token.Hex())
-			return "", "", err
-		}
-		l1TokenInfo = newToken
-		p.mu.Lock()
-		p.l1TokensInfo[l1Token.Hex()] = l1TokenInfo
-		p.mu.Unlock()
-	}
-
-	tokenSymbol := l1TokenInfo.Symbol
-	tokenDecimals := l1TokenInfo.Decimals
-
-	amount := formatAmount(erc20With.Amount, tokenDecimals)
-
-	// Slack notify title and text
-	var title string
-
-	isTON := l1TokenInfo.Symbol == "TON"
-
-	if isTON {
-		title = fmt.Sprintf("[" + p.cfg.Network + "] [TON Withdrawal Finalized]")
-	} else {
-		title = fmt.Sprintf("[" + p.cfg.Network + "] [ERC-20 Withdrawal Finalized]")
-	}
-	text := fmt.Sprintf("Tx: "+p.cfg.L1ExplorerUrl+"/tx/%s\nFrom: "+p.cfg.L2ExplorerUrl+"/address/%s\nTo: "+p.cfg.L1ExplorerUrl+"/address/%s\nL1Token: "+p.cfg.L1ExplorerUrl+"/token/%s\nL2Token: "+p.cfg.L2ExplorerUrl+"/token/%s\nAmount: %s %s", vLog.TxHash, erc20With.From, erc20With.To, erc20With.L1Token, erc20With.L2Token, amount, tokenSymbol)
+	bridgeEvent := p.newBridgeEvent(vLog, r, r.l1, types.BridgeStandard, types.DirectionWithdrawal, types.StatusFinalized)
+	bridgeEvent.L1Token = event.L1Token
+	bridgeEvent.L2Token = event.L2Token
+	bridgeEvent.From = event.From
+	bridgeEvent.To = event.To
+	bridgeEvent.Amount = event.Amount
+	p.setAsset(bridgeEvent, r.l1, event.L1Token)
 
-	return title, text, nil
+	return bridgeEvent, nil
 }
 
-func (p *App) withdrawalInitiatedEvent(vLog *ethereumTypes.Log) (string, string, error) {
+func (p *App) withdrawalInitiatedEvent(r *rollup, vLog *ethereumTypes.Log) (*types.BridgeEvent, error) {
 	log.GetLogger().Infow("Got L2 Withdrawal Event", "event", vLog)
 
-	_, l2BridgeFilterer, err := p.getBridgeFilterers()
+	_, l2BridgeFilterer, err := p.getBridgeFilterers(r)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	event, err := l2BridgeFilterer.ParseWithdrawalInitiated(*vLog)
 	if err != nil {
 		log.GetLogger().Errorw("WithdrawalInitiated event parsing fail", "error", err)
-		return "", "", err
+		return nil, err
 	}
 
-	l2With := bindings.L2StandardBridgeWithdrawalInitiated{
-		L1Token: event.L1Token,
-		L2Token: event.L2Token,
-		From:    event.From,
-		To:      event.To,
-		Amount:  event.Amount,
-	}
-
-	l2Token := l2With.L2Token
-
-	l2TokenInfo, found := p.l2TokensInfo[l2Token.Hex()]
-	if !found {
-		newToken, err := erc20.FetchTokenInfo(p.l2Client, l2Token.Hex())
-		if err != nil || newToken == nil {
-			log.GetLogger().Errorw("Token info not found for address", "l2Token", l2Token.Hex())
-			return "", "", err
-		}
-		l2TokenInfo = newToken
-		p.mu.Lock()
-		p.l2TokensInfo[l2Token.Hex()] = l2TokenInfo
-		p.mu.Unlock()
-	}
-
-	if l2TokenInfo == nil {
-		return "", "", fmt.Errorf("l2TokenInfo not found")
-	}
-
-	tokenSymbol := l2TokenInfo.Symbol
-	tokenDecimals := l2TokenInfo.Decimals
-	amount := formatAmount(l2With.Amount, tokenDecimals)
-
-	var title string
-	var text string
-
-	isETH := l2TokenInfo.Symbol == "ETH"
-	isTON := l2TokenInfo.Symbol == "TON"
-
-	if isETH {
-		title = fmt.Sprintf("[" + p.cfg.Network + "] [ETH Withdrawal Initialized]")
-		text = fmt.Sprintf("Tx: "+p.cfg.L2ExplorerUrl+"/tx/%s\nFrom: "+p.cfg.L2ExplorerUrl+"/address/%s\nTo: "+p.cfg.L1ExplorerUrl+"/address/%s\nL1Token: ETH\nL2Token: "+p.cfg.L2ExplorerUrl+"/token/%s\nAmount: %s %s", vLog.TxHash, l2With.From, l2With.To, l2With.L2Token, amount, tokenSymbol)
-	} else if isTON {
-		title = fmt.Sprintf("[" + p.cfg.Network + "] [TON Withdrawal Initialized]")
-		text = fmt.Sprintf("Tx: "+p.cfg.L2ExplorerUrl+"/tx/%s\nFrom: "+p.cfg.L2ExplorerUrl+"/address/%s\nTo: "+p.cfg.L1ExplorerUrl+"/address/%s\nL1Token: "+p.cfg.L1ExplorerUrl+"/token/%s\nL2Token: "+p.cfg.L2ExplorerUrl+"/token/%s\nAmount: %s %s", vLog.TxHash, l2With.From, l2With.To, l2With.L1Token, l2With.L2Token, amount, tokenSymbol)
-	} else {
-		title = fmt.Sprintf("[" + p.cfg.Network + "] [ERC-20 Withdrawal Initialized]")
-		text = fmt.Sprintf("Tx: "+p.cfg.L2ExplorerUrl+"/tx/%s\nFrom: "+p.cfg.L2ExplorerUrl+"/address/%s\nTo: "+p.cfg.L1ExplorerUrl+"/address/%s\nL1Token: "+p.cfg.L1ExplorerUrl+"/token/%s\nL2Token: "+p.cfg.L2ExplorerUrl+"/token/%s\nAmount: %s %s", vLog.TxHash, l2With.From, l2With.To, l2With.L1Token, l2With.L2Token, amount, tokenSymbol)
-	}
+	bridgeEvent := p.newBridgeEvent(vLog, r, r.l2, types.BridgeStandard, types.DirectionWithdrawal, types.StatusInitiated)
+	bridgeEvent.L1Token = event.L1Token
+	bridgeEvent.L2Token = event.L2Token
+	bridgeEvent.From = event.From
+	bridgeEvent.To = event.To
+	bridgeEvent.Amount = event.Amount
+	p.setAsset(bridgeEvent, r.l2, event.L2Token)
 
-	return title, text, nil
+	return bridgeEvent, nil
 }
 
-func (p *App) withdrawalUsdcFinalizedEvent(vLog *ethereumTypes.Log) (string, string, error) {
+func (p *App) withdrawalUsdcFinalizedEvent(r *rollup, vLog *ethereumTypes.Log) (*types.BridgeEvent, error) {
 	log.GetLogger().Infow("Got L1 USDC Withdrawal Event", "event", vLog)
 
-	l1UsdcBridgeFilterer, _, err := p.getUSDCBridgeFilterers()
+	l1UsdcBridgeFilterer, _, err := p.getUSDCBridgeFilterers(r)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	event, err := l1UsdcBridgeFilterer.ParseERC20WithdrawalFinalized(*vLog)
 	if err != nil {
 		log.GetLogger().Errorw("USDC WithdrawalFinalized event parsing fail", "error", err)
-		return "", "", err
+		return nil, err
 	}
 
-	l1UsdcWith := bindings.L1UsdcBridgeERC20WithdrawalFinalized{
-		L1Token: event.L1Token,
-		L2Token: event.L2Token,
-		From:    event.From,
-		To:      event.To,
-		Amount:  event.Amount,
-	}
-
-	Amount := formatAmount(l1UsdcWith.Amount, 6)
-
-	// Slack notify title and text
-	title := fmt.Sprintf("[" + p.cfg.Network + "] [USDC Withdrawal Finalized]")
-	text := fmt.Sprintf("Tx: "+p.cfg.L1ExplorerUrl+"/tx/%s\nFrom: "+p.cfg.L2ExplorerUrl+"/address/%s\nTo: "+p.cfg.L1ExplorerUrl+"/address/%s\nL1Token: "+p.cfg.L1ExplorerUrl+"/token/%s\nL2Token: "+p.cfg.L2ExplorerUrl+"/token/%s\nAmount: %s USDC", vLog.TxHash, l1UsdcWith.From, l1UsdcWith.To, l1UsdcWith.L1Token, l1UsdcWith.L2Token, Amount)
+	bridgeEvent := p.newBridgeEvent(vLog, r, r.l1, types.BridgeUsdc, types.DirectionWithdrawal, types.StatusFinalized)
+	bridgeEvent.L1Token = event.L1Token
+	bridgeEvent.L2Token = event.L2Token
+	bridgeEvent.From = event.From
+	bridgeEvent.To = event.To
+	bridgeEvent.Amount = event.Amount
+	p.setAsset(bridgeEvent, r.l1, event.L1Token)
 
-	return title, text, nil
+	return bridgeEvent, nil
 }
 
-func (p *App) withdrawalUsdcInitiatedEvent(vLog *ethereumTypes.Log) (string, string, error) {
+func (p *App) withdrawalUsdcInitiatedEvent(r *rollup, vLog *ethereumTypes.Log) (*types.BridgeEvent, error) {
 	log.GetLogger().Infow("Got L2 USDC Withdrawal Event", "event", vLog)
 
-	_, l2UsdcBridgeFilterer, err := p.getUSDCBridgeFilterers()
+	_, l2UsdcBridgeFilterer, err := p.getUSDCBridgeFilterers(r)
 	if err != nil {
 		log.GetLogger().Errorw("Failed to get USDC bridge filters", "error", err)
-		return "", "", err
+		return nil, err
 	}
 
 	event, err := l2UsdcBridgeFilterer.ParseWithdrawalInitiated(*vLog)
 	if err != nil {
 		log.GetLogger().Errorw("Failed to parse the USDC WithdrawalInitiated event", "error", err)
-		return "", "", err
+		return nil, err
 	}
 
-	l2UsdcWith := bindings.L2UsdcBridgeWithdrawalInitiated{
-		L1Token: event.L1Token,
-		L2Token: event.L2Token,
-		From:    event.From,
-		To:      event.To,
-		Amount:  event.Amount,
-	}
-
-	Amount := formatAmount(l2UsdcWith.Amount, 6)
-
-	title := fmt.Sprintf("[" + p.cfg.Network + "] [USDC Withdrawal Initialized]")
-	text := fmt.Sprintf("Tx: "+p.cfg.L2ExplorerUrl+"/tx/%s\nFrom: "+p.cfg.L2ExplorerUrl+"/address/%s\nTo: "+p.cfg.L1ExplorerUrl+"/address/%s\nL1Token: "+p.cfg.L1ExplorerUrl+"/token/%s\nL2Token: "+p.cfg.L2ExplorerUrl+"/token/%s\nAmount: %s USDC", vLog.TxHash, l2UsdcWith.From, l2UsdcWith.To, l2UsdcWith.L1Token, l2UsdcWith.L2Token, Amount)
+	bridgeEvent := p.newBridgeEvent(vLog, r, r.l2, types.BridgeUsdc, types.DirectionWithdrawal, types.StatusInitiated)
+	bridgeEvent.L1Token = event.L1Token
+	bridgeEvent.L2Token = event.L2Token
+	bridgeEvent.From = event.From
+	bridgeEvent.To = event.To
+	bridgeEvent.Amount = event.Amount
+	p.setAsset(bridgeEvent, r.l2, event.L2Token)
 
-	return title, text, nil
+	return bridgeEvent, nil
 }
@@ -0,0 +1,154 @@
+package addressbook
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Entry labels an address and tags it for the watchlists, e.g. exchange,
+// treasury or sanctioned.
+type Entry struct {
+	Address common.Address
+	Label   string
+	Tags    []string
+}
+
+// Book maps the addresses to their entry. It is read only once built.
+type Book struct {
+	entries map[common.Address]*Entry
+}
+
+// New builds a book of the entries. The entries of the same address are
+// merged, the last label wins and the tags are combined.
+func New(entries ...Entry) *Book {
+	b := &Book{entries: make(map[common.Address]*Entry, len(entries))}
+	for _, entry := range entries {
+		b.add(entry)
+	}
+
+	return b
+}
+
+func (b *Book) add(entry Entry) {
+	current, ok := b.entries[entry.Address]
+	if !ok {
+		current = &Entry{Address: entry.Address}
+		b.entries[entry.Address] = current
+	}
+
+	if entry.Label != "" {
+		current.Label = entry.Label
+	}
+
+	for _, tag := range entry.Tags {
+		if tag != "" && !contains(current.Tags, tag) {
+			current.Tags = append(current.Tags, tag)
+		}
+	}
+	sort.Strings(current.Tags)
+}
+
+// Lookup returns the entry of the address.
+func (b *Book) Lookup(address common.Address) (Entry, bool) {
+	if b == nil {
+		return Entry{}, false
+	}
+
+	entry, ok := b.entries[address]
+	if !ok {
+		return Entry{}, false
+	}
+
+	return *entry, true
+}
+
+// Len returns the number of addresses of the book.
+func (b *Book) Len() int {
+	return len(b.entries)
+}
+
+// ReadCSV reads the entries of a CSV file with the address, label and tags
+// columns and a header line. The tags are separated by semicolons.
+func ReadCSV(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, errors.New("missing header")
+	}
+
+	entries := make([]Entry, 0, len(records)-1)
+	for i, record := range records[1:] {
+		line := i + 2
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("line %d: expected address, label and tags", line)
+		}
+
+		if !common.IsHexAddress(record[0]) {
+			return nil, fmt.Errorf("line %d: invalid address %s", line, record[0])
+		}
+
+		entry := Entry{
+			Address: common.HexToAddress(record[0]),
+			Label:   strings.TrimSpace(record[1]),
+		}
+		if len(record) == 3 {
+			entry.Tags = ParseTags(record[2])
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// LoadCSV reads the entries of the CSV file at path.
+func LoadCSV(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries, err := ReadCSV(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address book %s: %w", path, err)
+	}
+
+	return entries, nil
+}
+
+// ParseTags splits the semicolon separated tags.
+func ParseTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ";") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,62 @@
+package addressbook
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	hotWallet = common.HexToAddress("0x28C6c06298d514Db089934071355E5743bf21d60")
+	treasury  = common.HexToAddress("0x00000000000000000000000000000000000000a1")
+)
+
+const testCSV = `address,label,tags
+# exchanges
+0x28c6c06298d514db089934071355e5743bf21d60, Binance Hot Wallet, exchange;hot-wallet
+0x00000000000000000000000000000000000000a1,Treasury,
+`
+
+func TestLoadCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "addresses.csv")
+	require.NoError(t, os.WriteFile(path, []byte(testCSV), 0o600))
+
+	entries, err := LoadCSV(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, Entry{Address: hotWallet, Label: "Binance Hot Wallet", Tags: []string{"exchange", "hot-wallet"}}, entries[0])
+	assert.Equal(t, Entry{Address: treasury, Label: "Treasury"}, entries[1])
+
+	_, err = ReadCSV(strings.NewReader("address,label,tags\n0xnope,Bad,\n"))
+	require.ErrorContains(t, err, "line 2: invalid address 0xnope")
+
+	_, err = ReadCSV(strings.NewReader(""))
+	require.Error(t, err)
+}
+
+func TestBook_Lookup(t *testing.T) {
+	book := New(
+		Entry{Address: hotWallet, Label: "Binance", Tags: []string{"exchange"}},
+		Entry{Address: hotWallet, Label: "Binance 14", Tags: []string{"hot-wallet", "exchange"}},
+		Entry{Address: treasury, Tags: []string{"treasury"}},
+	)
+	assert.Equal(t, 2, book.Len())
+
+	entry, ok := book.Lookup(hotWallet)
+	require.True(t, ok)
+	assert.Equal(t, "Binance 14", entry.Label)
+	assert.Equal(t, []string{"exchange", "hot-wallet"}, entry.Tags)
+
+	_, ok = book.Lookup(common.HexToAddress("0x01"))
+	assert.False(t, ok)
+
+	var empty *Book
+	_, ok = empty.Lookup(hotWallet)
+	assert.False(t, ok)
+}
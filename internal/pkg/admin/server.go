@@ -0,0 +1,327 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	shutdownTimeout = 5 * time.Second
+
+	// MaxBackfillBlocks is the largest block range of a backfill.
+	MaxBackfillBlocks = 10000
+)
+
+// ErrNotFound is returned by the operations for an unknown network or chain.
+var ErrNotFound = errors.New("not found")
+
+// ChainStatus is the head of the listener of a chain.
+type ChainStatus struct {
+	Network   string `json:"network"`
+	Chain     string `json:"chain"`
+	Layer     string `json:"layer"`
+	Head      uint64 `json:"head"`
+	ChainHead uint64 `json:"chain_head"`
+	Lag       uint64 `json:"lag"`
+	Paused    bool   `json:"paused"`
+}
+
+// Subscription is a request registered on the listener of a chain.
+type Subscription struct {
+	Network string `json:"network"`
+	Chain   string `json:"chain"`
+	Type    string `json:"type"`
+	Key     string `json:"key"`
+}
+
+// DeadLetter is a notification a notifier failed to send.
+type DeadLetter struct {
+	Network  string    `json:"network"`
+	Notifier string    `json:"notifier"`
+	Title    string    `json:"title"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// Operations are the runtime operations on the listeners. An empty network
+// selects every network.
+type Operations interface {
+	Chains() []ChainStatus
+	Subscriptions(network, chain string) ([]Subscription, error)
+	// Pause skips the routine bridge event notifications until Resume, the
+	// alerts and the escalated events being still sent.
+	Pause(network string) error
+	Resume(network string) error
+	ResetHead(ctx context.Context, network, chain string, number uint64) error
+	// Backfill starts processing the blocks again in the background.
+	Backfill(network, chain string, fromBlock, toBlock uint64) error
+	DeadLetters(ctx context.Context) ([]DeadLetter, error)
+	// ReplayDeadLetters sends the failed notifications again, returning the
+	// number of the notifications sent.
+	ReplayDeadLetters(ctx context.Context) (int, error)
+}
+
+type chainsResponse struct {
+	Chains []ChainStatus `json:"chains"`
+}
+
+type subscriptionsResponse struct {
+	Subscriptions []Subscription `json:"subscriptions"`
+}
+
+type deadLettersResponse struct {
+	DeadLetters []DeadLetter `json:"dead_letters"`
+}
+
+type replayResponse struct {
+	Replayed int `json:"replayed"`
+}
+
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server exposes the operations through an HTTP/JSON API authenticated by a
+// bearer token.
+type Server struct {
+	addr  string
+	token string
+	ops   Operations
+}
+
+func NewServer(addr string, token string, ops Operations) *Server {
+	return &Server{
+		addr:  addr,
+		token: token,
+		ops:   ops,
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/chains", s.handleChains)
+	mux.HandleFunc("/subscriptions", s.handleSubscriptions)
+	mux.HandleFunc("/pause", s.handlePause)
+	mux.HandleFunc("/resume", s.handleResume)
+	mux.HandleFunc("/head", s.handleHead)
+	mux.HandleFunc("/backfill", s.handleBackfill)
+	mux.HandleFunc("/dlq", s.handleDeadLetters)
+	mux.HandleFunc("/dlq/replay", s.handleReplay)
+	return s.authenticate(mux)
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.GetLogger().Infow("Start the admin API server", "addr", s.addr)
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// authenticate rejects the requests without the bearer token, every request
+// when no token is set.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || s.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleChains serves GET /chains
+func (s *Server) handleChains(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, chainsResponse{Chains: s.ops.Chains()})
+}
+
+// handleSubscriptions serves GET /subscriptions?network=&chain=
+func (s *Server) handleSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	query := r.URL.Query()
+	subscriptions, err := s.ops.Subscriptions(query.Get("network"), query.Get("chain"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, subscriptionsResponse{Subscriptions: subscriptions})
+}
+
+// handlePause serves POST /pause?network=
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	if err := s.ops.Pause(r.URL.Query().Get("network")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, statusResponse{Status: "paused"})
+}
+
+// handleResume serves POST /resume?network=
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	if err := s.ops.Resume(r.URL.Query().Get("network")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, statusResponse{Status: "resumed"})
+}
+
+// handleHead serves POST /head?network=&chain=&block=
+func (s *Server) handleHead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	query := r.URL.Query()
+	number, err := strconv.ParseUint(query.Get("block"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid block: %s", query.Get("block"))})
+		return
+	}
+
+	if err := s.ops.ResetHead(r.Context(), query.Get("network"), query.Get("chain"), number); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, statusResponse{Status: "reset"})
+}
+
+// handleBackfill serves POST /backfill?network=&chain=&from=&to=
+func (s *Server) handleBackfill(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	query := r.URL.Query()
+	fromBlock, err := strconv.ParseUint(query.Get("from"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid from: %s", query.Get("from"))})
+		return
+	}
+
+	toBlock, err := strconv.ParseUint(query.Get("to"), 10, 64)
+	if err != nil || toBlock < fromBlock {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid to: %s", query.Get("to"))})
+		return
+	}
+
+	if toBlock-fromBlock >= MaxBackfillBlocks {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("at most %d blocks can be backfilled at once", MaxBackfillBlocks)})
+		return
+	}
+
+	if err := s.ops.Backfill(query.Get("network"), query.Get("chain"), fromBlock, toBlock); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, statusResponse{Status: "started"})
+}
+
+// handleDeadLetters serves GET /dlq
+func (s *Server) handleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	deadLetters, err := s.ops.DeadLetters(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deadLettersResponse{DeadLetters: deadLetters})
+}
+
+// handleReplay serves POST /dlq/replay
+func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	replayed, err := s.ops.ReplayDeadLetters(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, replayResponse{Replayed: replayed})
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: err.Error()})
+		return
+	}
+
+	log.GetLogger().Errorw("Failed to run the admin operation", "error", err)
+	writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.GetLogger().Errorw("Failed to write the response", "error", err)
+	}
+}
@@ -0,0 +1,166 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testOperations records the operations on the sepolia:l1 chain.
+type testOperations struct {
+	paused      map[string]bool
+	head        uint64
+	backfills   [][2]uint64
+	deadLetters []DeadLetter
+}
+
+func (o *testOperations) Chains() []ChainStatus {
+	return []ChainStatus{{Network: "sepolia", Chain: "l1", Layer: "l1", Head: o.head, ChainHead: 110, Lag: 110 - o.head, Paused: o.paused["sepolia"]}}
+}
+
+func (o *testOperations) Subscriptions(network, chain string) ([]Subscription, error) {
+	if err := o.find(network, chain); err != nil {
+		return nil, err
+	}
+
+	return []Subscription{{Network: "sepolia", Chain: "l1", Type: "event", Key: "0xa1:0xabcd"}}, nil
+}
+
+func (o *testOperations) Pause(network string) error {
+	o.paused[network] = true
+	return nil
+}
+
+func (o *testOperations) Resume(network string) error {
+	o.paused[network] = false
+	return nil
+}
+
+func (o *testOperations) ResetHead(_ context.Context, network, chain string, number uint64) error {
+	if err := o.find(network, chain); err != nil {
+		return err
+	}
+
+	o.head = number
+	return nil
+}
+
+func (o *testOperations) Backfill(network, chain string, fromBlock, toBlock uint64) error {
+	if err := o.find(network, chain); err != nil {
+		return err
+	}
+
+	o.backfills = append(o.backfills, [2]uint64{fromBlock, toBlock})
+	return nil
+}
+
+func (o *testOperations) DeadLetters(context.Context) ([]DeadLetter, error) {
+	return o.deadLetters, nil
+}
+
+func (o *testOperations) ReplayDeadLetters(context.Context) (int, error) {
+	replayed := len(o.deadLetters)
+	o.deadLetters = nil
+	return replayed, nil
+}
+
+func (o *testOperations) find(network, chain string) error {
+	if network != "sepolia" || chain != "l1" {
+		return fmt.Errorf("%w: chain %s:%s", ErrNotFound, network, chain)
+	}
+
+	return nil
+}
+
+func request(t *testing.T, server *httptest.Server, method, path, token string) *http.Response {
+	req, err := http.NewRequest(method, server.URL+path, nil)
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+func TestServer_Authentication(t *testing.T) {
+	server := httptest.NewServer(NewServer("", "secret", &testOperations{}).Handler())
+	defer server.Close()
+
+	for _, token := range []string{"", "wrong"} {
+		resp := request(t, server, http.MethodGet, "/chains", token)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, "Bearer", resp.Header.Get("WWW-Authenticate"))
+	}
+
+	// no token rejects every request
+	noToken := httptest.NewServer(NewServer("", "", &testOperations{}).Handler())
+	defer noToken.Close()
+	assert.Equal(t, http.StatusUnauthorized, request(t, noToken, http.MethodGet, "/chains", "").StatusCode)
+}
+
+func TestServer_Operations(t *testing.T) {
+	ops := &testOperations{paused: make(map[string]bool), head: 100}
+	server := httptest.NewServer(NewServer("", "secret", ops).Handler())
+	defer server.Close()
+
+	resp := request(t, server, http.MethodGet, "/chains", "secret")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var chains chainsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&chains))
+	assert.Equal(t, []ChainStatus{{Network: "sepolia", Chain: "l1", Layer: "l1", Head: 100, ChainHead: 110, Lag: 10}}, chains.Chains)
+
+	resp = request(t, server, http.MethodGet, "/subscriptions?network=sepolia&chain=l1", "secret")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var subscriptions subscriptionsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&subscriptions))
+	assert.Len(t, subscriptions.Subscriptions, 1)
+
+	assert.Equal(t, http.StatusNotFound, request(t, server, http.MethodGet, "/subscriptions?network=mainnet&chain=l1", "secret").StatusCode)
+
+	assert.Equal(t, http.StatusOK, request(t, server, http.MethodPost, "/pause?network=sepolia", "secret").StatusCode)
+	assert.True(t, ops.paused["sepolia"])
+	assert.Equal(t, http.StatusOK, request(t, server, http.MethodPost, "/resume?network=sepolia", "secret").StatusCode)
+	assert.False(t, ops.paused["sepolia"])
+
+	assert.Equal(t, http.StatusOK, request(t, server, http.MethodPost, "/head?network=sepolia&chain=l1&block=90", "secret").StatusCode)
+	assert.Equal(t, uint64(90), ops.head)
+
+	assert.Equal(t, http.StatusAccepted, request(t, server, http.MethodPost, "/backfill?network=sepolia&chain=l1&from=10&to=20", "secret").StatusCode)
+	assert.Equal(t, [][2]uint64{{10, 20}}, ops.backfills)
+
+	ops.deadLetters = []DeadLetter{{Network: "sepolia", Notifier: "slack", Title: "failed", Error: "webhook failed"}}
+	resp = request(t, server, http.MethodGet, "/dlq", "secret")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var deadLetters deadLettersResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&deadLetters))
+	assert.Equal(t, ops.deadLetters, deadLetters.DeadLetters)
+
+	resp = request(t, server, http.MethodPost, "/dlq/replay", "secret")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var replay replayResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&replay))
+	assert.Equal(t, 1, replay.Replayed)
+	assert.Empty(t, ops.deadLetters)
+
+	for _, path := range []string{
+		"/head?network=sepolia&chain=l1&block=latest",
+		"/backfill?network=sepolia&chain=l1&from=20&to=10",
+		"/backfill?network=sepolia&chain=l1&from=0&to=10000",
+	} {
+		assert.Equal(t, http.StatusBadRequest, request(t, server, http.MethodPost, path, "secret").StatusCode, path)
+	}
+
+	assert.Equal(t, http.StatusMethodNotAllowed, request(t, server, http.MethodGet, "/pause", "secret").StatusCode)
+	assert.Equal(t, http.StatusMethodNotAllowed, request(t, server, http.MethodPost, "/chains", "secret").StatusCode)
+	assert.Equal(t, http.StatusMethodNotAllowed, request(t, server, http.MethodGet, "/dlq/replay", "secret").StatusCode)
+}
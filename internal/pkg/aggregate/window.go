@@ -0,0 +1,102 @@
+package aggregate
+
+import (
+	"sync"
+	"time"
+)
+
+type entry struct {
+	key   string
+	at    time.Time
+	value float64
+}
+
+// Window sums the values of a rolling time window and reports when the sum
+// crosses a threshold. The window ends at the latest time added, so the
+// values of old blocks are aggregated by their own time.
+type Window struct {
+	duration  time.Duration
+	threshold float64
+
+	mu      sync.Mutex
+	entries []entry
+	keys    map[string]struct{}
+	latest  time.Time
+	sum     float64
+	crossed bool
+}
+
+func NewWindow(duration time.Duration, threshold float64) *Window {
+	return &Window{
+		duration:  duration,
+		threshold: threshold,
+		keys:      make(map[string]struct{}),
+	}
+}
+
+// Add adds the value at time at, identified by key so a re-delivered value is
+// counted once. It returns the sum of the window and whether this value made
+// the sum cross the threshold. The crossing is reported again only after the
+// sum fell below the threshold.
+func (w *Window) Add(key string, at time.Time, value float64) (float64, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.keys[key]; ok {
+		return w.sum, false
+	}
+
+	if at.After(w.latest) {
+		w.latest = at
+	}
+	w.expire()
+
+	if !at.After(w.latest.Add(-w.duration)) {
+		return w.sum, false
+	}
+
+	w.entries = append(w.entries, entry{key: key, at: at, value: value})
+	w.keys[key] = struct{}{}
+	w.sum += value
+
+	if w.sum < w.threshold {
+		w.crossed = false
+		return w.sum, false
+	}
+
+	if w.crossed {
+		return w.sum, false
+	}
+	w.crossed = true
+
+	return w.sum, true
+}
+
+// Sum returns the sum of the window.
+func (w *Window) Sum() float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.sum
+}
+
+// expire drops the entries out of the window ending at the latest time.
+func (w *Window) expire() {
+	start := w.latest.Add(-w.duration)
+
+	kept := w.entries[:0]
+	w.sum = 0
+	for _, e := range w.entries {
+		if !e.at.After(start) {
+			delete(w.keys, e.key)
+			continue
+		}
+		kept = append(kept, e)
+		w.sum += e.value
+	}
+	w.entries = kept
+
+	if w.sum < w.threshold {
+		w.crossed = false
+	}
+}
@@ -0,0 +1,46 @@
+package aggregate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindow_Add(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	window := NewWindow(time.Hour, 1000)
+
+	sum, crossed := window.Add("a", start, 600)
+	assert.Equal(t, 600.0, sum)
+	assert.False(t, crossed)
+
+	// a re-delivered value is counted once
+	sum, crossed = window.Add("a", start, 600)
+	assert.Equal(t, 600.0, sum)
+	assert.False(t, crossed)
+
+	sum, crossed = window.Add("b", start.Add(30*time.Minute), 500)
+	assert.Equal(t, 1100.0, sum)
+	assert.True(t, crossed)
+
+	// the crossing is reported once while the sum stays above the threshold
+	sum, crossed = window.Add("c", start.Add(40*time.Minute), 100)
+	assert.Equal(t, 1200.0, sum)
+	assert.False(t, crossed)
+
+	// a moves out of the window, the sum falls below the threshold
+	sum, crossed = window.Add("d", start.Add(61*time.Minute), 10)
+	assert.Equal(t, 610.0, sum)
+	assert.False(t, crossed)
+
+	sum, crossed = window.Add("e", start.Add(62*time.Minute), 400)
+	assert.Equal(t, 1010.0, sum)
+	assert.True(t, crossed)
+
+	// a value older than the window is ignored
+	sum, crossed = window.Add("f", start, 5000)
+	assert.Equal(t, 1010.0, sum)
+	assert.False(t, crossed)
+	assert.Equal(t, 1010.0, window.Sum())
+}
@@ -0,0 +1,124 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+//go:embed migrations
+var migrationsFS embed.FS
+
+type migration struct {
+	version int
+	name    string
+	query   string
+}
+
+// Migrate applies the migrations of the driver which haven't been applied yet.
+// Applied versions are tracked in the schema_migrations table.
+func Migrate(ctx context.Context, db *sql.DB, driver string) error {
+	migrations, err := loadMigrations(driver)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at BIGINT NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	applied := make(map[int]bool)
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return err
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+
+		log.GetLogger().Infow("Apply archive migration", "version", m.version, "name", m.name)
+
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, m.query); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("failed to apply migration %s: %w", m.name, err)
+		}
+
+		query := rebind(driver, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`)
+		if _, err := tx.ExecContext(ctx, query, m.version, time.Now().Unix()); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func loadMigrations(driver string) ([]migration, error) {
+	dir := path.Join("migrations", driver)
+	entries, err := fs.ReadDir(migrationsFS, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for driver %s: %w", driver, err)
+	}
+
+	migrations := make([]migration, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+
+		version, err := strconv.Atoi(strings.SplitN(name, "_", 2)[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration name %s: %w", name, err)
+		}
+
+		query, err := fs.ReadFile(migrationsFS, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, migration{version: version, name: name, query: string(query)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return migrations, nil
+}
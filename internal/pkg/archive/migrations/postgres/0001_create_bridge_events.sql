@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS bridge_events (
+    id           BIGSERIAL PRIMARY KEY,
+    network      TEXT          NOT NULL,
+    layer        TEXT          NOT NULL,
+    chain_id     BIGINT        NOT NULL,
+    bridge       TEXT          NOT NULL,
+    direction    TEXT          NOT NULL,
+    status       TEXT          NOT NULL,
+    block_number BIGINT        NOT NULL,
+    block_hash   TEXT          NOT NULL,
+    block_time   BIGINT        NOT NULL,
+    tx_hash      TEXT          NOT NULL,
+    log_index    INTEGER       NOT NULL,
+    l1_token     TEXT          NOT NULL,
+    l2_token     TEXT          NOT NULL,
+    from_address TEXT          NOT NULL,
+    to_address   TEXT          NOT NULL,
+    amount       NUMERIC(78,0) NOT NULL,
+    symbol       TEXT          NOT NULL,
+    decimals     INTEGER       NOT NULL,
+    created_at   BIGINT        NOT NULL,
+    UNIQUE (chain_id, tx_hash, log_index)
+);
+
+CREATE INDEX IF NOT EXISTS bridge_events_from_address_idx ON bridge_events (from_address);
+CREATE INDEX IF NOT EXISTS bridge_events_to_address_idx ON bridge_events (to_address);
+CREATE INDEX IF NOT EXISTS bridge_events_l1_token_idx ON bridge_events (l1_token);
+CREATE INDEX IF NOT EXISTS bridge_events_l2_token_idx ON bridge_events (l2_token);
+CREATE INDEX IF NOT EXISTS bridge_events_block_time_idx ON bridge_events (block_time);
@@ -0,0 +1,154 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	shutdownTimeout = 5 * time.Second
+)
+
+type eventsResponse struct {
+	Events []*Record `json:"events"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server exposes the archived events through a read-only HTTP/JSON API.
+type Server struct {
+	addr  string
+	store *Store
+}
+
+func NewServer(addr string, store *Store) *Server {
+	return &Server{
+		addr:  addr,
+		store: store,
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", s.handleEvents)
+	return mux
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.GetLogger().Infow("Start the archive API server", "addr", s.addr)
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// handleEvents serves GET /events?address=&token=&direction=&from=&to=&limit=&offset=
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	records, err := s.store.Query(r.Context(), filter)
+	if err != nil {
+		log.GetLogger().Errorw("Failed to query the archived events", "error", err)
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to query events"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, eventsResponse{Events: records})
+}
+
+func parseFilter(r *http.Request) (Filter, error) {
+	query := r.URL.Query()
+
+	filter := Filter{
+		Address:   query.Get("address"),
+		Token:     query.Get("token"),
+		Direction: query.Get("direction"),
+	}
+
+	switch filter.Direction {
+	case "", types.DirectionDeposit, types.DirectionWithdrawal:
+	default:
+		return Filter{}, fmt.Errorf("invalid direction: %s", filter.Direction)
+	}
+
+	var err error
+	if filter.Since, err = parseTime(query.Get("from")); err != nil {
+		return Filter{}, fmt.Errorf("invalid from: %w", err)
+	}
+
+	if filter.Until, err = parseTime(query.Get("to")); err != nil {
+		return Filter{}, fmt.Errorf("invalid to: %w", err)
+	}
+
+	if v := query.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			return Filter{}, fmt.Errorf("invalid limit: %w", err)
+		}
+	}
+
+	if v := query.Get("offset"); v != "" {
+		if filter.Offset, err = strconv.Atoi(v); err != nil || filter.Offset < 0 {
+			return Filter{}, fmt.Errorf("invalid offset: %s", v)
+		}
+	}
+
+	return filter, nil
+}
+
+// parseTime accepts either unix seconds or RFC3339.
+func parseTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+
+	return time.Parse(time.RFC3339, v)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.GetLogger().Errorw("Failed to write the response", "error", err)
+	}
+}
@@ -0,0 +1,51 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+func TestServer_Events(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	require.NoError(t, store.Write(ctx, newTestEvent(0, types.DirectionDeposit, alice, alice, baseTs)))
+	require.NoError(t, store.Write(ctx, newTestEvent(1, types.DirectionWithdrawal, bob, bob, baseTs.Add(time.Hour))))
+
+	server := httptest.NewServer(NewServer("", store).Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events?direction=withdrawal&from=" + baseTs.Format(time.RFC3339))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body eventsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(t, body.Events, 1)
+	assert.Equal(t, bob.Hex(), body.Events[0].From)
+	assert.Equal(t, types.DirectionWithdrawal, body.Events[0].Direction)
+
+	for _, query := range []string{"direction=sideways", "from=yesterday", "limit=ten"} {
+		resp, err := http.Get(server.URL + "/events?" + query)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+
+	resp, err = http.Post(server.URL+"/events", "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -0,0 +1,239 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/database"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+const (
+	DefaultQueryLimit = 100
+	MaxQueryLimit     = 1000
+)
+
+// Record is an archived bridge event.
+type Record struct {
+	ID          int64     `json:"id"`
+	Network     string    `json:"network"`
+	Layer       string    `json:"layer"`
+	ChainID     uint64    `json:"chain_id"`
+	Bridge      string    `json:"bridge"`
+	Direction   string    `json:"direction"`
+	Status      string    `json:"status"`
+	BlockNumber uint64    `json:"block_number"`
+	BlockHash   string    `json:"block_hash"`
+	BlockTime   time.Time `json:"block_time"`
+	TxHash      string    `json:"tx_hash"`
+	LogIndex    uint      `json:"log_index"`
+	L1Token     string    `json:"l1_token"`
+	L2Token     string    `json:"l2_token"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Amount      string    `json:"amount"`
+	Symbol      string    `json:"symbol"`
+	Decimals    int       `json:"decimals"`
+}
+
+// Filter narrows down the archived events. Zero values are ignored.
+type Filter struct {
+	// Address matches either the sender or the recipient.
+	Address string
+	// Token matches either the L1 or the L2 token.
+	Token     string
+	Direction string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+	Offset    int
+}
+
+type Store struct {
+	db     *sql.DB
+	driver string
+}
+
+func NewStore(ctx context.Context, db *sql.DB, driver string) (*Store, error) {
+	if err := Migrate(ctx, db, driver); err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		db:     db,
+		driver: driver,
+	}, nil
+}
+
+// Write archives the event. Writing the same event twice is a no-op.
+func (s *Store) Write(ctx context.Context, event *types.BridgeEvent) error {
+	query := rebind(s.driver, `INSERT INTO bridge_events (
+		network, layer, chain_id, bridge, direction, status, block_number, block_hash, block_time,
+		tx_hash, log_index, l1_token, l2_token, from_address, to_address, amount, symbol, decimals, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (chain_id, tx_hash, log_index) DO NOTHING`)
+
+	amount := "0"
+	if event.Amount != nil {
+		amount = event.Amount.String()
+	}
+
+	_, err := s.db.ExecContext(ctx, query,
+		event.Network,
+		event.Layer,
+		int64(event.ChainID),
+		event.Bridge,
+		event.Direction,
+		event.Status,
+		int64(event.BlockNumber),
+		event.BlockHash.Hex(),
+		event.BlockTime.Unix(),
+		event.TxHash.Hex(),
+		int64(event.LogIndex),
+		event.L1Token.Hex(),
+		event.L2Token.Hex(),
+		event.From.Hex(),
+		event.To.Hex(),
+		amount,
+		event.Symbol,
+		event.Decimals,
+		time.Now().Unix(),
+	)
+	return err
+}
+
+// Retract deletes the events of a re-organized block. Events included again in
+// the new block are written back by Write.
+func (s *Store) Retract(ctx context.Context, chainID uint64, removedBlockHash common.Hash, _ *ethereumTypes.Header) error {
+	query := rebind(s.driver, `DELETE FROM bridge_events WHERE chain_id = ? AND block_hash = ?`)
+
+	_, err := s.db.ExecContext(ctx, query, int64(chainID), removedBlockHash.Hex())
+	return err
+}
+
+func (s *Store) Query(ctx context.Context, filter Filter) ([]*Record, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if filter.Address != "" {
+		address := common.HexToAddress(filter.Address).Hex()
+		conditions = append(conditions, "(from_address = ? OR to_address = ?)")
+		args = append(args, address, address)
+	}
+
+	if filter.Token != "" {
+		token := common.HexToAddress(filter.Token).Hex()
+		conditions = append(conditions, "(l1_token = ? OR l2_token = ?)")
+		args = append(args, token, token)
+	}
+
+	if filter.Direction != "" {
+		conditions = append(conditions, "direction = ?")
+		args = append(args, filter.Direction)
+	}
+
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "block_time >= ?")
+		args = append(args, filter.Since.Unix())
+	}
+
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "block_time < ?")
+		args = append(args, filter.Until.Unix())
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	if limit > MaxQueryLimit {
+		limit = MaxQueryLimit
+	}
+
+	query := `SELECT id, network, layer, chain_id, bridge, direction, status, block_number, block_hash, block_time,
+		tx_hash, log_index, l1_token, l2_token, from_address, to_address, amount, symbol, decimals
+		FROM bridge_events`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY block_time DESC, id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, filter.Offset)
+
+	rows, err := s.db.QueryContext(ctx, rebind(s.driver, query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]*Record, 0)
+	for rows.Next() {
+		var (
+			record    Record
+			blockTime int64
+		)
+
+		err := rows.Scan(
+			&record.ID,
+			&record.Network,
+			&record.Layer,
+			&record.ChainID,
+			&record.Bridge,
+			&record.Direction,
+			&record.Status,
+			&record.BlockNumber,
+			&record.BlockHash,
+			&blockTime,
+			&record.TxHash,
+			&record.LogIndex,
+			&record.L1Token,
+			&record.L2Token,
+			&record.From,
+			&record.To,
+			&record.Amount,
+			&record.Symbol,
+			&record.Decimals,
+		)
+		if err != nil {
+			return nil, err
+		}
+		record.BlockTime = time.Unix(blockTime, 0).UTC()
+
+		records = append(records, &record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// rebind replaces the `?` placeholders with the positional ones of postgres.
+func rebind(driver string, query string) string {
+	if driver != database.DriverPostgres {
+		return query
+	}
+
+	var (
+		builder strings.Builder
+		n       int
+	)
+	for _, c := range query {
+		if c == '?' {
+			n++
+			builder.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		builder.WriteRune(c)
+	}
+
+	return builder.String()
+}
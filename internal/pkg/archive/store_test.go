@@ -0,0 +1,148 @@
+package archive
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/database"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+var (
+	alice  = common.HexToAddress("0x00000000000000000000000000000000000a11ce")
+	bob    = common.HexToAddress("0x0000000000000000000000000000000000000b0b")
+	ton    = common.HexToAddress("0xa30fe40285B8f5c0457DbC3B7C8A280373c40044")
+	l2Ton  = common.HexToAddress("0x4200000000000000000000000000000000000486")
+	baseTs = time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func newTestStore(t *testing.T) *Store {
+	ctx := context.Background()
+
+	db, err := database.New(ctx, database.Config{
+		Driver: database.DriverSQLite,
+		DSN:    filepath.Join(t.TempDir(), "archive.db"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	store, err := NewStore(ctx, db, database.DriverSQLite)
+	require.NoError(t, err)
+
+	return store
+}
+
+func newTestEvent(logIndex uint, direction string, from, to common.Address, blockTime time.Time) *types.BridgeEvent {
+	return &types.BridgeEvent{
+		Network:     "sepolia",
+		Layer:       types.LayerL1,
+		ChainID:     11155111,
+		Bridge:      types.BridgeStandard,
+		Direction:   direction,
+		Status:      types.StatusInitiated,
+		BlockNumber: 100,
+		BlockHash:   common.HexToHash("0x01"),
+		BlockTime:   blockTime,
+		TxHash:      common.HexToHash("0x02"),
+		LogIndex:    logIndex,
+		L1Token:     ton,
+		L2Token:     l2Ton,
+		From:        from,
+		To:          to,
+		Amount:      new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil),
+		Symbol:      "TON",
+		Decimals:    18,
+	}
+}
+
+func TestStore_WriteAndQuery(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	require.NoError(t, store.Write(ctx, newTestEvent(0, types.DirectionDeposit, alice, alice, baseTs)))
+	require.NoError(t, store.Write(ctx, newTestEvent(1, types.DirectionWithdrawal, bob, alice, baseTs.Add(time.Hour))))
+	require.NoError(t, store.Write(ctx, newTestEvent(2, types.DirectionDeposit, bob, bob, baseTs.Add(2*time.Hour))))
+
+	// duplicated events are ignored
+	require.NoError(t, store.Write(ctx, newTestEvent(0, types.DirectionDeposit, alice, alice, baseTs)))
+
+	records, err := store.Query(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, uint(2), records[0].LogIndex)
+	assert.Equal(t, "1000000000000000000000000000000", records[0].Amount)
+	assert.Equal(t, baseTs.Add(2*time.Hour), records[0].BlockTime)
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected []uint
+	}{
+		{name: "address", filter: Filter{Address: alice.Hex()}, expected: []uint{1, 0}},
+		{name: "lowercase address", filter: Filter{Address: "0x0000000000000000000000000000000000000b0b"}, expected: []uint{2, 1}},
+		{name: "token", filter: Filter{Token: l2Ton.Hex()}, expected: []uint{2, 1, 0}},
+		{name: "unknown token", filter: Filter{Token: bob.Hex()}, expected: []uint{}},
+		{name: "direction", filter: Filter{Direction: types.DirectionDeposit}, expected: []uint{2, 0}},
+		{name: "time range", filter: Filter{Since: baseTs.Add(time.Hour), Until: baseTs.Add(2 * time.Hour)}, expected: []uint{1}},
+		{name: "limit and offset", filter: Filter{Limit: 1, Offset: 1}, expected: []uint{1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			records, err := store.Query(ctx, test.filter)
+			require.NoError(t, err)
+
+			logIndexes := make([]uint, 0, len(records))
+			for _, record := range records {
+				logIndexes = append(logIndexes, record.LogIndex)
+			}
+			assert.Equal(t, test.expected, logIndexes)
+		})
+	}
+}
+
+func TestStore_Retract(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	event := newTestEvent(0, types.DirectionDeposit, alice, bob, baseTs)
+	require.NoError(t, store.Write(ctx, event))
+
+	require.NoError(t, store.Retract(ctx, event.ChainID+1, event.BlockHash, nil))
+	records, err := store.Query(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	require.NoError(t, store.Retract(ctx, event.ChainID, event.BlockHash, nil))
+	records, err = store.Query(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, records, 0)
+
+	// the event included again in the new block is archived with the new block hash
+	event.BlockHash = common.HexToHash("0x03")
+	require.NoError(t, store.Write(ctx, event))
+	records, err = store.Query(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, event.BlockHash.Hex(), records[0].BlockHash)
+}
+
+func TestMigrate_Idempotent(t *testing.T) {
+	store := newTestStore(t)
+
+	require.NoError(t, Migrate(context.Background(), store.db, database.DriverSQLite))
+}
+
+func Test_rebind(t *testing.T) {
+	query := "SELECT * FROM t WHERE a = ? AND b = ?"
+
+	assert.Equal(t, query, rebind(database.DriverSQLite, query))
+	assert.Equal(t, "SELECT * FROM t WHERE a = $1 AND b = $2", rebind(database.DriverPostgres, query))
+}
@@ -67,6 +67,10 @@ func (c *Client) GetClient() *ethclient.Client {
 	return c.defaultClient
 }
 
+func (c *Client) ChainID() *big.Int {
+	return c.chainID
+}
+
 func (c *Client) SubscribeNewHead(ctx context.Context, newHeadCh chan<- *ethereumTypes.Header) (ethereum.Subscription, error) {
 	return c.wsClient.SubscribeNewHead(ctx, newHeadCh)
 }
@@ -110,6 +114,15 @@ func (c *Client) GetLogs(ctx context.Context, blockHash common.Hash) ([]ethereum
 	return nil, err
 }
 
+func (c *Client) GetTransactions(ctx context.Context, blockHash common.Hash) (ethereumTypes.Transactions, error) {
+	block, err := c.defaultClient.BlockByHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return block.Transactions(), nil
+}
+
 func (c *Client) HeaderAtBlockHash(ctx context.Context, blockHash common.Hash) (*ethereumTypes.Header, error) {
 	headerAtBlockHash, err := c.defaultClient.HeaderByHash(ctx, blockHash)
 	if err != nil {
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+func New(ctx context.Context, dbConfig Config) (*sql.DB, error) {
+	if dbConfig.DSN == "" {
+		return nil, errors.New("database dsn is empty")
+	}
+
+	switch dbConfig.Driver {
+	case DriverPostgres, DriverSQLite:
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", dbConfig.Driver)
+	}
+
+	db, err := sql.Open(dbConfig.Driver, dbConfig.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	if dbConfig.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(dbConfig.MaxOpenConns)
+	} else if dbConfig.Driver == DriverSQLite {
+		// sqlite allows only one writer at a time
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
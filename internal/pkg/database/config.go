@@ -0,0 +1,12 @@
+package database
+
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+type Config struct {
+	Driver       string `json:"driver" yaml:"driver" toml:"driver"`
+	DSN          string `json:"-" yaml:"dsn" toml:"dsn"`
+	MaxOpenConns int    `json:"max_open_conns" yaml:"max_open_conns" toml:"max_open_conns"`
+}
@@ -0,0 +1,534 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+const (
+	DefaultLargest = 5
+
+	DefaultDepositStuckAfter    = time.Hour
+	DefaultWithdrawalStuckAfter = 8 * 24 * time.Hour
+	DefaultMaxPendingAge        = 30 * 24 * time.Hour
+)
+
+type Period string
+
+const (
+	PeriodHourly Period = "hourly"
+	PeriodDaily  Period = "daily"
+	PeriodWeekly Period = "weekly"
+)
+
+func ParsePeriod(s string) (Period, error) {
+	switch period := Period(s); period {
+	case PeriodHourly, PeriodDaily, PeriodWeekly:
+		return period, nil
+	default:
+		return "", fmt.Errorf("unknown digest period: %s", s)
+	}
+}
+
+// Start returns the start of the period of t, in UTC. The weeks start on
+// Monday.
+func (p Period) Start(t time.Time) time.Time {
+	t = t.UTC()
+	switch p {
+	case PeriodHourly:
+		return t.Truncate(time.Hour)
+	case PeriodWeekly:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// End returns the end of the period starting at start.
+func (p Period) End(start time.Time) time.Time {
+	switch p {
+	case PeriodHourly:
+		return start.Add(time.Hour)
+	case PeriodWeekly:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+type Config struct {
+	Periods []Period
+	// Largest is the number of the largest transfers of the reports.
+	Largest int
+	// StuckAfter is the duration after which an initiated transfer which
+	// isn't finalized is stuck, by direction.
+	StuckAfter map[string]time.Duration
+	// MaxPendingAge is the age after which a transfer which isn't finalized
+	// is no longer tracked.
+	MaxPendingAge time.Duration
+}
+
+// Transfer is a bridge transfer of the reports.
+type Transfer struct {
+	Key       string    `json:"key"`
+	Direction string    `json:"direction"`
+	Asset     string    `json:"asset"`
+	Amount    *big.Int  `json:"amount"`
+	Decimals  int       `json:"decimals"`
+	ValueUSD  *float64  `json:"value_usd,omitempty"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	TxHash    string    `json:"tx_hash"`
+	Time      time.Time `json:"time"`
+	// TransferKey pairs the pending transfer with its finalization, and
+	// Alerted is set once the stuck transfer has been returned by Stuck.
+	TransferKey string `json:"transfer_key,omitempty"`
+	Alerted     bool   `json:"alerted,omitempty"`
+}
+
+// Volume sums the initiated transfers of an asset in a direction.
+type Volume struct {
+	Direction string   `json:"direction"`
+	Asset     string   `json:"asset"`
+	Count     int      `json:"count"`
+	Amount    *big.Int `json:"amount"`
+	Decimals  int      `json:"decimals"`
+	ValueUSD  float64  `json:"value_usd"`
+}
+
+// Report aggregates the events of a period.
+type Report struct {
+	Period      Period             `json:"period"`
+	Start       time.Time          `json:"start"`
+	End         time.Time          `json:"end"`
+	Deposits    int                `json:"deposits"`
+	Withdrawals int                `json:"withdrawals"`
+	Finalized   int                `json:"finalized"`
+	Volumes     map[string]*Volume `json:"volumes"`
+	Largest     []Transfer         `json:"largest"`
+	Addresses   map[string]bool    `json:"addresses"`
+	// Pending and Stuck are set when the report is flushed.
+	Pending int        `json:"pending"`
+	Stuck   []Transfer `json:"stuck,omitempty"`
+}
+
+func newReport(period Period, start time.Time) *Report {
+	return &Report{
+		Period:    period,
+		Start:     start,
+		End:       period.End(start),
+		Volumes:   make(map[string]*Volume),
+		Addresses: make(map[string]bool),
+	}
+}
+
+// SortedVolumes returns the volumes by direction, then by descending USD
+// value, then by asset.
+func (r *Report) SortedVolumes() []*Volume {
+	volumes := make([]*Volume, 0, len(r.Volumes))
+	for _, volume := range r.Volumes {
+		volumes = append(volumes, volume)
+	}
+
+	sort.Slice(volumes, func(i, j int) bool {
+		if volumes[i].Direction != volumes[j].Direction {
+			return volumes[i].Direction < volumes[j].Direction
+		}
+		if volumes[i].ValueUSD != volumes[j].ValueUSD {
+			return volumes[i].ValueUSD > volumes[j].ValueUSD
+		}
+		return volumes[i].Asset < volumes[j].Asset
+	})
+
+	return volumes
+}
+
+// State is the persisted state of the digests: the reports of the current
+// periods and the pending transfers.
+type State struct {
+	Reports map[Period]*Report    `json:"reports"`
+	Pending map[string][]Transfer `json:"pending"`
+	// Seen holds the keys of the events of the longest current period, so a
+	// re-delivered event is counted once.
+	Seen map[string]time.Time `json:"seen"`
+	// Recovered holds the stuck transfers finalized since the last call to
+	// Stuck.
+	Recovered []Transfer `json:"recovered,omitempty"`
+}
+
+// Store persists the state of the digests.
+type Store interface {
+	Load(ctx context.Context) (*State, error)
+	Save(ctx context.Context, state *State) error
+}
+
+// Digest aggregates the bridge events of a network into the reports of the
+// periods.
+type Digest struct {
+	cfg   Config
+	store Store
+
+	mu    sync.Mutex
+	state *State
+	// dirty is set when the state changed since it was saved.
+	dirty bool
+}
+
+// New restores the state of the store, which may be nil to keep the state in
+// memory only.
+func New(ctx context.Context, cfg Config, store Store) (*Digest, error) {
+	if cfg.Largest <= 0 {
+		cfg.Largest = DefaultLargest
+	}
+	if cfg.MaxPendingAge <= 0 {
+		cfg.MaxPendingAge = DefaultMaxPendingAge
+	}
+
+	d := &Digest{
+		cfg:   cfg,
+		store: store,
+	}
+
+	var state *State
+	if store != nil {
+		var err error
+		state, err = store.Load(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	d.state = normalizeState(state)
+
+	return d, nil
+}
+
+func normalizeState(state *State) *State {
+	if state == nil {
+		state = &State{}
+	}
+	if state.Reports == nil {
+		state.Reports = make(map[Period]*Report)
+	}
+	if state.Pending == nil {
+		state.Pending = make(map[string][]Transfer)
+	}
+	if state.Seen == nil {
+		state.Seen = make(map[string]time.Time)
+	}
+	for _, report := range state.Reports {
+		if report.Volumes == nil {
+			report.Volumes = make(map[string]*Volume)
+		}
+		if report.Addresses == nil {
+			report.Addresses = make(map[string]bool)
+		}
+	}
+
+	return state
+}
+
+// Add aggregates the event in the reports of its periods. The events of the
+// periods already reported are only used to track the pending transfers. The
+// state is saved by the next Flush.
+func (d *Digest) Add(event *types.BridgeEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := event.Key()
+	if _, ok := d.state.Seen[key]; ok {
+		return
+	}
+	d.state.Seen[key] = event.BlockTime
+	d.dirty = true
+
+	transfer := newTransfer(event)
+	d.trackPending(event, transfer)
+
+	for _, period := range d.cfg.Periods {
+		report := d.report(period, event.BlockTime)
+		if event.BlockTime.Before(report.Start) {
+			continue
+		}
+		d.addToReport(report, event, transfer)
+	}
+}
+
+func (d *Digest) report(period Period, t time.Time) *Report {
+	report, ok := d.state.Reports[period]
+	if !ok {
+		report = newReport(period, period.Start(t))
+		d.state.Reports[period] = report
+	}
+
+	return report
+}
+
+func (d *Digest) addToReport(report *Report, event *types.BridgeEvent, transfer Transfer) {
+	report.Addresses[event.From.Hex()] = true
+	report.Addresses[event.To.Hex()] = true
+
+	if event.Status == types.StatusFinalized {
+		report.Finalized++
+		return
+	}
+
+	if event.Direction == types.DirectionWithdrawal {
+		report.Withdrawals++
+	} else {
+		report.Deposits++
+	}
+
+	volumeKey := event.Direction + ":" + transfer.Asset
+	volume, ok := report.Volumes[volumeKey]
+	if !ok {
+		volume = &Volume{Direction: event.Direction, Asset: transfer.Asset, Amount: new(big.Int), Decimals: event.Decimals}
+		report.Volumes[volumeKey] = volume
+	}
+	volume.Count++
+	volume.Amount.Add(volume.Amount, transfer.Amount)
+	if transfer.ValueUSD != nil {
+		volume.ValueUSD += *transfer.ValueUSD
+	}
+
+	if transfer.ValueUSD == nil {
+		return
+	}
+
+	report.Largest = append(report.Largest, transfer)
+	sort.SliceStable(report.Largest, func(i, j int) bool {
+		return *report.Largest[i].ValueUSD > *report.Largest[j].ValueUSD
+	})
+	if len(report.Largest) > d.cfg.Largest {
+		report.Largest = report.Largest[:d.cfg.Largest]
+	}
+}
+
+// trackPending adds the initiated transfers to the pending ones and removes
+// the pending transfer an event finalizes. The transfers are paired by
+// their bridge, addresses and amount, as the events have no common id.
+func (d *Digest) trackPending(event *types.BridgeEvent, transfer Transfer) {
+	key := event.TransferKey()
+
+	if event.Status != types.StatusFinalized {
+		d.state.Pending[key] = append(d.state.Pending[key], transfer)
+		return
+	}
+
+	pending := d.state.Pending[key]
+	if len(pending) == 0 {
+		return
+	}
+
+	if pending[0].Alerted {
+		d.state.Recovered = append(d.state.Recovered, pending[0])
+	}
+
+	if len(pending) == 1 {
+		delete(d.state.Pending, key)
+		return
+	}
+	d.state.Pending[key] = pending[1:]
+}
+
+// Flush returns the reports of the periods ended at now, with the pending
+// and stuck transfers at now, and starts the next periods. It drops the
+// pending transfers older than MaxPendingAge and saves the changed state.
+func (d *Digest) Flush(ctx context.Context, now time.Time) ([]*Report, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var reports []*Report
+	for _, period := range d.cfg.Periods {
+		report, ok := d.state.Reports[period]
+		if !ok {
+			d.state.Reports[period] = newReport(period, period.Start(now))
+			continue
+		}
+
+		if now.Before(report.End) {
+			continue
+		}
+
+		report.Pending, report.Stuck = d.pendingAt(now)
+		reports = append(reports, report)
+		d.state.Reports[period] = newReport(period, period.Start(now))
+	}
+
+	if len(reports) > 0 {
+		d.expireSeen()
+		d.dirty = true
+	}
+
+	d.expirePending(now)
+
+	return reports, d.save(ctx)
+}
+
+func (d *Digest) pendingAt(now time.Time) (int, []Transfer) {
+	var (
+		count int
+		stuck []Transfer
+	)
+	for _, transfers := range d.state.Pending {
+		for _, transfer := range transfers {
+			count++
+
+			if d.isStuck(transfer, now) {
+				stuck = append(stuck, transfer)
+			}
+		}
+	}
+
+	sort.Slice(stuck, func(i, j int) bool {
+		return stuck[i].Time.Before(stuck[j].Time)
+	})
+
+	return count, stuck
+}
+
+// Stuck returns the transfers stuck at now which weren't returned before,
+// the oldest first, and the stuck transfers finalized since the last call.
+func (d *Digest) Stuck(ctx context.Context, now time.Time) ([]Transfer, []Transfer, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	recovered := d.state.Recovered
+	d.state.Recovered = nil
+
+	var stuck []Transfer
+	for _, transfers := range d.state.Pending {
+		for i := range transfers {
+			if transfers[i].Alerted || !d.isStuck(transfers[i], now) {
+				continue
+			}
+
+			transfers[i].Alerted = true
+			stuck = append(stuck, transfers[i])
+			d.dirty = true
+		}
+	}
+
+	if len(recovered) > 0 {
+		d.dirty = true
+	}
+
+	if len(stuck) == 0 && len(recovered) == 0 {
+		return nil, nil, nil
+	}
+
+	sort.Slice(stuck, func(i, j int) bool {
+		return stuck[i].Time.Before(stuck[j].Time)
+	})
+
+	return stuck, recovered, d.save(ctx)
+}
+
+func (d *Digest) isStuck(transfer Transfer, now time.Time) bool {
+	stuckAfter, ok := d.cfg.StuckAfter[transfer.Direction]
+	if !ok {
+		stuckAfter = DefaultDepositStuckAfter
+		if transfer.Direction == types.DirectionWithdrawal {
+			stuckAfter = DefaultWithdrawalStuckAfter
+		}
+	}
+
+	return now.Sub(transfer.Time) >= stuckAfter
+}
+
+// expireSeen drops the keys of the events older than the current reports.
+func (d *Digest) expireSeen() {
+	oldest := time.Time{}
+	for _, report := range d.state.Reports {
+		if oldest.IsZero() || report.Start.Before(oldest) {
+			oldest = report.Start
+		}
+	}
+
+	for key, at := range d.state.Seen {
+		if at.Before(oldest) {
+			delete(d.state.Seen, key)
+		}
+	}
+}
+
+// expirePending drops the pending transfers older than MaxPendingAge.
+func (d *Digest) expirePending(now time.Time) {
+	for key, transfers := range d.state.Pending {
+		kept := transfers[:0]
+		for _, transfer := range transfers {
+			if now.Sub(transfer.Time) < d.cfg.MaxPendingAge {
+				kept = append(kept, transfer)
+			}
+		}
+
+		if len(kept) == len(transfers) {
+			continue
+		}
+		d.dirty = true
+
+		if len(kept) == 0 {
+			delete(d.state.Pending, key)
+			continue
+		}
+		d.state.Pending[key] = kept
+	}
+}
+
+// save saves the state when it changed since it was last saved.
+func (d *Digest) save(ctx context.Context) error {
+	if d.store == nil || !d.dirty {
+		return nil
+	}
+
+	if err := d.store.Save(ctx, d.state); err != nil {
+		return err
+	}
+	d.dirty = false
+
+	return nil
+}
+
+func newTransfer(event *types.BridgeEvent) Transfer {
+	amount := new(big.Int)
+	if event.Amount != nil {
+		amount.Set(event.Amount)
+	}
+
+	return Transfer{
+		Key:       event.Key(),
+		Direction: event.Direction,
+		Asset:     assetName(event),
+		Amount:    amount,
+		Decimals:  event.Decimals,
+		ValueUSD:  event.ValueUSD,
+		From:      event.From.Hex(),
+		To:        event.To.Hex(),
+		TxHash:    event.TxHash.Hex(),
+		Time:      event.BlockTime,
+
+		TransferKey: event.TransferKey(),
+	}
+}
+
+// assetName names the classified assets, the other tokens by their symbol
+// and address so tokens with the same symbol aren't summed together.
+func assetName(event *types.BridgeEvent) string {
+	if event.Asset != "" {
+		return event.Asset
+	}
+
+	token := event.L1Token
+	if event.Layer == types.LayerL2 {
+		token = event.L2Token
+	}
+
+	return fmt.Sprintf("%s (%s)", event.Symbol, strings.ToLower(token.Hex()[:10]))
+}
@@ -0,0 +1,223 @@
+package digest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+func TestPeriod_Start(t *testing.T) {
+	// a wednesday
+	at := time.Date(2024, 1, 3, 15, 42, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2024, 1, 3, 15, 0, 0, 0, time.UTC), PeriodHourly.Start(at))
+	assert.Equal(t, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), PeriodDaily.Start(at))
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), PeriodWeekly.Start(at))
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), PeriodWeekly.Start(time.Date(2024, 1, 7, 23, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC), PeriodWeekly.End(PeriodWeekly.Start(at)))
+
+	_, err := ParsePeriod("monthly")
+	require.Error(t, err)
+}
+
+func testEvent(index uint, direction, status string, eth int64, at time.Time) *types.BridgeEvent {
+	amount := new(big.Int).Mul(big.NewInt(eth), big.NewInt(1e18))
+	value := float64(eth) * 3000
+
+	return &types.BridgeEvent{
+		Bridge:    types.BridgeStandard,
+		Direction: direction,
+		Status:    status,
+		TxHash:    common.BigToHash(big.NewInt(int64(index))),
+		LogIndex:  index,
+		From:      common.BigToAddress(big.NewInt(int64(index%2 + 1))),
+		To:        common.BigToAddress(big.NewInt(int64(index%2 + 1))),
+		Amount:    amount,
+		Decimals:  18,
+		Asset:     "ETH",
+		ValueUSD:  &value,
+		BlockTime: at,
+	}
+}
+
+func TestDigest_Flush(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)
+
+	redisServer := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+
+	cfg := Config{Periods: []Period{PeriodHourly, PeriodDaily}, Largest: 2}
+	d, err := New(ctx, cfg, NewRedisStore(redisClient, "sepolia"))
+	require.NoError(t, err)
+
+	reports, err := d.Flush(ctx, start)
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+
+	d.Add(testEvent(1, types.DirectionDeposit, types.StatusInitiated, 1, start.Add(time.Minute)))
+	d.Add(testEvent(2, types.DirectionDeposit, types.StatusInitiated, 5, start.Add(2*time.Minute)))
+	d.Add(testEvent(3, types.DirectionWithdrawal, types.StatusInitiated, 3, start.Add(3*time.Minute)))
+	// a re-delivered event is counted once
+	d.Add(testEvent(3, types.DirectionWithdrawal, types.StatusInitiated, 3, start.Add(3*time.Minute)))
+
+	// the finalization of the first deposit on L2
+	finalized := testEvent(1, types.DirectionDeposit, types.StatusFinalized, 1, start.Add(4*time.Minute))
+	finalized.LogIndex = 100
+	d.Add(finalized)
+
+	// the events are saved by the flush
+	assert.False(t, redisServer.Exists("digest:sepolia"))
+	reports, err = d.Flush(ctx, start.Add(5*time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+
+	// the state survives a restart
+	d, err = New(ctx, cfg, NewRedisStore(redisClient, "sepolia"))
+	require.NoError(t, err)
+
+	reports, err = d.Flush(ctx, start.Add(30*time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+
+	reports, err = d.Flush(ctx, start.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+
+	report := reports[0]
+	assert.Equal(t, PeriodHourly, report.Period)
+	assert.Equal(t, start, report.Start)
+	assert.Equal(t, 2, report.Deposits)
+	assert.Equal(t, 1, report.Withdrawals)
+	assert.Equal(t, 1, report.Finalized)
+	assert.Len(t, report.Addresses, 2)
+
+	volumes := report.SortedVolumes()
+	require.Len(t, volumes, 2)
+	assert.Equal(t, "deposit", volumes[0].Direction)
+	assert.Equal(t, 2, volumes[0].Count)
+	assert.Equal(t, 18000.0, volumes[0].ValueUSD)
+	assert.Equal(t, "6000000000000000000", volumes[0].Amount.String())
+
+	require.Len(t, report.Largest, 2)
+	assert.Equal(t, 15000.0, *report.Largest[0].ValueUSD)
+	assert.Equal(t, 9000.0, *report.Largest[1].ValueUSD)
+
+	// the second deposit is stuck after an hour, the withdrawal is pending
+	assert.Equal(t, 2, report.Pending)
+	require.Len(t, report.Stuck, 1)
+	assert.Equal(t, "deposit", report.Stuck[0].Direction)
+
+	reports, err = d.Flush(ctx, start.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	assert.Equal(t, PeriodDaily, reports[1].Period)
+	assert.Equal(t, 2, reports[1].Deposits)
+	assert.Zero(t, reports[0].Deposits)
+}
+
+func TestDigest_Stuck(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)
+
+	redisServer := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+
+	cfg := Config{Periods: []Period{PeriodDaily}}
+	d, err := New(ctx, cfg, NewRedisStore(redisClient, "sepolia"))
+	require.NoError(t, err)
+
+	deposit := testEvent(1, types.DirectionDeposit, types.StatusInitiated, 1, start)
+	d.Add(deposit)
+	d.Add(testEvent(2, types.DirectionWithdrawal, types.StatusInitiated, 2, start))
+
+	stuck, _, err := d.Stuck(ctx, start.Add(30*time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, stuck)
+
+	stuck, _, err = d.Stuck(ctx, start.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, stuck, 1)
+	assert.Equal(t, deposit.TransferKey(), stuck[0].TransferKey)
+
+	// the stuck transfers are returned once, across restarts
+	d, err = New(ctx, cfg, NewRedisStore(redisClient, "sepolia"))
+	require.NoError(t, err)
+
+	stuck, _, err = d.Stuck(ctx, start.Add(3*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, stuck)
+
+	// the finalization of the stuck deposit recovers it
+	finalized := testEvent(1, types.DirectionDeposit, types.StatusFinalized, 1, start.Add(4*time.Hour))
+	finalized.LogIndex = 100
+	d.Add(finalized)
+
+	stuck, recovered, err := d.Stuck(ctx, start.Add(9*24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, stuck, 1)
+	assert.Equal(t, "withdrawal", stuck[0].Direction)
+	require.Len(t, recovered, 1)
+	assert.Equal(t, deposit.TransferKey(), recovered[0].TransferKey)
+
+	_, recovered, err = d.Stuck(ctx, start.Add(9*24*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, recovered)
+}
+
+func TestDigest_PairTransfers(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)
+
+	d, err := New(ctx, Config{Periods: []Period{PeriodDaily}}, nil)
+	require.NoError(t, err)
+
+	deposit := testEvent(1, types.DirectionDeposit, types.StatusInitiated, 1, start)
+	deposit.L2ChainID = 111551
+	d.Add(deposit)
+
+	// the same amount of another token or another rollup isn't the finalization
+	otherToken := testEvent(1, types.DirectionDeposit, types.StatusFinalized, 1, start.Add(time.Minute))
+	otherToken.L2ChainID = 111551
+	otherToken.L1Token = common.HexToAddress("0xa1")
+	otherRollup := testEvent(1, types.DirectionDeposit, types.StatusFinalized, 1, start.Add(time.Minute))
+	otherRollup.L2ChainID = 111552
+	d.Add(otherToken)
+	d.Add(otherRollup)
+
+	stuck, _, err := d.Stuck(ctx, start.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, stuck, 1)
+	assert.Equal(t, deposit.TransferKey(), stuck[0].TransferKey)
+}
+
+func TestDigest_ExpirePending(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)
+
+	d, err := New(ctx, Config{Periods: []Period{PeriodDaily}, MaxPendingAge: 48 * time.Hour}, nil)
+	require.NoError(t, err)
+
+	d.Add(testEvent(1, types.DirectionWithdrawal, types.StatusInitiated, 1, start))
+	d.Add(testEvent(2, types.DirectionWithdrawal, types.StatusInitiated, 2, start.Add(24*time.Hour)))
+
+	reports, err := d.Flush(ctx, start.Add(49*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, 2, reports[0].Pending)
+
+	// the first withdrawal is no longer tracked after the flush
+	stuck, _, err := d.Stuck(ctx, start.Add(8*24*time.Hour+time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, stuck)
+	assert.Len(t, d.state.Pending, 1)
+}
@@ -0,0 +1,53 @@
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	digestKey = "digest"
+)
+
+// RedisStore keeps the state of the digests of a network in Redis.
+type RedisStore struct {
+	redisClient redis.UniversalClient
+	key         string
+}
+
+func NewRedisStore(redisClient redis.UniversalClient, network string) *RedisStore {
+	return &RedisStore{
+		redisClient: redisClient,
+		key:         fmt.Sprintf("%s:%s", digestKey, network),
+	}
+}
+
+func (s *RedisStore) Load(ctx context.Context) (*State, error) {
+	result, err := s.redisClient.Get(ctx, s.key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var state State
+	if err := json.Unmarshal(result, &state); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func (s *RedisStore) Save(ctx context.Context, state *State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return s.redisClient.Set(ctx, s.key, data, 0).Err()
+}
@@ -1,35 +1,96 @@
 package erc20
 
 import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/bcclient"
 
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/bcclient"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
 )
 
 func FetchTokenInfo(bcClient *bcclient.Client, tokenAddress string) (*types.Token, error) {
-	erc20Instance, err := NewErc20(common.HexToAddress(tokenAddress), bcClient.GetClient())
+	token, err := FetchToken(context.Background(), bcClient.GetClient(), common.HexToAddress(tokenAddress))
+	if err != nil {
+		return nil, err
+	}
+	token.Address = tokenAddress
+
+	return token, nil
+}
+
+// FetchToken reads the symbol and the decimals of the token. The symbol of
+// the tokens predating the ERC-20 standard, such as MKR, is a bytes32.
+func FetchToken(ctx context.Context, caller ethereum.ContractCaller, address common.Address) (*types.Token, error) {
+	parsed, err := Erc20MetaData.GetAbi()
 	if err != nil {
-		log.GetLogger().Errorw("Failed to create erc20 instance", "error", err)
 		return nil, err
 	}
 
-	symbol, err := erc20Instance.Symbol(nil)
+	symbolData, err := call(ctx, caller, parsed, address, "symbol")
 	if err != nil {
-		log.GetLogger().Errorw("Failed to get symbol", "error", err)
+		log.GetLogger().Errorw("Failed to get symbol", "error", err, "address", address)
 		return nil, err
 	}
 
-	decimals, err := erc20Instance.Decimals(nil)
+	symbol, err := decodeSymbol(parsed, symbolData)
 	if err != nil {
-		log.GetLogger().Errorw("Failed to get decimals", "error", err)
+		log.GetLogger().Errorw("Failed to decode symbol", "error", err, "address", address)
+		return nil, err
+	}
+
+	decimalsData, err := call(ctx, caller, parsed, address, "decimals")
+	if err != nil {
+		log.GetLogger().Errorw("Failed to get decimals", "error", err, "address", address)
+		return nil, err
+	}
+
+	var decimals uint8
+	if err := parsed.UnpackIntoInterface(&decimals, "decimals", decimalsData); err != nil {
+		log.GetLogger().Errorw("Failed to decode decimals", "error", err, "address", address)
 		return nil, err
 	}
 
 	return &types.Token{
 		Decimals: int(decimals),
 		Symbol:   symbol,
-		Address:  tokenAddress,
+		Address:  address.Hex(),
 	}, nil
 }
+
+func call(ctx context.Context, caller ethereum.ContractCaller, parsed *abi.ABI, address common.Address, method string) ([]byte, error) {
+	input, err := parsed.Pack(method)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := caller.CallContract(ctx, ethereum.CallMsg{To: &address, Data: input}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(output) == 0 {
+		return nil, errors.New("empty result, not a token contract")
+	}
+
+	return output, nil
+}
+
+func decodeSymbol(parsed *abi.ABI, data []byte) (string, error) {
+	// a string result is at least an offset and a length
+	if len(data) == 32 {
+		return strings.TrimRight(string(data), "\x00"), nil
+	}
+
+	var symbol string
+	if err := parsed.UnpackIntoInterface(&symbol, "symbol", data); err != nil {
+		return "", err
+	}
+
+	return symbol, nil
+}
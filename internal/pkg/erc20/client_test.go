@@ -59,3 +59,26 @@ func Test_FetchTokenInfo(t *testing.T) {
 	}
 
 }
+
+func Test_decodeSymbol(t *testing.T) {
+	parsed, err := Erc20MetaData.GetAbi()
+	require.NoError(t, err)
+
+	// MKR returns its symbol as a bytes32
+	mkr := make([]byte, 32)
+	copy(mkr, "MKR")
+
+	symbol, err := decodeSymbol(parsed, mkr)
+	require.NoError(t, err)
+	assert.Equal(t, "MKR", symbol)
+
+	data, err := parsed.Methods["symbol"].Outputs.Pack("TON")
+	require.NoError(t, err)
+
+	symbol, err = decodeSymbol(parsed, data)
+	require.NoError(t, err)
+	assert.Equal(t, "TON", symbol)
+
+	_, err = decodeSymbol(parsed, data[:40])
+	require.Error(t, err)
+}
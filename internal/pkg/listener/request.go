@@ -4,20 +4,24 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
 )
 
 type Notifier interface {
-	NotifyWithReTry(title string, text string)
-	Notify(title string, text string) error
+	Notify(msg *notification.Message) error
 	Enable()
 	Disable()
 }
+
+// EventHandler decodes the log into a message. A nil message is not notified.
+type EventHandler func(vLog *types.Log) (*notification.Message, error)
+
 type EventRequest struct {
 	contractAddress common.Address
 	eventABI        string
 
-	handler  func(vLog *types.Log) (string, string, error)
+	handler  EventHandler
 	notifier Notifier
 }
 
@@ -32,13 +36,17 @@ func (r *EventRequest) GetRequestType() int {
 
 func (r *EventRequest) Callback(v any) {
 	if v, ok := v.(*types.Log); ok {
-		title, text, err := r.handler(v)
+		msg, err := r.handler(v)
 		if err != nil {
 			log.GetLogger().Errorw("Failed to handle event request", "err", err, "log", v)
 			return
 		}
 
-		err = r.notifier.Notify(title, text)
+		if msg == nil {
+			return
+		}
+
+		err = r.notifier.Notify(msg)
 		if err != nil {
 			log.GetLogger().Errorw("Failed to notify event request", "err", err, "log", v)
 			return
@@ -46,7 +54,7 @@ func (r *EventRequest) Callback(v any) {
 	}
 }
 
-func MakeEventRequest(notifier Notifier, addr string, eventABI string, handler func(vLog *types.Log) (string, string, error)) *EventRequest {
+func MakeEventRequest(notifier Notifier, addr string, eventABI string, handler EventHandler) *EventRequest {
 	address := common.HexToAddress(addr)
 	return &EventRequest{
 		contractAddress: address,
@@ -55,3 +63,41 @@ func MakeEventRequest(notifier Notifier, addr string, eventABI string, handler f
 		notifier:        notifier,
 	}
 }
+
+// Transaction is a transaction of a block, passed to the TransactionRequest
+// callbacks.
+type Transaction struct {
+	Tx     *types.Transaction
+	Header *types.Header
+}
+
+// TransactionHandler handles a transaction sent to the address of the request.
+type TransactionHandler func(tx *Transaction)
+
+// TransactionRequest subscribes to the transactions sent to an address, such
+// as the batch inbox, which emit no log.
+type TransactionRequest struct {
+	to      common.Address
+	handler TransactionHandler
+}
+
+func (r *TransactionRequest) SerializeEventRequest() string {
+	return serializeTransactionRequest(r.to)
+}
+
+func (r *TransactionRequest) GetRequestType() int {
+	return RequestTransactionType
+}
+
+func (r *TransactionRequest) Callback(v any) {
+	if v, ok := v.(*Transaction); ok {
+		r.handler(v)
+	}
+}
+
+func MakeTransactionRequest(to string, handler TransactionHandler) *TransactionRequest {
+	return &TransactionRequest{
+		to:      common.HexToAddress(to),
+		handler: handler,
+	}
+}
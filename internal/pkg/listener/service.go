@@ -6,12 +6,16 @@ import (
 	"encoding/gob"
 	"fmt"
 	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/constant"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
 	"go.uber.org/zap"
@@ -19,7 +23,8 @@ import (
 )
 
 var (
-	RequestEventType = 1
+	RequestEventType       = 1
+	RequestTransactionType = 2
 )
 
 const (
@@ -45,37 +50,107 @@ type BlockChainSource interface {
 	BlockNumber(ctx context.Context) (uint64, error)
 	GetLogs(ctx context.Context, blockHash common.Hash) ([]ethereumTypes.Log, error)
 	GetBlocks(ctx context.Context, withLogs bool, fromBlock, toBlock uint64) ([]*types.NewBlock, error)
+	GetTransactions(ctx context.Context, blockHash common.Hash) (ethereumTypes.Transactions, error)
 }
 
+// ReorgHandler is called with the hash of a block which has been replaced by
+// newHeader, before the logs of the new block are processed.
+type ReorgHandler func(ctx context.Context, removedBlockHash common.Hash, newHeader *ethereumTypes.Header)
+
+// SyncedHandler is called when the blocks missed since the last run have been
+// processed, before the new heads are subscribed.
+type SyncedHandler func(ctx context.Context)
+
+// HeadHandler is called with every new head of the subscription, before its
+// logs are processed.
+type HeadHandler func(ctx context.Context, header *ethereumTypes.Header)
+
+// Monitor observes the heads and the failures of the listener.
+type Monitor interface {
+	// HeadReceived is called with every new head of the subscription.
+	HeadReceived(header *ethereumTypes.Header)
+	// HeadStored is called with the result of saving a processed head.
+	HeadStored(header *ethereumTypes.Header, err error)
+	// RPCFailed is called with the failed calls to the chain.
+	RPCFailed(err error)
+}
+
+type nopMonitor struct{}
+
+func (nopMonitor) HeadReceived(*ethereumTypes.Header) {}
+
+func (nopMonitor) HeadStored(*ethereumTypes.Header, error) {}
+
+func (nopMonitor) RPCFailed(error) {}
+
 type EventService struct {
-	l           *zap.SugaredLogger
-	bcClient    BlockChainSource
-	blockKeeper BlockKeeper
-	requestMap  map[string]RequestSubscriber
-	filter      *CounterBloom
-	sub         ethereum.Subscription
+	l              *zap.SugaredLogger
+	bcClient       BlockChainSource
+	blockKeeper    BlockKeeper
+	requestsMu     sync.RWMutex
+	requestMap     map[string]RequestSubscriber
+	reorgHandlers  []ReorgHandler
+	syncedHandlers []SyncedHandler
+	headHandlers   []HeadHandler
+	retryThreshold uint64
+	monitor        Monitor
+	filter         *CounterBloom
+	sub            ethereum.Subscription
+	// processMu serializes the processing of the blocks with the backfills.
+	processMu sync.Mutex
+	// backfilling is set while the logs of a backfill are processed.
+	backfilling atomic.Bool
+	resets      chan *headReset
+}
+
+// headReset asks the running listener to move its head to a block.
+type headReset struct {
+	number uint64
+	done   chan error
 }
 
 func MakeService(name string, bcClient BlockChainSource, keeper BlockKeeper) (*EventService, error) {
 	service := &EventService{
-		l:           log.GetLogger().Named(name),
-		bcClient:    bcClient,
-		blockKeeper: keeper,
-		filter:      MakeDefaultCounterBloom(),
-		requestMap:  make(map[string]RequestSubscriber),
+		l:              log.GetLogger().Named(name),
+		bcClient:       bcClient,
+		blockKeeper:    keeper,
+		filter:         MakeDefaultCounterBloom(),
+		requestMap:     make(map[string]RequestSubscriber),
+		retryThreshold: defaultRetryThreshold,
+		monitor:        nopMonitor{},
+		resets:         make(chan *headReset),
 	}
 
 	return service, nil
 }
 
-func (s *EventService) existRequest(request RequestSubscriber) bool {
-	key := request.SerializeEventRequest()
-	_, ok := s.requestMap[key]
-	return ok
+// requests returns the current subscriptions. The returned map is never
+// modified, the subscriptions are replaced by a new map instead.
+func (s *EventService) requests() map[string]RequestSubscriber {
+	s.requestsMu.RLock()
+	defer s.requestsMu.RUnlock()
+
+	return s.requestMap
+}
+
+// Requests returns the subscribed requests sorted by key.
+func (s *EventService) Requests() []RequestSubscriber {
+	requestMap := s.requests()
+
+	requests := make([]RequestSubscriber, 0, len(requestMap))
+	for _, request := range requestMap {
+		requests = append(requests, request)
+	}
+
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].SerializeEventRequest() < requests[j].SerializeEventRequest()
+	})
+
+	return requests
 }
 
 func (s *EventService) RequestByKey(key string) RequestSubscriber {
-	request, ok := s.requestMap[key]
+	request, ok := s.requests()[key]
 	if ok {
 		return request
 	}
@@ -83,11 +158,67 @@ func (s *EventService) RequestByKey(key string) RequestSubscriber {
 }
 
 func (s *EventService) AddSubscribeRequest(request RequestSubscriber) {
-	if s.existRequest(request) {
+	s.requestsMu.Lock()
+	defer s.requestsMu.Unlock()
+
+	key := request.SerializeEventRequest()
+	if _, ok := s.requestMap[key]; ok {
 		return
 	}
-	key := request.SerializeEventRequest()
-	s.requestMap[key] = request
+
+	requestMap := make(map[string]RequestSubscriber, len(s.requestMap)+1)
+	for k, v := range s.requestMap {
+		requestMap[k] = v
+	}
+	requestMap[key] = request
+
+	s.requestMap = requestMap
+}
+
+// SetSubscribeRequests replaces all the subscriptions at once. A block being
+// processed keeps the subscriptions it started with.
+func (s *EventService) SetSubscribeRequests(requests []RequestSubscriber) {
+	requestMap := make(map[string]RequestSubscriber, len(requests))
+	for _, request := range requests {
+		key := request.SerializeEventRequest()
+		if _, ok := requestMap[key]; ok {
+			continue
+		}
+		requestMap[key] = request
+	}
+
+	s.requestsMu.Lock()
+	s.requestMap = requestMap
+	s.requestsMu.Unlock()
+}
+
+func (s *EventService) AddReorgHandler(handler ReorgHandler) {
+	s.reorgHandlers = append(s.reorgHandlers, handler)
+}
+
+func (s *EventService) AddSyncedHandler(handler SyncedHandler) {
+	s.syncedHandlers = append(s.syncedHandlers, handler)
+}
+
+func (s *EventService) AddHeadHandler(handler HeadHandler) {
+	s.headHandlers = append(s.headHandlers, handler)
+}
+
+// SetRetryThreshold sets the number of consecutive failed re-subscriptions
+// tolerated before Start gives up and returns an error.
+func (s *EventService) SetRetryThreshold(threshold uint64) {
+	if threshold == 0 {
+		threshold = defaultRetryThreshold
+	}
+	s.retryThreshold = threshold
+}
+
+// SetMonitor reports the heads and the failures of the listener to monitor.
+func (s *EventService) SetMonitor(monitor Monitor) {
+	if monitor == nil {
+		monitor = nopMonitor{}
+	}
+	s.monitor = monitor
 }
 
 func (s *EventService) CanProcess(log *ethereumTypes.Log) bool {
@@ -108,7 +239,27 @@ func (s *EventService) CanProcess(log *ethereumTypes.Log) bool {
 	return true
 }
 
+// Start syncs the blocks since the stored head, then processes the new heads
+// until ctx is done. A head reset stops the subscription and syncs again from
+// the new head.
 func (s *EventService) Start(ctx context.Context) error {
+	for {
+		reset, err := s.listen(ctx)
+		if reset == nil {
+			return err
+		}
+
+		err = s.resetHead(ctx, reset.number)
+		if err != nil {
+			s.l.Errorw("Failed to reset the head", "err", err, "number", reset.number)
+		}
+		reset.done <- err
+	}
+}
+
+// listen runs the listener until ctx is done, it fails or a head reset is
+// requested, which is returned once the subscription is stopped.
+func (s *EventService) listen(ctx context.Context) (*headReset, error) {
 	oldBlocksCh := make(chan *types.NewBlock)
 
 	errCh := make(chan error, 1)
@@ -132,49 +283,169 @@ func (s *EventService) Start(ctx context.Context) error {
 		err := s.handleNewBlock(ctx, oldBlock)
 		if err != nil {
 			s.l.Errorw("Failed to handle the old block", "err", err)
-			return err
+			return nil, err
 		}
 	}
 
 	if err := g.Wait(); err != nil {
 		s.l.Errorw("Failed to sync old blocks", "err", err)
-		return err
+		return nil, err
+	}
+
+	for _, handler := range s.syncedHandlers {
+		handler(ctx)
+	}
+
+	// fail reports the first error stopping the listener
+	fail := func(err error) {
+		select {
+		case errCh <- err:
+		default:
+		}
 	}
 
 	retried := uint64(0)
-	s.sub = event.ResubscribeErr(5*time.Second, func(ctx context.Context, err error) (event.Subscription, error) {
+	sub := event.ResubscribeErr(5*time.Second, func(ctx context.Context, err error) (event.Subscription, error) {
 		if err != nil {
 			retried++
 			s.l.Errorw("Failed to re-subscribe the event", "err", err)
+			s.monitor.RPCFailed(err)
 
-			if retried >= defaultRetryThreshold {
-				s.l.Fatalw("Can't connect subscription", "err", err)
+			if retried >= s.retryThreshold {
+				fail(fmt.Errorf("can't connect subscription after %d retries: %w", retried, err))
+				return nil, err
 			}
 			time.Sleep(5 * time.Second)
 		}
 
-		return s.subscribeNewHead(ctx)
+		newSub, err := s.subscribeNewHead(ctx)
+		if err == nil {
+			retried = 0
+		}
+
+		return newSub, err
 	})
+	defer sub.Unsubscribe()
+	s.sub = sub
 
 	go func() {
-		err, ok := <-s.sub.Err()
+		err, ok := <-sub.Err()
 		if !ok {
 			return
 		}
 		s.l.Errorw("Failed to subscribe new head", "err", err)
 
-		errCh <- err
+		fail(err)
 	}()
 
 	for {
 		select {
 		case <-ctx.Done():
-			return nil
+			return nil, nil
 		case err := <-errCh:
 			s.l.Errorw("Failed to re-subscribe the event", "err", err)
+			return nil, err
+		case reset := <-s.resets:
+			return reset, nil
+		}
+	}
+}
+
+// ResetHead moves the head of the running listener to the block number and
+// processes the following blocks again, the events already notified by the
+// process being skipped. It waits for the blocks being synced at startup.
+func (s *EventService) ResetHead(ctx context.Context, number uint64) error {
+	reset := &headReset{number: number, done: make(chan error, 1)}
+
+	select {
+	case s.resets <- reset:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-reset.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *EventService) resetHead(ctx context.Context, number uint64) error {
+	blocks, err := s.bcClient.GetBlocks(ctx, false, number, number)
+	if err != nil {
+		s.monitor.RPCFailed(err)
+		return err
+	}
+
+	if len(blocks) == 0 {
+		return fmt.Errorf("block not found: %d", number)
+	}
+
+	s.l.Infow("Reset the head", "number", number)
+
+	return s.blockKeeper.SetHead(ctx, blocks[0].Header, constant.ZeroHash)
+}
+
+// Backfill processes the blocks from fromBlock to toBlock again for the
+// current subscriptions without moving the head. Unlike the synced blocks,
+// the events already processed are notified again.
+func (s *EventService) Backfill(ctx context.Context, fromBlock, toBlock uint64) error {
+	if fromBlock > toBlock {
+		return fmt.Errorf("invalid block range: %d-%d", fromBlock, toBlock)
+	}
+
+	s.l.Infow("Backfill blocks", "from", fromBlock, "to", toBlock)
+
+	for from := fromBlock; from <= toBlock; from += MaxBatchBlocksSize {
+		to := min(from+MaxBatchBlocksSize-1, toBlock)
+
+		blocks, err := s.bcClient.GetBlocks(ctx, true, from, to)
+		if err != nil {
+			s.monitor.RPCFailed(err)
 			return err
 		}
+
+		for _, block := range blocks {
+			if err := s.backfillBlock(ctx, block); err != nil {
+				s.l.Errorw("Failed to backfill the block", "err", err, "number", block.Header.Number)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Backfilling reports whether the logs being processed are backfilled, for the
+// request handlers.
+func (s *EventService) Backfilling() bool {
+	return s.backfilling.Load()
+}
+
+func (s *EventService) backfillBlock(ctx context.Context, block *types.NewBlock) error {
+	s.processMu.Lock()
+	defer s.processMu.Unlock()
+
+	s.backfilling.Store(true)
+	defer s.backfilling.Store(false)
+
+	requests := s.requests()
+	for i := range block.Logs {
+		l := &block.Logs[i]
+		if len(l.Topics) == 0 || l.Removed {
+			continue
+		}
+
+		request, ok := requests[serializeEventRequestWithAddressAndABI(l.Address, l.Topics[0])]
+		if !ok {
+			continue
+		}
+
+		request.Callback(l)
 	}
+
+	return s.filterTransactionsAndNotify(ctx, block.Header)
 }
 
 func (s *EventService) subscribeNewHead(
@@ -186,6 +457,7 @@ func (s *EventService) subscribeNewHead(
 
 	sub, err := s.bcClient.SubscribeNewHead(ctx, headChanges)
 	if err != nil {
+		s.monitor.RPCFailed(err)
 		return nil, err
 	}
 	s.l.Infow("Start process new head")
@@ -208,10 +480,15 @@ func (s *EventService) subscribeNewHead(
 			select {
 			case newHead := <-headChanges:
 				s.l.Infow("New head received", "header", newHead.Number)
+				s.monitor.HeadReceived(newHead)
+				for _, handler := range s.headHandlers {
+					handler(ctx, newHead)
+				}
 
 				logs, err := s.bcClient.GetLogs(ctx, newHead.Hash())
 				if err != nil {
 					s.l.Errorw("Failed to filter logs", "err", err)
+					s.monitor.RPCFailed(err)
 					return err
 				}
 
@@ -241,6 +518,9 @@ func (s *EventService) handleNewBlock(ctx context.Context, newBlock *types.NewBl
 		return nil
 	}
 
+	s.processMu.Lock()
+	defer s.processMu.Unlock()
+
 	newHeader := newBlock.Header
 	reorgedBlocks, err := s.handleReorgBlocks(ctx, newHeader)
 	if err != nil {
@@ -256,13 +536,27 @@ func (s *EventService) handleNewBlock(ctx context.Context, newBlock *types.NewBl
 	blocks = append(blocks, newBlock)
 
 	for _, block := range blocks {
+		if block.ReorgedBlockHash.Cmp(constant.ZeroHash) != 0 {
+			s.l.Infow("Block re-organized", "removed", block.ReorgedBlockHash, "new", block.Header.Hash(), "number", block.Header.Number)
+			for _, handler := range s.reorgHandlers {
+				handler(ctx, block.ReorgedBlockHash, block.Header)
+			}
+		}
+
 		err = s.filterEventsAndNotify(ctx, block.Logs)
 		if err != nil {
 			s.l.Errorw("Failed to handle block", "err", err, "block", block)
 			return err
 		}
 
+		err = s.filterTransactionsAndNotify(ctx, block.Header)
+		if err != nil {
+			s.l.Errorw("Failed to handle the block transactions", "err", err, "block", block)
+			return err
+		}
+
 		err = s.blockKeeper.SetHead(ctx, block.Header, block.ReorgedBlockHash)
+		s.monitor.HeadStored(block.Header, err)
 		if err != nil {
 			s.l.Errorw("Failed to set head on the keeper", "err", err, "block", block)
 			return err
@@ -273,15 +567,17 @@ func (s *EventService) handleNewBlock(ctx context.Context, newBlock *types.NewBl
 }
 
 func (s *EventService) filterEventsAndNotify(_ context.Context, logs []ethereumTypes.Log) error {
+	requests := s.requests()
+
 	for _, l := range logs {
 		if len(l.Topics) == 0 {
 			continue
 		}
 
 		key := serializeEventRequestWithAddressAndABI(l.Address, l.Topics[0])
-		request := s.RequestByKey(key)
+		request, ok := requests[key]
 
-		if request == nil {
+		if !ok {
 			continue
 		}
 
@@ -298,9 +594,50 @@ func (s *EventService) filterEventsAndNotify(_ context.Context, logs []ethereumT
 	return nil
 }
 
+// filterTransactionsAndNotify passes the transactions of the block to the
+// requests of their recipient. The transactions are only fetched when such a
+// request is subscribed.
+func (s *EventService) filterTransactionsAndNotify(ctx context.Context, header *ethereumTypes.Header) error {
+	requests := s.requests()
+
+	subscribed := false
+	for _, request := range requests {
+		if request.GetRequestType() == RequestTransactionType {
+			subscribed = true
+			break
+		}
+	}
+
+	if !subscribed {
+		return nil
+	}
+
+	txs, err := s.bcClient.GetTransactions(ctx, header.Hash())
+	if err != nil {
+		s.monitor.RPCFailed(err)
+		return err
+	}
+
+	for _, tx := range txs {
+		if tx.To() == nil {
+			continue
+		}
+
+		request, ok := requests[serializeTransactionRequest(*tx.To())]
+		if !ok {
+			continue
+		}
+
+		request.Callback(&Transaction{Tx: tx, Header: header})
+	}
+
+	return nil
+}
+
 func (s *EventService) syncOldBlocks(ctx context.Context, headCh chan *types.NewBlock) error {
 	onchainBlockNo, err := s.bcClient.BlockNumber(ctx)
 	if err != nil {
+		s.monitor.RPCFailed(err)
 		return err
 	}
 
@@ -338,6 +675,7 @@ func (s *EventService) syncOldBlocks(ctx context.Context, headCh chan *types.New
 
 		blocks, err := s.bcClient.GetBlocks(ctx, true, fromBlock, toBlock)
 		if err != nil {
+			s.monitor.RPCFailed(err)
 			return err
 		}
 
@@ -381,6 +719,7 @@ func (s *EventService) handleReorgBlocks(ctx context.Context, newHeader *ethereu
 
 		blocks, err := s.bcClient.GetBlocks(ctx, true, fromBlock, toBlock)
 		if err != nil {
+			s.monitor.RPCFailed(err)
 			return nil, err
 		}
 
@@ -401,6 +740,10 @@ func serializeEventRequestWithAddressAndABI(address common.Address, hashedABI co
 	return result
 }
 
+func serializeTransactionRequest(to common.Address) string {
+	return fmt.Sprintf("tx:%s", to.String())
+}
+
 func calculateBatchBlocks(blocks int) int {
 	return int(math.Ceil(float64(blocks) / float64(MaxBatchBlocksSize)))
 }
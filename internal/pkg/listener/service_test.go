@@ -2,13 +2,19 @@ package listener
 
 import (
 	"context"
+	"errors"
+	"math/big"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/bcclient"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/constant"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/notification"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/repository"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/testutil"
 	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
@@ -99,3 +105,249 @@ func Test_handleReorgBlock(t *testing.T) {
 	assert.Equal(t, true, len(blocks) > 0)
 
 }
+
+type testRequest struct {
+	key string
+}
+
+func (r *testRequest) GetRequestType() int {
+	return RequestEventType
+}
+
+func (r *testRequest) SerializeEventRequest() string {
+	return r.key
+}
+
+func (r *testRequest) Callback(any) {}
+
+func TestEventService_SetSubscribeRequests(t *testing.T) {
+	service, err := MakeService("test-event-listener", nil, nil)
+	require.NoError(t, err)
+
+	service.AddSubscribeRequest(&testRequest{key: "a"})
+	requests := service.requests()
+
+	service.SetSubscribeRequests([]RequestSubscriber{&testRequest{key: "b"}, &testRequest{key: "c"}})
+
+	assert.Nil(t, service.RequestByKey("a"))
+	assert.NotNil(t, service.RequestByKey("b"))
+	assert.NotNil(t, service.RequestByKey("c"))
+
+	// a block being processed keeps its subscriptions
+	assert.Len(t, requests, 1)
+	assert.Contains(t, requests, "a")
+
+	service.AddSubscribeRequest(&testRequest{key: "d"})
+	assert.Len(t, service.requests(), 3)
+}
+
+// testTransactionSource returns the transactions of any block.
+type testTransactionSource struct {
+	BlockChainSource
+	txs   ethereumTypes.Transactions
+	calls int
+}
+
+func (s *testTransactionSource) GetTransactions(context.Context, common.Hash) (ethereumTypes.Transactions, error) {
+	s.calls++
+	return s.txs, nil
+}
+
+func TestEventService_filterTransactionsAndNotify(t *testing.T) {
+	inbox := common.HexToAddress("0xff00000000000000000000000000000000111551")
+	other := common.HexToAddress("0x01")
+	source := &testTransactionSource{}
+
+	service, err := MakeService("test-event-listener", source, nil)
+	require.NoError(t, err)
+
+	header := &ethereumTypes.Header{Number: big.NewInt(100)}
+
+	// the transactions aren't fetched without transaction request
+	service.SetSubscribeRequests([]RequestSubscriber{&testRequest{key: "a"}})
+	require.NoError(t, service.filterTransactionsAndNotify(context.Background(), header))
+	assert.Zero(t, source.calls)
+
+	var received []*Transaction
+	service.AddSubscribeRequest(MakeTransactionRequest(inbox.String(), func(tx *Transaction) {
+		received = append(received, tx)
+	}))
+	source.txs = ethereumTypes.Transactions{
+		ethereumTypes.NewTx(&ethereumTypes.LegacyTx{Nonce: 1, To: &inbox}),
+		ethereumTypes.NewTx(&ethereumTypes.LegacyTx{Nonce: 2, To: &other}),
+		ethereumTypes.NewTx(&ethereumTypes.LegacyTx{Nonce: 3}),
+	}
+	require.NoError(t, service.filterTransactionsAndNotify(context.Background(), header))
+
+	require.Len(t, received, 1)
+	assert.Equal(t, uint64(1), received[0].Tx.Nonce())
+	assert.Equal(t, header, received[0].Header)
+}
+
+// testBlockSource returns the blocks of its map.
+type testBlockSource struct {
+	BlockChainSource
+	blocks map[uint64]*types.NewBlock
+}
+
+func (s *testBlockSource) GetBlocks(_ context.Context, _ bool, fromBlock, toBlock uint64) ([]*types.NewBlock, error) {
+	var blocks []*types.NewBlock
+	for number := fromBlock; number <= toBlock; number++ {
+		if block, ok := s.blocks[number]; ok {
+			blocks = append(blocks, block)
+		}
+	}
+
+	return blocks, nil
+}
+
+// testKeeper records the heads set.
+type testKeeper struct {
+	BlockKeeper
+	heads []*ethereumTypes.Header
+}
+
+func (k *testKeeper) SetHead(_ context.Context, header *ethereumTypes.Header, _ common.Hash) error {
+	k.heads = append(k.heads, header)
+	return nil
+}
+
+func TestEventService_Requests(t *testing.T) {
+	service, err := MakeService("test-event-listener", nil, nil)
+	require.NoError(t, err)
+
+	service.SetSubscribeRequests([]RequestSubscriber{&testRequest{key: "c"}, &testRequest{key: "a"}, &testRequest{key: "b"}})
+
+	var keys []string
+	for _, request := range service.Requests() {
+		keys = append(keys, request.SerializeEventRequest())
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, keys)
+}
+
+func TestEventService_Backfill(t *testing.T) {
+	const eventABI = "OutputProposed(bytes32,uint256,uint256,uint256)"
+	oracle := common.HexToAddress("0xa3")
+	topic := crypto.Keccak256Hash([]byte(eventABI))
+
+	source := &testBlockSource{blocks: make(map[uint64]*types.NewBlock)}
+	for number := uint64(1); number <= 25; number++ {
+		source.blocks[number] = &types.NewBlock{
+			Header: &ethereumTypes.Header{Number: new(big.Int).SetUint64(number)},
+			Logs: []ethereumTypes.Log{
+				{Address: oracle, Topics: []common.Hash{topic}, BlockNumber: number},
+				{Address: common.HexToAddress("0x01"), Topics: []common.Hash{topic}, BlockNumber: number},
+			},
+		}
+	}
+	source.blocks[20].Logs[0].Removed = true
+
+	service, err := MakeService("test-event-listener", source, nil)
+	require.NoError(t, err)
+
+	var backfilled []uint64
+	service.AddSubscribeRequest(MakeEventRequest(nil, oracle.String(), eventABI, func(vLog *ethereumTypes.Log) (*notification.Message, error) {
+		assert.True(t, service.Backfilling())
+		backfilled = append(backfilled, vLog.BlockNumber)
+		return nil, nil
+	}))
+
+	// a processed log is notified again
+	assert.True(t, service.CanProcess(&source.blocks[5].Logs[0]))
+
+	require.NoError(t, service.Backfill(context.Background(), 5, 22))
+	assert.Equal(t, []uint64{5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 21, 22}, backfilled)
+	assert.False(t, service.Backfilling())
+
+	require.Error(t, service.Backfill(context.Background(), 10, 9))
+}
+
+func TestEventService_resetHead(t *testing.T) {
+	source := &testBlockSource{blocks: map[uint64]*types.NewBlock{
+		100: {Header: &ethereumTypes.Header{Number: big.NewInt(100)}},
+	}}
+	keeper := &testKeeper{}
+
+	service, err := MakeService("test-event-listener", source, keeper)
+	require.NoError(t, err)
+
+	require.NoError(t, service.resetHead(context.Background(), 100))
+	require.Len(t, keeper.heads, 1)
+	assert.Equal(t, big.NewInt(100), keeper.heads[0].Number)
+
+	require.EqualError(t, service.resetHead(context.Background(), 101), "block not found: 101")
+}
+
+func TestEventService_ResetHead(t *testing.T) {
+	service, err := MakeService("test-event-listener", nil, nil)
+	require.NoError(t, err)
+
+	// the reset waits for the listener to run
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, service.ResetHead(ctx, 100), context.DeadlineExceeded)
+}
+
+// testHeadSource is the chain of a block keeper, at the head block.
+type testHeadSource struct {
+	repository.BlockChainSource
+	head *ethereumTypes.Header
+}
+
+func (s *testHeadSource) GetHeader(context.Context) (*ethereumTypes.Header, error) {
+	return s.head, nil
+}
+
+// testMetadataKeeper stores the head, or fails with err.
+type testMetadataKeeper struct {
+	head string
+	err  error
+}
+
+func (k *testMetadataKeeper) GetHead(context.Context) (string, error) {
+	return k.head, nil
+}
+
+func (k *testMetadataKeeper) SetHead(_ context.Context, blockHash string) error {
+	if k.err != nil {
+		return k.err
+	}
+
+	k.head = blockHash
+	return nil
+}
+
+// testMonitor records the results of the stored heads.
+type testMonitor struct {
+	nopMonitor
+	stored []error
+}
+
+func (m *testMonitor) HeadStored(_ *ethereumTypes.Header, err error) {
+	m.stored = append(m.stored, err)
+}
+
+func TestEventService_handleNewBlock_HeadStoreFailure(t *testing.T) {
+	ctx := context.Background()
+	head := &ethereumTypes.Header{Number: big.NewInt(10)}
+	metadata := &testMetadataKeeper{}
+
+	keeper, err := repository.NewBlockKeeper(ctx, &testHeadSource{head: head}, metadata)
+	require.NoError(t, err)
+
+	service, err := MakeService("test-event-listener", &testBlockSource{}, keeper)
+	require.NoError(t, err)
+	monitor := &testMonitor{}
+	service.SetMonitor(monitor)
+
+	block := &ethereumTypes.Header{Number: big.NewInt(11), ParentHash: head.Hash()}
+	require.NoError(t, service.handleNewBlock(ctx, &types.NewBlock{Header: block}))
+	assert.Equal(t, block.Hash().String(), metadata.head)
+
+	// the failure to save the head is reported to the monitor and the listener
+	metadata.err = errors.New("connection refused")
+	next := &ethereumTypes.Header{Number: big.NewInt(12), ParentHash: block.Hash()}
+	require.ErrorIs(t, service.handleNewBlock(ctx, &types.NewBlock{Header: next}), metadata.err)
+	assert.Equal(t, []error{nil, metadata.err}, monitor.stored)
+	assert.Equal(t, block.Hash().String(), metadata.head)
+}
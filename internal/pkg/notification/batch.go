@@ -0,0 +1,150 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	DefaultBatchMax = 20
+)
+
+// Batcher coalesces the messages notified within a window into a single
+// message, so a burst of events makes a few requests. The critical messages,
+// and the ones which can't be merged, are sent at once.
+type Batcher struct {
+	sender Sender
+	window time.Duration
+	max    int
+
+	mu      sync.Mutex
+	pending []*Message
+	timer   *time.Timer
+}
+
+// NewBatcher sends the messages of every window to sender, at most max
+// messages at once.
+func NewBatcher(sender Sender, window time.Duration, max int) *Batcher {
+	if max < 1 {
+		max = DefaultBatchMax
+	}
+
+	return &Batcher{
+		sender: sender,
+		window: window,
+		max:    max,
+	}
+}
+
+// Notify queues msg until the end of the window, or sends the batch when it
+// is full. The errors of the batches sent at the end of the window are
+// logged.
+func (b *Batcher) Notify(msg *Message) error {
+	if !batchable(msg) {
+		return b.sender.Notify(msg)
+	}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, msg)
+	if len(b.pending) == 1 {
+		b.timer = time.AfterFunc(b.window, b.flushWindow)
+	}
+
+	if len(b.pending) < b.max {
+		b.mu.Unlock()
+		return nil
+	}
+
+	batch := b.take()
+	b.mu.Unlock()
+
+	return b.sender.Notify(mergeMessages(batch))
+}
+
+// Flush sends the queued messages now.
+func (b *Batcher) Flush() error {
+	b.mu.Lock()
+	batch := b.take()
+	b.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	return b.sender.Notify(mergeMessages(batch))
+}
+
+func (b *Batcher) flushWindow() {
+	if err := b.Flush(); err != nil {
+		log.GetLogger().Errorw("Failed to notify the batched messages", "error", err)
+	}
+}
+
+// take returns the queued messages and stops the window, b.mu held.
+func (b *Batcher) take() []*Message {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+
+	batch := b.pending
+	b.pending = nil
+
+	return batch
+}
+
+// batchable reports whether msg can be merged with other messages. The alerts
+// identified by a dedup key and the threaded messages keep their identity.
+func batchable(msg *Message) bool {
+	return !msg.Severity.AtLeast(SeverityCritical) && msg.DedupKey == "" && !msg.Resolved && msg.Thread == ""
+}
+
+// mergeMessages makes a message of the batch with its highest severity and
+// the attributes all of its messages have, mentioning the members when one of
+// the messages does.
+func mergeMessages(batch []*Message) *Message {
+	if len(batch) == 1 {
+		return batch[0]
+	}
+
+	merged := &Message{
+		Title:      fmt.Sprintf("%d notifications", len(batch)),
+		Severity:   SeverityInfo,
+		Attributes: make(map[string]string),
+	}
+
+	var text strings.Builder
+	for i, msg := range batch {
+		if i > 0 {
+			text.WriteString("\n\n")
+		}
+		fmt.Fprintf(&text, "*%s*\n%s", msg.Title, msg.Text)
+
+		if !merged.Severity.AtLeast(msg.Severity) {
+			merged.Severity = msg.Severity
+		}
+		if merged.Mention == "" {
+			merged.Mention = msg.Mention
+		}
+	}
+	merged.Text = text.String()
+
+	for key, value := range batch[0].Attributes {
+		common := true
+		for _, msg := range batch[1:] {
+			if msg.Attributes[key] != value {
+				common = false
+				break
+			}
+		}
+		if common {
+			merged.Attributes[key] = value
+		}
+	}
+
+	return merged
+}
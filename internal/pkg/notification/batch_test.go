@@ -0,0 +1,94 @@
+package notification
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type syncSender struct {
+	mu       sync.Mutex
+	messages []*Message
+	err      error
+	// release blocks the sends until it is closed, when set.
+	release chan struct{}
+}
+
+func (s *syncSender) Notify(msg *Message) error {
+	if s.release != nil {
+		<-s.release
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, msg)
+	return s.err
+}
+
+func (s *syncSender) sent() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*Message(nil), s.messages...)
+}
+
+func TestBatcher_Notify(t *testing.T) {
+	sender := &syncSender{}
+	batcher := NewBatcher(sender, 50*time.Millisecond, 3)
+
+	first := &Message{Title: "first", Text: "a", Severity: SeverityInfo, Attributes: map[string]string{"network": "sepolia", "layer": "l1"}, Mention: "<!here>"}
+	second := &Message{Title: "second", Text: "b", Severity: SeverityWarning, Attributes: map[string]string{"network": "sepolia", "layer": "l2"}}
+
+	require.NoError(t, batcher.Notify(first))
+	require.NoError(t, batcher.Notify(second))
+	assert.Empty(t, sender.sent())
+
+	// the critical messages are not delayed
+	critical := &Message{Title: "critical", Severity: SeverityCritical}
+	require.NoError(t, batcher.Notify(critical))
+	assert.Equal(t, []*Message{critical}, sender.sent())
+
+	require.Eventually(t, func() bool { return len(sender.sent()) == 2 }, time.Second, 10*time.Millisecond)
+
+	merged := sender.sent()[1]
+	assert.Equal(t, "2 notifications", merged.Title)
+	assert.Equal(t, "*first*\na\n\n*second*\nb", merged.Text)
+	assert.Equal(t, SeverityWarning, merged.Severity)
+	assert.Equal(t, map[string]string{"network": "sepolia"}, merged.Attributes)
+	assert.Equal(t, "<!here>", merged.Mention)
+
+	// a full batch is sent at once
+	for i := 0; i < 3; i++ {
+		require.NoError(t, batcher.Notify(&Message{Title: "burst"}))
+	}
+	assert.Len(t, sender.sent(), 3)
+	assert.Equal(t, "3 notifications", sender.sent()[2].Title)
+
+	// a single message is sent as is
+	single := &Message{Title: "single"}
+	require.NoError(t, batcher.Notify(single))
+	require.NoError(t, batcher.Flush())
+	assert.Same(t, single, sender.sent()[3])
+	require.NoError(t, batcher.Flush())
+	assert.Len(t, sender.sent(), 4)
+}
+
+func TestBatcher_NotifyAlerts(t *testing.T) {
+	sender := &syncSender{}
+	batcher := NewBatcher(sender, time.Hour, 10)
+
+	// the resolves and the threaded messages keep their dedup key and thread
+	trigger := &Message{Title: "Lag", Severity: SeverityWarning, DedupKey: "watchdog:lag:sepolia:l1"}
+	resolve := &Message{Title: "Lag Recovered", Severity: SeverityWarning, DedupKey: "watchdog:lag:sepolia:l1", Resolved: true}
+	reply := &Message{Title: "Deposit Finalized", Thread: "sepolia:transfer"}
+	require.NoError(t, batcher.Notify(&Message{Title: "deposit"}))
+	require.NoError(t, batcher.Notify(trigger))
+	require.NoError(t, batcher.Notify(resolve))
+	require.NoError(t, batcher.Notify(reply))
+
+	assert.Equal(t, []*Message{trigger, resolve, reply}, sender.sent())
+}
@@ -0,0 +1,192 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	DefaultDeadLetterSize = 1000
+
+	deadLetterKey = "dead_letters"
+
+	deadLetterTimeout = 5 * time.Second
+)
+
+// DeadLetter is a message a notifier failed to send, kept to be sent again.
+type DeadLetter struct {
+	Network  string    `json:"network"`
+	Notifier string    `json:"notifier"`
+	Message  *Message  `json:"message"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// DeadLetterStore keeps the last failed messages, the oldest first.
+type DeadLetterStore interface {
+	Add(ctx context.Context, letter *DeadLetter) error
+	List(ctx context.Context) ([]*DeadLetter, error)
+	// Take returns the letters and removes them from the store.
+	Take(ctx context.Context) ([]*DeadLetter, error)
+}
+
+// DeadLetterSender keeps the messages its sender failed to send in the store.
+type DeadLetterSender struct {
+	network  string
+	notifier string
+	sender   Sender
+	store    DeadLetterStore
+}
+
+func NewDeadLetterSender(network, notifier string, sender Sender, store DeadLetterStore) *DeadLetterSender {
+	return &DeadLetterSender{
+		network:  network,
+		notifier: notifier,
+		sender:   sender,
+		store:    store,
+	}
+}
+
+func (s *DeadLetterSender) Notify(msg *Message) error {
+	err := s.sender.Notify(msg)
+	if err == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deadLetterTimeout)
+	defer cancel()
+
+	letter := &DeadLetter{
+		Network:  s.network,
+		Notifier: s.notifier,
+		Message:  msg,
+		Error:    err.Error(),
+		FailedAt: time.Now().UTC(),
+	}
+	if storeErr := s.store.Add(ctx, letter); storeErr != nil {
+		log.GetLogger().Errorw("Failed to keep the failed notification", "error", storeErr, "notifier", s.notifier, "title", msg.Title)
+	}
+
+	return err
+}
+
+// MemoryDeadLetterStore keeps the last size letters in memory.
+type MemoryDeadLetterStore struct {
+	mu      sync.Mutex
+	size    int
+	letters []*DeadLetter
+}
+
+func NewMemoryDeadLetterStore(size int) *MemoryDeadLetterStore {
+	if size < 1 {
+		size = DefaultDeadLetterSize
+	}
+
+	return &MemoryDeadLetterStore{size: size}
+}
+
+func (s *MemoryDeadLetterStore) Add(_ context.Context, letter *DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.letters = append(s.letters, letter)
+	if len(s.letters) > s.size {
+		s.letters = s.letters[len(s.letters)-s.size:]
+	}
+
+	return nil
+}
+
+func (s *MemoryDeadLetterStore) List(_ context.Context) ([]*DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*DeadLetter(nil), s.letters...), nil
+}
+
+func (s *MemoryDeadLetterStore) Take(_ context.Context) ([]*DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	letters := s.letters
+	s.letters = nil
+
+	return letters, nil
+}
+
+// RedisDeadLetterStore keeps the last size letters in a Redis list, across
+// restarts.
+type RedisDeadLetterStore struct {
+	redisClient redis.UniversalClient
+	size        int
+}
+
+func NewRedisDeadLetterStore(redisClient redis.UniversalClient, size int) *RedisDeadLetterStore {
+	if size < 1 {
+		size = DefaultDeadLetterSize
+	}
+
+	return &RedisDeadLetterStore{
+		redisClient: redisClient,
+		size:        size,
+	}
+}
+
+func (s *RedisDeadLetterStore) Add(ctx context.Context, letter *DeadLetter) error {
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, deadLetterKey, data)
+		pipe.LTrim(ctx, deadLetterKey, int64(-s.size), -1)
+		return nil
+	})
+
+	return err
+}
+
+func (s *RedisDeadLetterStore) List(ctx context.Context) ([]*DeadLetter, error) {
+	values, err := s.redisClient.LRange(ctx, deadLetterKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeDeadLetters(values), nil
+}
+
+func (s *RedisDeadLetterStore) Take(ctx context.Context) ([]*DeadLetter, error) {
+	var values *redis.StringSliceCmd
+	_, err := s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		values = pipe.LRange(ctx, deadLetterKey, 0, -1)
+		pipe.Del(ctx, deadLetterKey)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeDeadLetters(values.Val()), nil
+}
+
+// decodeDeadLetters skips the letters which can't be decoded.
+func decodeDeadLetters(values []string) []*DeadLetter {
+	letters := make([]*DeadLetter, 0, len(values))
+	for _, value := range values {
+		letter := new(DeadLetter)
+		if err := json.Unmarshal([]byte(value), letter); err != nil {
+			log.GetLogger().Errorw("Failed to decode the failed notification", "error", err)
+			continue
+		}
+		letters = append(letters, letter)
+	}
+
+	return letters
+}
@@ -0,0 +1,66 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterSender_Notify(t *testing.T) {
+	ctx := context.Background()
+	sender := &recordSender{}
+	store := NewMemoryDeadLetterStore(10)
+	deadLetters := NewDeadLetterSender("sepolia", "slack", sender, store)
+
+	require.NoError(t, deadLetters.Notify(&Message{Title: "sent"}))
+
+	sender.err = errors.New("webhook failed")
+	require.ErrorIs(t, deadLetters.Notify(&Message{Title: "failed"}), sender.err)
+
+	letters, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	assert.Equal(t, "sepolia", letters[0].Network)
+	assert.Equal(t, "slack", letters[0].Notifier)
+	assert.Equal(t, "failed", letters[0].Message.Title)
+	assert.Equal(t, "webhook failed", letters[0].Error)
+}
+
+func TestDeadLetterStores(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+
+	for name, store := range map[string]DeadLetterStore{
+		"memory": NewMemoryDeadLetterStore(2),
+		"redis":  NewRedisDeadLetterStore(redis.NewClient(&redis.Options{Addr: redisServer.Addr()}), 2),
+	} {
+		store := store
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			// the oldest letters are dropped beyond the size
+			for i := 1; i <= 3; i++ {
+				require.NoError(t, store.Add(ctx, &DeadLetter{Notifier: "slack", Message: &Message{Title: fmt.Sprint(i), Severity: SeverityCritical}}))
+			}
+
+			letters, err := store.List(ctx)
+			require.NoError(t, err)
+			require.Len(t, letters, 2)
+			assert.Equal(t, "2", letters[0].Message.Title)
+			assert.Equal(t, SeverityCritical, letters[1].Message.Severity)
+
+			letters, err = store.Take(ctx)
+			require.NoError(t, err)
+			assert.Len(t, letters, 2)
+
+			letters, err = store.List(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, letters)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package notification
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+const emailDomain = "thanos-event-listener"
+
+// EmailConfig is the SMTP server and the addresses of the emails.
+type EmailConfig struct {
+	// Addr is the host:port of the SMTP server, the connection is upgraded
+	// with STARTTLS when the server supports it.
+	Addr     string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// EmailNotificationService sends the messages by email. The emails of an
+// alert reference its dedup key, so the clients show its triggers and its
+// resolution in a single conversation.
+type EmailNotificationService struct {
+	cfg        EmailConfig
+	numOfRetry int
+	sendMail   func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+	sleep      func(time.Duration)
+	now        func() time.Time
+}
+
+func MakeEmailNotificationService(cfg EmailConfig, numOfRetry int) *EmailNotificationService {
+	return &EmailNotificationService{
+		cfg:        cfg,
+		numOfRetry: numOfRetry,
+		sendMail:   smtp.SendMail,
+		sleep:      time.Sleep,
+		now:        time.Now,
+	}
+}
+
+func (s *EmailNotificationService) Notify(msg *Message) error {
+	body, err := s.email(msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		host, _, err := net.SplitHostPort(s.cfg.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, host)
+	}
+
+	return retry(s.numOfRetry, s.sleep, "email", func() error {
+		return s.sendMail(s.cfg.Addr, auth, s.cfg.From, s.cfg.To, body)
+	})
+}
+
+// email formats msg as a plain text email, its subject prefixed with the
+// severity or RESOLVED.
+func (s *EmailNotificationService) email(msg *Message) ([]byte, error) {
+	now := s.now()
+
+	status := strings.ToUpper(string(msg.Severity))
+	if status == "" {
+		status = strings.ToUpper(string(SeverityInfo))
+	}
+	if msg.Resolved {
+		status = "RESOLVED"
+	}
+
+	var email bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&email, "%s: %s\r\n", key, value)
+	}
+	header("From", s.cfg.From)
+	header("To", strings.Join(s.cfg.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", fmt.Sprintf("[%s] %s", status, msg.Title)))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", fmt.Sprintf("<%d@%s>", now.UnixNano(), emailDomain))
+	if msg.DedupKey != "" {
+		sum := sha256.Sum256([]byte(msg.DedupKey))
+		reference := fmt.Sprintf("<%s@%s>", hex.EncodeToString(sum[:16]), emailDomain)
+		header("In-Reply-To", reference)
+		header("References", reference)
+	}
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=UTF-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	email.WriteString("\r\n")
+
+	var text strings.Builder
+	text.WriteString(msg.Text)
+	for _, link := range msg.Links {
+		fmt.Fprintf(&text, "\n%s: %s", link.Text, link.URL)
+	}
+
+	w := quotedprintable.NewWriter(&email)
+	if _, err := w.Write([]byte(strings.ReplaceAll(text.String(), "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return email.Bytes(), nil
+}
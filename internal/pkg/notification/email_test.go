@@ -0,0 +1,132 @@
+package notification
+
+import (
+	"bufio"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpStub is a local SMTP server keeping the received emails, rejecting the
+// recipients of reject.
+type smtpStub struct {
+	listener net.Listener
+	reject   string
+	emails   chan string
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	stub := &smtpStub{listener: listener, emails: make(chan string, 10)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go stub.serve(conn)
+		}
+	}()
+
+	return stub
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+
+	text := textproto.NewConn(conn)
+	_ = text.PrintfLine("220 stub ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case command == "EHLO" || command == "HELO":
+			_ = text.PrintfLine("250 stub")
+		case command == "RCPT" && s.reject != "" && strings.Contains(line, s.reject):
+			_ = text.PrintfLine("550 no such user")
+		case command == "DATA":
+			_ = text.PrintfLine("354 go ahead")
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			s.emails <- string(data)
+			_ = text.PrintfLine("250 queued")
+		case command == "QUIT":
+			_ = text.PrintfLine("221 bye")
+			return
+		default:
+			_ = text.PrintfLine("250 ok")
+		}
+	}
+}
+
+func TestEmailNotificationService_Notify(t *testing.T) {
+	stub := newSMTPStub(t)
+
+	email := MakeEmailNotificationService(EmailConfig{
+		Addr: stub.listener.Addr().String(),
+		From: "listener@example.com",
+		To:   []string{"ops@example.com", "oncall@example.com"},
+	}, 3)
+	email.now = func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	require.NoError(t, email.Notify(&Message{
+		Title:    "[sepolia] [l1 Listener Failure]",
+		Text:     "The l1 listener failed 3 times in a row.",
+		Severity: SeverityCritical,
+		DedupKey: "listener:sepolia:l1",
+		Links:    []Link{{Text: "Runbook", URL: "https://runbook"}},
+	}))
+	require.NoError(t, email.Notify(&Message{Title: "[sepolia] [l1 Listener Recovered]", DedupKey: "listener:sepolia:l1", Resolved: true}))
+
+	triggered, resolved := readEmail(t, <-stub.emails), readEmail(t, <-stub.emails)
+
+	assert.Equal(t, "[CRITICAL] [sepolia] [l1 Listener Failure]", triggered.Header.Get("Subject"))
+	assert.Equal(t, "ops@example.com, oncall@example.com", triggered.Header.Get("To"))
+	assert.Equal(t, "Mon, 01 Jan 2024 00:00:00 +0000", triggered.Header.Get("Date"))
+	// the dot reader of the stub reads the CRLF line endings as LF
+	assert.Equal(t, "The l1 listener failed 3 times in a row.\nRunbook: https://runbook\n", triggered.body)
+
+	// the trigger and the resolution reference the same alert
+	assert.Equal(t, "[RESOLVED] [sepolia] [l1 Listener Recovered]", resolved.Header.Get("Subject"))
+	assert.NotEmpty(t, triggered.Header.Get("References"))
+	assert.Equal(t, triggered.Header.Get("References"), resolved.Header.Get("References"))
+
+	// a rejected recipient fails at once
+	stub.reject = "oncall@"
+	err := email.Notify(&Message{Title: "rejected"})
+	var smtpErr *textproto.Error
+	require.ErrorAs(t, err, &smtpErr)
+	assert.Equal(t, 550, smtpErr.Code)
+}
+
+type receivedEmail struct {
+	Header textproto.MIMEHeader
+	body   string
+}
+
+func readEmail(t *testing.T, data string) receivedEmail {
+	reader := textproto.NewReader(bufio.NewReader(strings.NewReader(data)))
+	header, err := reader.ReadMIMEHeader()
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(quotedprintable.NewReader(reader.R))
+	require.NoError(t, err)
+
+	return receivedEmail{Header: header, body: string(body)}
+}
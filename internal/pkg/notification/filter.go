@@ -0,0 +1,23 @@
+package notification
+
+// SeverityFilter drops the messages below a minimum severity, e.g. for the
+// paging destinations only receiving the critical alerts.
+type SeverityFilter struct {
+	sender      Sender
+	minSeverity Severity
+}
+
+func NewSeverityFilter(sender Sender, minSeverity Severity) *SeverityFilter {
+	return &SeverityFilter{
+		sender:      sender,
+		minSeverity: minSeverity,
+	}
+}
+
+func (f *SeverityFilter) Notify(msg *Message) error {
+	if !msg.Severity.AtLeast(f.minSeverity) {
+		return nil
+	}
+
+	return f.sender.Notify(msg)
+}
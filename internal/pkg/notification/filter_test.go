@@ -0,0 +1,20 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeverityFilter_Notify(t *testing.T) {
+	sender := &recordSender{}
+	filter := NewSeverityFilter(sender, SeverityCritical)
+
+	require.NoError(t, filter.Notify(&Message{Title: "info", Severity: SeverityInfo}))
+	require.NoError(t, filter.Notify(&Message{Title: "warning", Severity: SeverityWarning}))
+	require.NoError(t, filter.Notify(&Message{Title: "critical", Severity: SeverityCritical}))
+
+	require.Len(t, sender.messages, 1)
+	assert.Equal(t, "critical", sender.messages[0].Title)
+}
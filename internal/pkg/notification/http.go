@@ -0,0 +1,157 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	maxErrorBodySize    = 1 << 10
+	maxResponseBodySize = 1 << 20
+
+	minRetryBackoff = time.Second
+	maxRetryBackoff = 30 * time.Second
+)
+
+// HTTPError is the error of a request answered with a non 2xx status.
+type HTTPError struct {
+	StatusCode int
+	// RetryAfter is the delay asked by the destination before the next
+	// request, 0 when not set.
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+// Temporary reports whether the request may succeed when retried.
+func (e *HTTPError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// checkResponse returns an *HTTPError when the status of resp isn't 2xx.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+
+	return &HTTPError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		Body:       string(body),
+	}
+}
+
+// parseRetryAfter reads the Retry-After header, either seconds or an HTTP
+// date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+
+	return 0
+}
+
+// retryDelay returns the delay before retrying after err, and false when err
+// is permanent.
+func retryDelay(err error, attempt int) (time.Duration, bool) {
+	// the 5xx replies of the SMTP servers are permanent
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) && smtpErr.Code >= 500 {
+		return 0, false
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		if !httpErr.Temporary() {
+			return 0, false
+		}
+		if httpErr.RetryAfter > 0 {
+			return httpErr.RetryAfter, true
+		}
+	}
+
+	backoff := minRetryBackoff << attempt
+	if backoff <= 0 || backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+
+	return backoff, true
+}
+
+// retry calls send up to numOfRetry times until it succeeds, sleeping the
+// delay of retryDelay between the attempts. The permanent errors fail at once.
+func retry(numOfRetry int, sleep func(time.Duration), destination string, send func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := send()
+		if err == nil {
+			return nil
+		}
+
+		if attempt+1 >= numOfRetry {
+			return err
+		}
+
+		delay, ok := retryDelay(err, attempt)
+		if !ok {
+			return err
+		}
+
+		log.GetLogger().Warnw("Failed to send the notification, retrying", "error", err, "destination", destination, "delay", delay, "attempt", attempt+1)
+		sleep(delay)
+	}
+}
+
+// postJSON posts body as JSON to url, returning the response body of a 2xx
+// response and an *HTTPError otherwise.
+func postJSON(client *http.Client, url string, headers map[string]string, body any) ([]byte, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxResponseBodySize))
+}
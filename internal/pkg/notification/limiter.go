@@ -0,0 +1,115 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	DefaultQueueSize = 1000
+)
+
+// ErrQueueFull is returned when a message is dropped because the queue of
+// its destination is full.
+var ErrQueueFull = errors.New("notification queue is full")
+
+// RateLimiter delays the messages of a destination to a token bucket rate,
+// e.g. the 1 message per second of the Slack webhooks. The messages are
+// queued and sent one at a time in the background, so a slow destination
+// doesn't block the caller, and a Retry-After of the destination delays the
+// next ones.
+type RateLimiter struct {
+	sender  Sender
+	limiter *rate.Limiter
+	queue   chan *Message
+
+	mu      sync.Mutex
+	running bool
+
+	// pausedUntil and sleep are used by the sending goroutine only.
+	pausedUntil time.Time
+	sleep       func(time.Duration)
+}
+
+// NewRateLimiter allows perSecond messages per second to sender, with bursts
+// of burst messages, queueing at most queueSize messages.
+func NewRateLimiter(sender Sender, perSecond float64, burst int, queueSize int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	if queueSize < 1 {
+		queueSize = DefaultQueueSize
+	}
+
+	return &RateLimiter{
+		sender:  sender,
+		limiter: rate.NewLimiter(rate.Limit(perSecond), burst),
+		queue:   make(chan *Message, queueSize),
+		sleep:   time.Sleep,
+	}
+}
+
+// Notify queues msg, or drops it when the queue is full. The errors of the
+// messages sent in the background are logged.
+func (l *RateLimiter) Notify(msg *Message) error {
+	select {
+	case l.queue <- msg:
+	default:
+		log.GetLogger().Errorw("Dropped the notification, the queue is full", "title", msg.Title, "queue_size", cap(l.queue))
+		return ErrQueueFull
+	}
+
+	// the sending goroutine runs while messages are queued
+	l.mu.Lock()
+	if !l.running {
+		l.running = true
+		go l.run()
+	}
+	l.mu.Unlock()
+
+	return nil
+}
+
+func (l *RateLimiter) run() {
+	for {
+		select {
+		case msg := <-l.queue:
+			if err := l.send(msg); err != nil {
+				log.GetLogger().Errorw("Failed to send the notification", "error", err, "title", msg.Title)
+			}
+		default:
+			l.mu.Lock()
+			if len(l.queue) == 0 {
+				l.running = false
+				l.mu.Unlock()
+				return
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+func (l *RateLimiter) send(msg *Message) error {
+	if wait := time.Until(l.pausedUntil); wait > 0 {
+		l.sleep(wait)
+	}
+
+	if err := l.limiter.Wait(context.Background()); err != nil {
+		return err
+	}
+
+	err := l.sender.Notify(msg)
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+		l.pausedUntil = time.Now().Add(httpErr.RetryAfter)
+	}
+
+	return err
+}
@@ -0,0 +1,61 @@
+package notification
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_Notify(t *testing.T) {
+	sender := &syncSender{}
+	limiter := NewRateLimiter(sender, 50, 1, 0)
+
+	// the burst is queued at once, then sent one message every 20ms
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		require.NoError(t, limiter.Notify(&Message{Title: "burst"}))
+	}
+	assert.Less(t, time.Since(start), 20*time.Millisecond)
+
+	require.Eventually(t, func() bool { return len(sender.sent()) == 6 }, time.Second, time.Millisecond)
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
+
+func TestRateLimiter_RetryAfter(t *testing.T) {
+	sender := &syncSender{err: &HTTPError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Minute}}
+	limiter := NewRateLimiter(sender, 50, 1, 0)
+
+	slept := make(chan time.Duration, 1)
+	limiter.sleep = func(d time.Duration) { slept <- d }
+
+	require.NoError(t, limiter.Notify(&Message{Title: "limited"}))
+	require.Eventually(t, func() bool { return len(sender.sent()) == 1 }, time.Second, time.Millisecond)
+
+	// the next message waits for the Retry-After of the destination
+	require.NoError(t, limiter.Notify(&Message{Title: "after"}))
+	select {
+	case d := <-slept:
+		assert.InDelta(t, time.Minute.Seconds(), d.Seconds(), 1)
+	case <-time.After(time.Second):
+		t.Fatal("the message didn't wait for the Retry-After")
+	}
+}
+
+func TestRateLimiter_QueueFull(t *testing.T) {
+	sender := &syncSender{release: make(chan struct{})}
+	limiter := NewRateLimiter(sender, 1000, 1, 2)
+
+	// the first message is being sent, the next two are queued
+	require.NoError(t, limiter.Notify(&Message{Title: "sending"}))
+	require.Eventually(t, func() bool { return len(limiter.queue) == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, limiter.Notify(&Message{Title: "queued"}))
+	require.NoError(t, limiter.Notify(&Message{Title: "queued"}))
+
+	require.ErrorIs(t, limiter.Notify(&Message{Title: "dropped"}), ErrQueueFull)
+
+	close(sender.release)
+	require.Eventually(t, func() bool { return len(sender.sent()) == 3 }, time.Second, time.Millisecond)
+}
@@ -0,0 +1,92 @@
+package notification
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+var severityRanks = map[Severity]int{
+	SeverityInfo:     0,
+	SeverityWarning:  1,
+	SeverityCritical: 2,
+}
+
+func ParseSeverity(s string) (Severity, error) {
+	if s == "" {
+		return SeverityInfo, nil
+	}
+
+	severity := Severity(s)
+	if _, ok := severityRanks[severity]; !ok {
+		return "", fmt.Errorf("unknown severity: %s", s)
+	}
+
+	return severity, nil
+}
+
+// AtLeast reports whether the severity is equal to or higher than other.
+func (s Severity) AtLeast(other Severity) bool {
+	return severityRanks[s] >= severityRanks[other]
+}
+
+// Message is a notification with the attributes used to route it.
+type Message struct {
+	Title    string
+	Text     string
+	Severity Severity
+	// Attributes describe the source of the message, e.g. the network, layer
+	// or token symbol of a bridge event.
+	Attributes map[string]string
+	// Values are the numeric attributes of the message, e.g. the USD value
+	// of a bridge event, compared to the minimums of the routes.
+	Values map[string]float64
+	// Fields and Links are the structured content of the message, rendered
+	// by the destinations supporting it instead of Text.
+	Fields []Field
+	Links  []Link
+	// Mention alerts the members of the destination, e.g. <!here> on Slack.
+	Mention string
+	// Thread groups the messages of a subject, e.g. a bridge transfer. The
+	// destinations supporting threads post the messages of a thread as
+	// replies to its first message, or to the last message starting it.
+	Thread       string
+	StartsThread bool
+	// DedupKey identifies an alert across its repeated triggers for the
+	// incident destinations, and Resolved resolves the alert of DedupKey.
+	DedupKey string
+	Resolved bool
+}
+
+// Field is a named value of a message, linked to URL when set.
+type Field struct {
+	Name  string
+	Value string
+	URL   string
+}
+
+// Link is a button of a message.
+type Link struct {
+	Text string
+	URL  string
+}
+
+// truncate cuts s to max bytes on a rune boundary.
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+
+	return s[:max]
+}
@@ -0,0 +1,106 @@
+package notification
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// OpsgenieAlertsURL is the endpoint of the Opsgenie Alert API, the EU
+	// instances use https://api.eu.opsgenie.com/v2/alerts.
+	OpsgenieAlertsURL = "https://api.opsgenie.com/v2/alerts"
+
+	maxOpsgenieMessage     = 130
+	maxOpsgenieDescription = 15000
+)
+
+var opsgeniePriorities = map[Severity]string{
+	SeverityInfo:     "P5",
+	SeverityWarning:  "P3",
+	SeverityCritical: "P1",
+}
+
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Priority    string            `json:"priority"`
+	Source      string            `json:"source,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+type opsgenieClose struct {
+	Source string `json:"source,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+// OpsgenieNotificationService creates and closes Opsgenie alerts. The dedup
+// key is the alias of the alert, so its repeated triggers are deduplicated.
+type OpsgenieNotificationService struct {
+	url        string
+	apiKey     string
+	source     string
+	numOfRetry int
+	client     *http.Client
+	sleep      func(time.Duration)
+}
+
+// MakeOpsgenieNotificationService sends the alerts to url, the Alert API
+// when empty, with the key of an API integration.
+func MakeOpsgenieNotificationService(url, apiKey, source string, numOfRetry int) *OpsgenieNotificationService {
+	if url == "" {
+		url = OpsgenieAlertsURL
+	}
+
+	return &OpsgenieNotificationService{
+		url:        url,
+		apiKey:     apiKey,
+		source:     source,
+		numOfRetry: numOfRetry,
+		client: &http.Client{
+			Timeout: time.Second * 5,
+		},
+		sleep: time.Sleep,
+	}
+}
+
+// Notify creates an alert, or closes the alert of the dedup key of a
+// resolved message. The resolved messages without dedup key are dropped.
+func (s *OpsgenieNotificationService) Notify(msg *Message) error {
+	headers := map[string]string{"Authorization": "GenieKey " + s.apiKey}
+
+	if msg.Resolved {
+		if msg.DedupKey == "" {
+			return nil
+		}
+
+		closeURL := fmt.Sprintf("%s/%s/close?identifierType=alias", s.url, url.PathEscape(msg.DedupKey))
+		body := opsgenieClose{Source: s.source, Note: msg.Title}
+
+		return retry(s.numOfRetry, s.sleep, "opsgenie", func() error {
+			_, err := postJSON(s.client, closeURL, headers, body)
+			return err
+		})
+	}
+
+	priority, ok := opsgeniePriorities[msg.Severity]
+	if !ok {
+		priority = opsgeniePriorities[SeverityInfo]
+	}
+
+	alert := opsgenieAlert{
+		Message:     truncate(msg.Title, maxOpsgenieMessage),
+		Alias:       msg.DedupKey,
+		Description: truncate(msg.Text, maxOpsgenieDescription),
+		Priority:    priority,
+		Source:      s.source,
+		Details:     msg.Attributes,
+	}
+
+	return retry(s.numOfRetry, s.sleep, "opsgenie", func() error {
+		_, err := postJSON(s.client, s.url, headers, alert)
+		return err
+	})
+}
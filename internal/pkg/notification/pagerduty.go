@@ -0,0 +1,114 @@
+package notification
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	// PagerDutyEventsURL is the endpoint of the PagerDuty Events API v2.
+	PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+	maxPagerDutySummary = 1024
+)
+
+var pagerDutySeverities = map[Severity]string{
+	SeverityInfo:     "info",
+	SeverityWarning:  "warning",
+	SeverityCritical: "critical",
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key,omitempty"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	Links       []pagerDutyLink   `json:"links,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+// PagerDutyNotificationService triggers and resolves PagerDuty incidents with
+// the Events API v2. The messages with the same dedup key update a single
+// incident.
+type PagerDutyNotificationService struct {
+	url        string
+	routingKey string
+	source     string
+	numOfRetry int
+	client     *http.Client
+	sleep      func(time.Duration)
+}
+
+// MakePagerDutyNotificationService sends the events of the integration
+// routingKey to url, the Events API v2 when empty.
+func MakePagerDutyNotificationService(url, routingKey, source string, numOfRetry int) *PagerDutyNotificationService {
+	if url == "" {
+		url = PagerDutyEventsURL
+	}
+
+	return &PagerDutyNotificationService{
+		url:        url,
+		routingKey: routingKey,
+		source:     source,
+		numOfRetry: numOfRetry,
+		client: &http.Client{
+			Timeout: time.Second * 5,
+		},
+		sleep: time.Sleep,
+	}
+}
+
+// Notify triggers an incident, or resolves the incident of the dedup key of
+// a resolved message. The resolved messages without dedup key are dropped.
+func (s *PagerDutyNotificationService) Notify(msg *Message) error {
+	event := pagerDutyEvent{
+		RoutingKey:  s.routingKey,
+		EventAction: "trigger",
+		DedupKey:    msg.DedupKey,
+	}
+
+	if msg.Resolved {
+		if msg.DedupKey == "" {
+			return nil
+		}
+		event.EventAction = "resolve"
+	} else {
+		severity, ok := pagerDutySeverities[msg.Severity]
+		if !ok {
+			severity = pagerDutySeverities[SeverityInfo]
+		}
+
+		details := make(map[string]string, len(msg.Attributes)+1)
+		for key, value := range msg.Attributes {
+			details[key] = value
+		}
+		details["text"] = msg.Text
+
+		event.Payload = &pagerDutyPayload{
+			Summary:       truncate(msg.Title, maxPagerDutySummary),
+			Source:        s.source,
+			Severity:      severity,
+			CustomDetails: details,
+		}
+
+		for _, link := range msg.Links {
+			event.Links = append(event.Links, pagerDutyLink{Href: link.URL, Text: link.Text})
+		}
+	}
+
+	return retry(s.numOfRetry, s.sleep, "pagerduty", func() error {
+		_, err := postJSON(s.client, s.url, nil, event)
+		return err
+	})
+}
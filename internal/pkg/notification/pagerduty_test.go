@@ -0,0 +1,110 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPagerDutyNotificationService_Notify(t *testing.T) {
+	var events []pagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event pagerDutyEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events = append(events, event)
+
+		if event.RoutingKey == "invalid" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"status":"invalid event"}`))
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"status":"success","dedup_key":"stuck"}`))
+	}))
+	defer server.Close()
+
+	pagerDuty := MakePagerDutyNotificationService(server.URL, "routing-key", "thanos-event-listener", 3)
+
+	require.NoError(t, pagerDuty.Notify(&Message{
+		Title:      "[sepolia] [ETH Deposit Stuck]",
+		Text:       "Deposit 1 ETH isn't finalized",
+		Severity:   SeverityWarning,
+		Attributes: map[string]string{"network": "sepolia"},
+		Links:      []Link{{Text: "View transaction", URL: "https://explorer/tx/0x01"}},
+		DedupKey:   "stuck",
+	}))
+	require.NoError(t, pagerDuty.Notify(&Message{Title: "[sepolia] [ETH Deposit Finalized]", Severity: SeverityWarning, DedupKey: "stuck", Resolved: true}))
+	// nothing to resolve without dedup key
+	require.NoError(t, pagerDuty.Notify(&Message{Title: "resolved", Resolved: true}))
+
+	require.Len(t, events, 2)
+	assert.Equal(t, pagerDutyEvent{
+		RoutingKey:  "routing-key",
+		EventAction: "trigger",
+		DedupKey:    "stuck",
+		Payload: &pagerDutyPayload{
+			Summary:       "[sepolia] [ETH Deposit Stuck]",
+			Source:        "thanos-event-listener",
+			Severity:      "warning",
+			CustomDetails: map[string]string{"network": "sepolia", "text": "Deposit 1 ETH isn't finalized"},
+		},
+		Links: []pagerDutyLink{{Href: "https://explorer/tx/0x01", Text: "View transaction"}},
+	}, events[0])
+	assert.Equal(t, pagerDutyEvent{RoutingKey: "routing-key", EventAction: "resolve", DedupKey: "stuck"}, events[1])
+
+	// an invalid event fails at once
+	pagerDuty.routingKey = "invalid"
+	var httpErr *HTTPError
+	require.ErrorAs(t, pagerDuty.Notify(&Message{Title: "invalid"}), &httpErr)
+	assert.Equal(t, http.StatusBadRequest, httpErr.StatusCode)
+	assert.Len(t, events, 3)
+}
+
+func TestOpsgenieNotificationService_Notify(t *testing.T) {
+	type request struct {
+		path, query, auth string
+		body              map[string]any
+	}
+
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, request{path: r.URL.EscapedPath(), query: r.URL.RawQuery, auth: r.Header.Get("Authorization"), body: body})
+
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"result":"Request will be processed"}`))
+	}))
+	defer server.Close()
+
+	opsgenie := MakeOpsgenieNotificationService(server.URL+"/v2/alerts", "api-key", "thanos-event-listener", 3)
+
+	require.NoError(t, opsgenie.Notify(&Message{
+		Title:      "[sepolia] [l1 Listener Failure]",
+		Text:       "The l1 listener failed 3 times in a row.",
+		Severity:   SeverityCritical,
+		Attributes: map[string]string{"chain": "l1"},
+		DedupKey:   "listener:sepolia/l1",
+	}))
+	require.NoError(t, opsgenie.Notify(&Message{Title: "[sepolia] [l1 Listener Recovered]", DedupKey: "listener:sepolia/l1", Resolved: true}))
+
+	require.Len(t, requests, 2)
+	assert.Equal(t, "/v2/alerts", requests[0].path)
+	assert.Equal(t, "GenieKey api-key", requests[0].auth)
+	assert.Equal(t, map[string]any{
+		"message":     "[sepolia] [l1 Listener Failure]",
+		"alias":       "listener:sepolia/l1",
+		"description": "The l1 listener failed 3 times in a row.",
+		"priority":    "P1",
+		"source":      "thanos-event-listener",
+		"details":     map[string]any{"chain": "l1"},
+	}, requests[0].body)
+
+	assert.Equal(t, "/v2/alerts/listener:sepolia%2Fl1/close", requests[1].path)
+	assert.Equal(t, "identifierType=alias", requests[1].query)
+	assert.Equal(t, "[sepolia] [l1 Listener Recovered]", requests[1].body["note"])
+}
@@ -0,0 +1,172 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Sender delivers a message to a single destination.
+type Sender interface {
+	Notify(msg *Message) error
+}
+
+// Route sends the messages matching all of its conditions to Notifiers.
+type Route struct {
+	Name string
+	// Match holds the attribute values a message must have.
+	Match map[string]string
+	// Min holds the minimum values a message must have. A message without
+	// the value doesn't match.
+	Min         map[string]float64
+	MinSeverity Severity
+	Notifiers   []string
+}
+
+func (r *Route) matches(msg *Message) bool {
+	if !msg.Severity.AtLeast(r.MinSeverity) {
+		return false
+	}
+
+	for key, value := range r.Match {
+		if msg.Attributes[key] != value {
+			return false
+		}
+	}
+
+	for key, min := range r.Min {
+		value, ok := msg.Values[key]
+		if !ok || value < min {
+			return false
+		}
+	}
+
+	return true
+}
+
+type routingTable struct {
+	senders        map[string]Sender
+	defaultTargets []string
+	routes         []Route
+}
+
+// Router dispatches messages to the named senders of every matching route,
+// or to the default senders when no route matches.
+type Router struct {
+	mu    sync.RWMutex
+	table *routingTable
+	off   atomic.Bool
+}
+
+func NewRouter(senders map[string]Sender, defaultTargets []string, routes []Route) (*Router, error) {
+	router := &Router{}
+	if err := router.Update(senders, defaultTargets, routes); err != nil {
+		return nil, err
+	}
+
+	return router, nil
+}
+
+// Update replaces the senders and routes at once. The current table is kept
+// when the new one refers to an unknown sender.
+func (r *Router) Update(senders map[string]Sender, defaultTargets []string, routes []Route) error {
+	for _, name := range defaultTargets {
+		if _, ok := senders[name]; !ok {
+			return fmt.Errorf("default route: unknown notifier %s", name)
+		}
+	}
+
+	for _, route := range routes {
+		for _, name := range route.Notifiers {
+			if _, ok := senders[name]; !ok {
+				return fmt.Errorf("route %s: unknown notifier %s", route.Name, name)
+			}
+		}
+	}
+
+	table := &routingTable{
+		senders:        senders,
+		defaultTargets: defaultTargets,
+		routes:         routes,
+	}
+
+	r.mu.Lock()
+	r.table = table
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Sender returns the sender of the name, nil when unknown.
+func (r *Router) Sender(name string) Sender {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.table.senders[name]
+}
+
+func (r *Router) Enable() {
+	r.off.Store(false)
+}
+
+// Disable skips the routine messages until Enable, the alerts and the
+// messages escalated above the info severity being still sent.
+func (r *Router) Disable() {
+	r.off.Store(true)
+}
+
+func (r *Router) Enabled() bool {
+	return !r.off.Load()
+}
+
+// Skips reports whether msg is skipped by the disabled router, a routine
+// message: info without alert attribute.
+func (r *Router) Skips(msg *Message) bool {
+	return r.off.Load() && msg.Severity == SeverityInfo && msg.Attributes["alert"] == ""
+}
+
+func (r *Router) Notify(msg *Message) error {
+	if r.Skips(msg) {
+		return nil
+	}
+
+	var errs []error
+	for _, sender := range r.targets(msg) {
+		if err := sender.Notify(msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// targets returns the senders of msg, each sender once.
+func (r *Router) targets(msg *Message) []Sender {
+	r.mu.RLock()
+	table := r.table
+	r.mu.RUnlock()
+
+	var names []string
+	for i := range table.routes {
+		if table.routes[i].matches(msg) {
+			names = append(names, table.routes[i].Notifiers...)
+		}
+	}
+
+	if len(names) == 0 {
+		names = table.defaultTargets
+	}
+
+	seen := make(map[string]struct{}, len(names))
+	senders := make([]Sender, 0, len(names))
+	for _, name := range names {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		senders = append(senders, table.senders[name])
+	}
+
+	return senders
+}
@@ -0,0 +1,113 @@
+package notification
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordSender struct {
+	messages []*Message
+	err      error
+}
+
+func (s *recordSender) Notify(msg *Message) error {
+	s.messages = append(s.messages, msg)
+	return s.err
+}
+
+func TestRouter_Notify(t *testing.T) {
+	main, ops := &recordSender{}, &recordSender{}
+
+	router, err := NewRouter(
+		map[string]Sender{"main": main, "ops": ops},
+		[]string{"main"},
+		[]Route{
+			{Name: "withdrawals", Match: map[string]string{"direction": "withdrawal"}, Notifiers: []string{"main", "ops"}},
+			{Name: "critical", MinSeverity: SeverityCritical, Notifiers: []string{"ops"}},
+		},
+	)
+	require.NoError(t, err)
+
+	deposit := &Message{Title: "deposit", Severity: SeverityInfo, Attributes: map[string]string{"direction": "deposit"}}
+	withdrawal := &Message{Title: "withdrawal", Severity: SeverityCritical, Attributes: map[string]string{"direction": "withdrawal"}}
+
+	require.NoError(t, router.Notify(deposit))
+	require.NoError(t, router.Notify(withdrawal))
+
+	assert.Equal(t, []*Message{deposit, withdrawal}, main.messages)
+	// the withdrawal matches both routes but is sent to ops once
+	assert.Equal(t, []*Message{withdrawal}, ops.messages)
+
+	// the disabled router only sends the alerts and the escalated messages
+	router.Disable()
+	alert := &Message{Title: "alert", Severity: SeverityInfo, Attributes: map[string]string{"alert": "digest"}}
+	escalated := &Message{Title: "escalated", Severity: SeverityWarning, Attributes: map[string]string{"direction": "deposit"}}
+	require.NoError(t, router.Notify(deposit))
+	require.NoError(t, router.Notify(alert))
+	require.NoError(t, router.Notify(escalated))
+	assert.Equal(t, []*Message{deposit, withdrawal, alert, escalated}, main.messages)
+	router.Enable()
+
+	main.err = errors.New("webhook failed")
+	require.Error(t, router.Notify(deposit))
+}
+
+func TestRouter_Update(t *testing.T) {
+	main, ops := &recordSender{}, &recordSender{}
+
+	router, err := NewRouter(map[string]Sender{"main": main}, []string{"main"}, nil)
+	require.NoError(t, err)
+
+	err = router.Update(map[string]Sender{"ops": ops}, []string{"main"}, nil)
+	require.Error(t, err)
+
+	msg := &Message{Title: "kept"}
+	require.NoError(t, router.Notify(msg))
+	assert.Len(t, main.messages, 1)
+
+	require.NoError(t, router.Update(map[string]Sender{"ops": ops}, []string{"ops"}, nil))
+	require.NoError(t, router.Notify(msg))
+	assert.Len(t, main.messages, 1)
+	assert.Len(t, ops.messages, 1)
+}
+
+func TestParseSeverity(t *testing.T) {
+	severity, err := ParseSeverity("")
+	require.NoError(t, err)
+	assert.Equal(t, SeverityInfo, severity)
+
+	severity, err = ParseSeverity("critical")
+	require.NoError(t, err)
+	assert.True(t, severity.AtLeast(SeverityWarning))
+	assert.False(t, SeverityInfo.AtLeast(severity))
+
+	_, err = ParseSeverity("fatal")
+	require.Error(t, err)
+}
+
+func TestRouter_NotifyMin(t *testing.T) {
+	main, whales := &recordSender{}, &recordSender{}
+
+	router, err := NewRouter(
+		map[string]Sender{"main": main, "whales": whales},
+		[]string{"main"},
+		[]Route{
+			{Name: "whales", Min: map[string]float64{"value_usd": 100000}, Notifiers: []string{"whales"}},
+		},
+	)
+	require.NoError(t, err)
+
+	small := &Message{Title: "small", Values: map[string]float64{"value_usd": 99999.99}}
+	large := &Message{Title: "large", Values: map[string]float64{"value_usd": 100000}}
+	unpriced := &Message{Title: "unpriced"}
+
+	require.NoError(t, router.Notify(small))
+	require.NoError(t, router.Notify(large))
+	require.NoError(t, router.Notify(unpriced))
+
+	assert.Equal(t, []*Message{small, unpriced}, main.messages)
+	assert.Equal(t, []*Message{large}, whales.messages)
+}
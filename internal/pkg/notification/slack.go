@@ -2,27 +2,126 @@ package notification
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
 )
 
+const (
+	// SlackPostMessageURL is the Slack API method posting the messages of a
+	// bot token.
+	SlackPostMessageURL = "https://slack.com/api/chat.postMessage"
+
+	slackThreadTimeout = 5 * time.Second
+
+	// the section blocks hold at most 10 fields or 3000 characters of text,
+	// and the action blocks 25 elements. The messages hold at most 50 blocks,
+	// the longer texts are truncated.
+	maxSlackFields   = 10
+	maxSlackButtons  = 25
+	maxSlackText     = 3000
+	maxSlackSections = 40
+
+	slackTruncated = "\n…(truncated)"
+)
+
+var slackColors = map[Severity]string{
+	SeverityInfo:     "#2eb886",
+	SeverityWarning:  "#daa038",
+	SeverityCritical: "#a30200",
+}
+
 type SlackData struct {
+	Channel     string            `json:"channel,omitempty"`
+	ThreadTS    string            `json:"thread_ts,omitempty"`
+	Text        string            `json:"text"`
+	Attachments []SlackAttachment `json:"attachments,omitempty"`
+}
+
+// SlackAttachment holds the Block Kit blocks of a message with the colour
+// of its severity.
+type SlackAttachment struct {
+	Color  string       `json:"color"`
+	Blocks []SlackBlock `json:"blocks"`
+}
+
+type SlackBlock struct {
+	Type     string         `json:"type"`
+	Text     *SlackText     `json:"text,omitempty"`
+	Fields   []SlackText    `json:"fields,omitempty"`
+	Elements []SlackElement `json:"elements,omitempty"`
+}
+
+type SlackText struct {
+	Type string `json:"type"`
 	Text string `json:"text"`
 }
 
+type SlackElement struct {
+	Type string     `json:"type"`
+	Text *SlackText `json:"text,omitempty"`
+	URL  string     `json:"url,omitempty"`
+}
+
+// SlackAPIError is the error of a Slack API call answered with ok: false.
+type SlackAPIError struct {
+	Code string
+}
+
+func (e *SlackAPIError) Error() string {
+	return fmt.Sprintf("slack api error: %s", e.Code)
+}
+
+type slackResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+	TS    string `json:"ts"`
+}
+
+// SlackNotificationService posts the messages to an incoming webhook, or with
+// chat.postMessage when a bot token is set, threading the messages of a
+// thread under its first message.
 type SlackNotificationService struct {
 	url        string
+	token      string
+	channel    string
+	threads    ThreadStore
 	numOfRetry int
 	off        bool
+	client     *http.Client
+	sleep      func(time.Duration)
 }
 
 func MakeSlackNotificationService(url string, numOfRetry int) *SlackNotificationService {
-	return &SlackNotificationService{url: url, numOfRetry: numOfRetry, off: false}
+	return &SlackNotificationService{
+		url:        url,
+		numOfRetry: numOfRetry,
+		off:        false,
+		client: &http.Client{
+			Timeout: time.Second * 5,
+		},
+		sleep: time.Sleep,
+	}
+}
+
+// MakeSlackBotNotificationService posts the messages to the channel with the
+// bot token, keeping the threads in threads, in memory when nil.
+func MakeSlackBotNotificationService(token, channel string, numOfRetry int, threads ThreadStore) *SlackNotificationService {
+	if threads == nil {
+		threads = NewMemoryThreadStore(DefaultThreadTTL)
+	}
+
+	service := MakeSlackNotificationService(SlackPostMessageURL, numOfRetry)
+	service.token = token
+	service.channel = channel
+	service.threads = threads
+
+	return service
 }
 
 func (slackNotificationService *SlackNotificationService) Enable() {
@@ -33,48 +132,200 @@ func (slackNotificationService *SlackNotificationService) Disable() {
 	slackNotificationService.off = true
 }
 
-func (slackNotificationService *SlackNotificationService) Notify(title string, text string) error {
+// Notify posts the message, retrying the failed requests up to numOfRetry
+// times. A 429 or 5xx response is retried after its Retry-After delay, or
+// after an exponential backoff; the other statuses fail at once.
+func (slackNotificationService *SlackNotificationService) Notify(msg *Message) error {
 	if slackNotificationService.off {
 		return nil
 	}
 
-	data := SlackData{
-		Text: fmt.Sprintf("*%s*\n%s", title, text),
-	}
+	data := slackData(msg)
+	data.Channel = slackNotificationService.channel
+	data.ThreadTS = slackNotificationService.threadTS(msg)
 
 	payload, err := json.Marshal(data)
 	if err != nil {
 		return err
 	}
+
+	return retry(slackNotificationService.numOfRetry, slackNotificationService.sleep, "slack", func() error {
+		ts, err := slackNotificationService.post(payload)
+		if err != nil {
+			return err
+		}
+
+		slackNotificationService.saveThread(msg, data.ThreadTS, ts)
+		return nil
+	})
+}
+
+// threadTS returns the timestamp of the message msg replies to, empty when
+// msg starts a thread or threads aren't supported.
+func (slackNotificationService *SlackNotificationService) threadTS(msg *Message) string {
+	if slackNotificationService.threads == nil || msg.Thread == "" || msg.StartsThread {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), slackThreadTimeout)
+	defer cancel()
+
+	ts, err := slackNotificationService.threads.Get(ctx, slackNotificationService.threadKey(msg))
+	if err != nil {
+		log.GetLogger().Errorw("Failed to get the slack thread", "error", err, "thread", msg.Thread)
+		return ""
+	}
+
+	return ts
+}
+
+// saveThread keeps the timestamp of the message starting the thread of msg.
+func (slackNotificationService *SlackNotificationService) saveThread(msg *Message, threadTS, ts string) {
+	if slackNotificationService.threads == nil || msg.Thread == "" || threadTS != "" || ts == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), slackThreadTimeout)
+	defer cancel()
+
+	if err := slackNotificationService.threads.Set(ctx, slackNotificationService.threadKey(msg), ts); err != nil {
+		log.GetLogger().Errorw("Failed to save the slack thread", "error", err, "thread", msg.Thread)
+	}
+}
+
+func (slackNotificationService *SlackNotificationService) threadKey(msg *Message) string {
+	return "slack:" + slackNotificationService.channel + ":" + msg.Thread
+}
+
+// post sends the payload, returning the timestamp of the message posted with
+// chat.postMessage.
+func (slackNotificationService *SlackNotificationService) post(payload []byte) (string, error) {
 	req, err := http.NewRequest("POST", slackNotificationService.url, bytes.NewBuffer(payload))
 	if err != nil {
-		return err
+		return "", err
 	}
 	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-
-	// Create a new client and execute our request
-	client := &http.Client{
-		Timeout: time.Second * 5,
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if slackNotificationService.token != "" {
+		req.Header.Set("Authorization", "Bearer "+slackNotificationService.token)
 	}
-	resp, err := client.Do(req)
+
+	resp, err := slackNotificationService.client.Do(req)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer resp.Body.Close()
 
-	// Read and print the response
-	body, _ := io.ReadAll(resp.Body)
+	if err := checkResponse(resp); err != nil {
+		return "", err
+	}
+
+	if slackNotificationService.token == "" {
+		return "", nil
+	}
 
-	log.GetLogger().Infow("Response", "body", string(body))
-	return nil
+	var result slackResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	if !result.OK {
+		return "", &SlackAPIError{Code: result.Error}
+	}
+
+	return result.TS, nil
+}
+
+// NotifyWithReTry posts the message, the retries being done by Notify.
+func (slackNotificationService *SlackNotificationService) NotifyWithReTry(msg *Message) {
+	if err := slackNotificationService.Notify(msg); err != nil {
+		log.GetLogger().Errorw("Failed to post the slack message", "error", err)
+	}
 }
 
-func (slackNotificationService *SlackNotificationService) NotifyWithReTry(title string, text string) {
-	for i := 0; i < slackNotificationService.numOfRetry; i++ {
-		err := slackNotificationService.Notify(title, text)
-		if err == nil {
-			break
+// slackData renders the message with Block Kit: the text, or the fields
+// when set, then the links as buttons, coloured by severity.
+func slackData(msg *Message) SlackData {
+	var blocks []SlackBlock
+	if len(msg.Fields) == 0 {
+		for _, section := range slackSections(msg.Text) {
+			blocks = append(blocks, SlackBlock{
+				Type: "section",
+				Text: &SlackText{Type: "mrkdwn", Text: section},
+			})
 		}
 	}
+
+	for start := 0; start < len(msg.Fields); start += maxSlackFields {
+		fields := msg.Fields[start:min(start+maxSlackFields, len(msg.Fields))]
+
+		block := SlackBlock{Type: "section"}
+		for _, field := range fields {
+			block.Fields = append(block.Fields, SlackText{Type: "mrkdwn", Text: slackField(field)})
+		}
+		blocks = append(blocks, block)
+	}
+
+	if len(msg.Links) > 0 {
+		block := SlackBlock{Type: "actions"}
+		for _, link := range msg.Links[:min(len(msg.Links), maxSlackButtons)] {
+			block.Elements = append(block.Elements, SlackElement{
+				Type: "button",
+				Text: &SlackText{Type: "plain_text", Text: link.Text},
+				URL:  link.URL,
+			})
+		}
+		blocks = append(blocks, block)
+	}
+
+	color, ok := slackColors[msg.Severity]
+	if !ok || msg.Resolved {
+		color = slackColors[SeverityInfo]
+	}
+
+	text := fmt.Sprintf("*%s*", msg.Title)
+	if msg.Mention != "" {
+		text = msg.Mention + " " + text
+	}
+
+	return SlackData{
+		Text:        text,
+		Attachments: []SlackAttachment{{Color: color, Blocks: blocks}},
+	}
+}
+
+// slackSections splits text into the sections of at most maxSlackText
+// characters, at the line breaks when possible.
+func slackSections(text string) []string {
+	var sections []string
+	for len(text) > maxSlackText {
+		if len(sections) == maxSlackSections-1 {
+			return append(sections, truncate(text, maxSlackText-len(slackTruncated))+slackTruncated)
+		}
+
+		section := truncate(text, maxSlackText)
+		if i := strings.LastIndexByte(section, '\n'); i > 0 {
+			section = section[:i]
+		}
+		sections = append(sections, section)
+		text = strings.TrimPrefix(text[len(section):], "\n")
+	}
+
+	return append(sections, text)
 }
+
+func slackField(field Field) string {
+	value := slackEscape(field.Value)
+	if field.URL != "" {
+		value = fmt.Sprintf("<%s|%s>", field.URL, value)
+	}
+
+	return fmt.Sprintf("*%s*\n%s", slackEscape(field.Name), value)
+}
+
+// slackEscape escapes the control characters of the mrkdwn text.
+func slackEscape(s string) string {
+	return slackEscaper.Replace(s)
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
@@ -0,0 +1,163 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackNotificationService_Notify(t *testing.T) {
+	var (
+		requests atomic.Int32
+		status   atomic.Int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if code := int(status.Load()); code != 0 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(code)
+			_, _ = w.Write([]byte("rate_limited"))
+			status.Store(0)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	slack := MakeSlackNotificationService(server.URL, 3)
+	slack.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	require.NoError(t, slack.Notify(&Message{Title: "ok"}))
+	assert.Equal(t, int32(1), requests.Load())
+
+	// a 429 is retried after its Retry-After
+	status.Store(http.StatusTooManyRequests)
+	require.NoError(t, slack.Notify(&Message{Title: "limited"}))
+	assert.Equal(t, int32(3), requests.Load())
+	assert.Equal(t, []time.Duration{7 * time.Second}, delays)
+
+	// a 404 fails at once
+	status.Store(http.StatusNotFound)
+	err := slack.Notify(&Message{Title: "missing"})
+	require.Error(t, err)
+	var httpErr *HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+	assert.Equal(t, int32(4), requests.Load())
+}
+
+func TestSlackNotificationService_NotifyThreads(t *testing.T) {
+	var posted []SlackData
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer xoxb-token", r.Header.Get("Authorization"))
+
+		var data SlackData
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&data))
+		posted = append(posted, data)
+
+		if data.Channel == "missing" {
+			_, _ = w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"ok":true,"ts":"1700000000.%06d"}`, len(posted))))
+	}))
+	defer server.Close()
+
+	threads := NewMemoryThreadStore(0)
+	slack := MakeSlackBotNotificationService("xoxb-token", "C0123", 1, threads)
+	slack.url = server.URL
+
+	require.NoError(t, slack.Notify(&Message{Title: "initiated", Thread: "transfer", StartsThread: true}))
+	require.NoError(t, slack.Notify(&Message{Title: "finalized", Thread: "transfer"}))
+	require.NoError(t, slack.Notify(&Message{Title: "other"}))
+
+	require.Len(t, posted, 3)
+	assert.Equal(t, "C0123", posted[0].Channel)
+	assert.Empty(t, posted[0].ThreadTS)
+	assert.Equal(t, "1700000000.000001", posted[1].ThreadTS)
+	assert.Empty(t, posted[2].ThreadTS)
+
+	// a new initiation starts a new thread
+	require.NoError(t, slack.Notify(&Message{Title: "initiated again", Thread: "transfer", StartsThread: true}))
+	require.NoError(t, slack.Notify(&Message{Title: "retracted", Thread: "transfer"}))
+	assert.Equal(t, "1700000000.000004", posted[4].ThreadTS)
+
+	slack.channel = "missing"
+	err := slack.Notify(&Message{Title: "lost"})
+	var apiErr *SlackAPIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "channel_not_found", apiErr.Code)
+}
+
+func Test_slackData(t *testing.T) {
+	data := slackData(&Message{
+		Title:    "[sepolia] [ETH Deposit Initialized]",
+		Text:     "plain text",
+		Severity: SeverityWarning,
+		Mention:  "<!here>",
+		Fields: []Field{
+			{Name: "Amount", Value: "1 ETH"},
+			{Name: "From", Value: "0x01 (A&B)", URL: "https://explorer/address/0x01"},
+		},
+		Links: []Link{{Text: "View transaction", URL: "https://explorer/tx/0x02"}},
+	})
+
+	assert.Equal(t, "<!here> *[sepolia] [ETH Deposit Initialized]*", data.Text)
+	require.Len(t, data.Attachments, 1)
+	assert.Equal(t, slackColors[SeverityWarning], data.Attachments[0].Color)
+	assert.Equal(t, []SlackBlock{
+		{Type: "section", Fields: []SlackText{
+			{Type: "mrkdwn", Text: "*Amount*\n1 ETH"},
+			{Type: "mrkdwn", Text: "*From*\n<https://explorer/address/0x01|0x01 (A&amp;B)>"},
+		}},
+		{Type: "actions", Elements: []SlackElement{
+			{Type: "button", Text: &SlackText{Type: "plain_text", Text: "View transaction"}, URL: "https://explorer/tx/0x02"},
+		}},
+	}, data.Attachments[0].Blocks)
+
+	// the text without fields
+	data = slackData(&Message{Title: "digest", Text: "plain text"})
+	assert.Equal(t, slackColors[SeverityInfo], data.Attachments[0].Color)
+	assert.Equal(t, []SlackBlock{{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: "plain text"}}}, data.Attachments[0].Blocks)
+}
+
+func Test_slackSections(t *testing.T) {
+	line := strings.Repeat("a", 999) + "\n"
+
+	// the long texts are split at the line breaks
+	sections := slackSections(strings.Repeat(line, 4))
+	require.Len(t, sections, 2)
+	assert.Equal(t, strings.Repeat(line, 2)+strings.Repeat("a", 999), sections[0])
+	assert.Equal(t, line, sections[1])
+
+	// a line longer than a section is cut
+	sections = slackSections(strings.Repeat("a", maxSlackText+1))
+	assert.Equal(t, []string{strings.Repeat("a", maxSlackText), "a"}, sections)
+
+	// the text beyond the last section is truncated
+	sections = slackSections(strings.Repeat(line, 3*maxSlackSections+10))
+	require.Len(t, sections, maxSlackSections)
+	for _, section := range sections {
+		assert.LessOrEqual(t, len(section), maxSlackText)
+	}
+	assert.True(t, strings.HasSuffix(sections[maxSlackSections-1], slackTruncated))
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 30*time.Second, parseRetryAfter("30", now))
+	assert.Equal(t, time.Minute, parseRetryAfter(now.Add(time.Minute).Format(http.TimeFormat), now))
+	assert.Zero(t, parseRetryAfter("", now))
+	assert.Zero(t, parseRetryAfter("soon", now))
+	assert.Zero(t, parseRetryAfter("-1", now))
+}
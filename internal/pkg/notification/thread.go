@@ -0,0 +1,109 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// DefaultThreadTTL keeps the threads for the withdrawals, finalized
+	// after the 7 days of the challenge period.
+	DefaultThreadTTL = 14 * 24 * time.Hour
+
+	threadKey = "thread"
+)
+
+// ThreadStore keeps the id of the first message of the threads, by thread.
+type ThreadStore interface {
+	Get(ctx context.Context, thread string) (string, error)
+	Set(ctx context.Context, thread, id string) error
+}
+
+type threadEntry struct {
+	id        string
+	expiresAt time.Time
+}
+
+// MemoryThreadStore keeps the threads in memory for ttl.
+type MemoryThreadStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	threads map[string]threadEntry
+	now     func() time.Time
+}
+
+func NewMemoryThreadStore(ttl time.Duration) *MemoryThreadStore {
+	if ttl <= 0 {
+		ttl = DefaultThreadTTL
+	}
+
+	return &MemoryThreadStore{
+		ttl:     ttl,
+		threads: make(map[string]threadEntry),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryThreadStore) Get(_ context.Context, thread string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.threads[thread]
+	if !ok || !s.now().Before(entry.expiresAt) {
+		return "", nil
+	}
+
+	return entry.id, nil
+}
+
+func (s *MemoryThreadStore) Set(_ context.Context, thread, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, entry := range s.threads {
+		if !now.Before(entry.expiresAt) {
+			delete(s.threads, key)
+		}
+	}
+	s.threads[thread] = threadEntry{id: id, expiresAt: now.Add(s.ttl)}
+
+	return nil
+}
+
+// RedisThreadStore keeps the threads in Redis for ttl, across restarts.
+type RedisThreadStore struct {
+	redisClient redis.UniversalClient
+	ttl         time.Duration
+}
+
+func NewRedisThreadStore(redisClient redis.UniversalClient, ttl time.Duration) *RedisThreadStore {
+	if ttl <= 0 {
+		ttl = DefaultThreadTTL
+	}
+
+	return &RedisThreadStore{
+		redisClient: redisClient,
+		ttl:         ttl,
+	}
+}
+
+func (s *RedisThreadStore) Get(ctx context.Context, thread string) (string, error) {
+	id, err := s.redisClient.Get(ctx, threadKey+":"+thread).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (s *RedisThreadStore) Set(ctx context.Context, thread, id string) error {
+	return s.redisClient.Set(ctx, threadKey+":"+thread, id, s.ttl).Err()
+}
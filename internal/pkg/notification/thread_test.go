@@ -0,0 +1,54 @@
+package notification
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryThreadStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store := NewMemoryThreadStore(time.Hour)
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Set(ctx, "transfer", "1.0"))
+	id, err := store.Get(ctx, "transfer")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0", id)
+
+	now = now.Add(time.Hour)
+	id, err = store.Get(ctx, "transfer")
+	require.NoError(t, err)
+	assert.Empty(t, id)
+
+	// the expired threads are dropped
+	require.NoError(t, store.Set(ctx, "other", "2.0"))
+	assert.Len(t, store.threads, 1)
+}
+
+func TestRedisThreadStore(t *testing.T) {
+	ctx := context.Background()
+	redisServer := miniredis.RunT(t)
+	store := NewRedisThreadStore(redis.NewClient(&redis.Options{Addr: redisServer.Addr()}), time.Hour)
+
+	id, err := store.Get(ctx, "transfer")
+	require.NoError(t, err)
+	assert.Empty(t, id)
+
+	require.NoError(t, store.Set(ctx, "transfer", "1.0"))
+	id, err = store.Get(ctx, "transfer")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0", id)
+
+	redisServer.FastForward(time.Hour)
+	id, err = store.Get(ctx, "transfer")
+	require.NoError(t, err)
+	assert.Empty(t, id)
+}
@@ -0,0 +1,97 @@
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// File serves the prices of a JSON file mapping the ids to their price, such
+// as a file updated by a cron job. The file is read again when it changes.
+type File struct {
+	path    string
+	mu      sync.Mutex
+	modTime time.Time
+	prices  map[string]float64
+}
+
+func NewFile(path string) *File {
+	return &File{
+		path: path,
+	}
+}
+
+func (f *File) Price(_ context.Context, id string) (float64, error) {
+	prices, err := f.load()
+	if err != nil {
+		return 0, err
+	}
+
+	price, ok := prices[id]
+	if !ok {
+		return 0, ErrNoPrice
+	}
+
+	return price, nil
+}
+
+func (f *File) load() (map[string]float64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.prices != nil && info.ModTime().Equal(f.modTime) {
+		return f.prices, nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := parsePrices(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price file %s: %w", f.path, err)
+	}
+
+	f.prices = prices
+	f.modTime = info.ModTime()
+
+	return prices, nil
+}
+
+// parsePrices reads a JSON object mapping the ids either to their price or to
+// an object with their usd price, as returned by the CoinGecko simple price
+// API.
+func parsePrices(data []byte) (map[string]float64, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]float64, len(raw))
+	for id, value := range raw {
+		var price float64
+		if err := json.Unmarshal(value, &price); err == nil {
+			prices[id] = price
+			continue
+		}
+
+		var quote struct {
+			USD *float64 `json:"usd"`
+		}
+		if err := json.Unmarshal(value, &quote); err != nil || quote.USD == nil {
+			return nil, fmt.Errorf("invalid price of %s: %s", id, value)
+		}
+		prices[id] = *quote.USD
+	}
+
+	return prices, nil
+}
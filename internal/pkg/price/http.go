@@ -0,0 +1,103 @@
+package price
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultHTTPTTL = time.Minute
+
+	httpTimeout     = 10 * time.Second
+	maxResponseSize = 1 << 20
+)
+
+// HTTP serves the prices of an HTTP API returning the same JSON as the price
+// file, e.g. https://api.coingecko.com/api/v3/simple/price?ids=ethereum,tokamak-network&vs_currencies=usd.
+// The prices are cached for the ttl.
+type HTTP struct {
+	url       string
+	ttl       time.Duration
+	client    *http.Client
+	mu        sync.Mutex
+	fetchedAt time.Time
+	prices    map[string]float64
+}
+
+func NewHTTP(url string, ttl time.Duration) *HTTP {
+	if ttl <= 0 {
+		ttl = DefaultHTTPTTL
+	}
+
+	return &HTTP{
+		url:    url,
+		ttl:    ttl,
+		client: &http.Client{Timeout: httpTimeout},
+	}
+}
+
+func (h *HTTP) Price(ctx context.Context, id string) (float64, error) {
+	prices, err := h.load(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	price, ok := prices[id]
+	if !ok {
+		return 0, ErrNoPrice
+	}
+
+	return price, nil
+}
+
+func (h *HTTP) load(ctx context.Context) (map[string]float64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.prices != nil && time.Since(h.fetchedAt) < h.ttl {
+		return h.prices, nil
+	}
+
+	prices, err := h.fetch(ctx)
+	if err != nil {
+		// the stale prices are better than none
+		if h.prices != nil {
+			return h.prices, nil
+		}
+		return nil, err
+	}
+
+	h.prices = prices
+	h.fetchedAt = time.Now()
+
+	return prices, nil
+}
+
+func (h *HTTP) fetch(ctx context.Context) (map[string]float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch the prices: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePrices(data)
+}
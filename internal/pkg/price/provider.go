@@ -0,0 +1,61 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"math/big"
+)
+
+// ErrNoPrice is returned for the assets a provider has no price of.
+var ErrNoPrice = errors.New("no price")
+
+// Provider returns the USD price of the assets, identified by a provider
+// defined id such as a symbol.
+type Provider interface {
+	Price(ctx context.Context, id string) (float64, error)
+}
+
+// Chain asks the providers in order, returning the first price found.
+type Chain []Provider
+
+func (c Chain) Price(ctx context.Context, id string) (float64, error) {
+	var errs []error
+	for _, provider := range c {
+		price, err := provider.Price(ctx, id)
+		if err == nil {
+			return price, nil
+		}
+
+		if !errors.Is(err, ErrNoPrice) {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return 0, errors.Join(errs...)
+	}
+
+	return 0, ErrNoPrice
+}
+
+// Static serves a fixed price table.
+type Static map[string]float64
+
+func (s Static) Price(_ context.Context, id string) (float64, error) {
+	price, ok := s[id]
+	if !ok {
+		return 0, ErrNoPrice
+	}
+
+	return price, nil
+}
+
+// Value returns the USD value of an amount of the token in its smallest unit.
+func Value(amount *big.Int, decimals int, price float64) float64 {
+	value := new(big.Float).SetInt(amount)
+	value.Quo(value, new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	value.Mul(value, big.NewFloat(price))
+
+	result, _ := value.Float64()
+	return result
+}
@@ -0,0 +1,130 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain_Price(t *testing.T) {
+	ctx := context.Background()
+
+	failing := providerFunc(func(context.Context, string) (float64, error) {
+		return 0, errors.New("unavailable")
+	})
+	chain := Chain{failing, Static{"ETH": 3000}, Static{"ETH": 1, "TON": 1.5}}
+
+	price, err := chain.Price(ctx, "ETH")
+	require.NoError(t, err)
+	assert.Equal(t, 3000.0, price)
+
+	price, err = chain.Price(ctx, "TON")
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, price)
+
+	_, err = chain.Price(ctx, "MKR")
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrNoPrice))
+
+	_, err = Chain{Static{}}.Price(ctx, "MKR")
+	require.ErrorIs(t, err, ErrNoPrice)
+}
+
+func TestFile_Price(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "prices.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"ETH": 3000, "ethereum": {"usd": 3001.5}}`), 0o600))
+
+	file := NewFile(path)
+
+	price, err := file.Price(ctx, "ETH")
+	require.NoError(t, err)
+	assert.Equal(t, 3000.0, price)
+
+	price, err = file.Price(ctx, "ethereum")
+	require.NoError(t, err)
+	assert.Equal(t, 3001.5, price)
+
+	// the cron job rewrites the file
+	require.NoError(t, os.WriteFile(path, []byte(`{"ETH": 3100}`), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+
+	price, err = file.Price(ctx, "ETH")
+	require.NoError(t, err)
+	assert.Equal(t, 3100.0, price)
+
+	_, err = file.Price(ctx, "ethereum")
+	require.ErrorIs(t, err, ErrNoPrice)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"ETH": "cheap"}`), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now().Add(2*time.Minute), time.Now().Add(2*time.Minute)))
+
+	_, err = file.Price(ctx, "ETH")
+	require.Error(t, err)
+}
+
+func TestHTTP_Price(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		requests atomic.Int32
+		failing  atomic.Bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ethereum": {"usd": 3000}, "tokamak-network": {"usd": 1.5}}`))
+	}))
+	defer server.Close()
+
+	provider := NewHTTP(server.URL, time.Hour)
+
+	price, err := provider.Price(ctx, "ethereum")
+	require.NoError(t, err)
+	assert.Equal(t, 3000.0, price)
+
+	price, err = provider.Price(ctx, "tokamak-network")
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, price)
+	assert.Equal(t, int32(1), requests.Load())
+
+	_, err = provider.Price(ctx, "bitcoin")
+	require.ErrorIs(t, err, ErrNoPrice)
+
+	// the stale prices are kept when the api fails
+	provider.fetchedAt = time.Time{}
+	failing.Store(true)
+
+	price, err = provider.Price(ctx, "ethereum")
+	require.NoError(t, err)
+	assert.Equal(t, 3000.0, price)
+	assert.Equal(t, int32(2), requests.Load())
+
+	_, err = NewHTTP(server.URL, time.Hour).Price(ctx, "ethereum")
+	require.Error(t, err)
+}
+
+func TestValue(t *testing.T) {
+	amount, _ := new(big.Int).SetString("1500000000000000000", 10)
+	assert.InDelta(t, 4500.0, Value(amount, 18, 3000), 1e-9)
+	assert.InDelta(t, 2.5, Value(big.NewInt(2500000), 6, 1), 1e-9)
+}
+
+type providerFunc func(ctx context.Context, id string) (float64, error)
+
+func (f providerFunc) Price(ctx context.Context, id string) (float64, error) {
+	return f(ctx, id)
+}
@@ -0,0 +1,42 @@
+package publisher
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaTransport writes messages to a Kafka topic keyed by the message key, so
+// every message of an event lands on the same partition.
+type KafkaTransport struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaTransport(brokers []string, topic string) *KafkaTransport {
+	return &KafkaTransport{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			BatchTimeout: 10 * time.Millisecond,
+		},
+	}
+}
+
+func (t *KafkaTransport) Send(ctx context.Context, msg *Message, payload []byte) error {
+	return t.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(msg.Key),
+		Value: payload,
+		Headers: []kafka.Header{
+			{Key: "type", Value: []byte(msg.Type)},
+			{Key: "version", Value: []byte(strconv.Itoa(msg.Version))},
+		},
+	})
+}
+
+func (t *KafkaTransport) Close() error {
+	return t.writer.Close()
+}
@@ -0,0 +1,62 @@
+package publisher
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+const (
+	// MessageVersion is bumped on every breaking change of the message schema.
+	MessageVersion = 1
+
+	MessageTypeEvent     = "event"
+	MessageTypeTombstone = "tombstone"
+)
+
+// Message is the envelope of every published message. Consumers should
+// de-duplicate by Key: an event published again after a re-org keeps its key,
+// and a tombstone with the same key retracts the previously published event.
+type Message struct {
+	Version     int                `json:"version"`
+	Type        string             `json:"type"`
+	Key         string             `json:"key"`
+	Event       *types.BridgeEvent `json:"event,omitempty"`
+	Tombstone   *Tombstone         `json:"tombstone,omitempty"`
+	PublishedAt int64              `json:"published_at"`
+}
+
+type Tombstone struct {
+	ChainID          uint64      `json:"chain_id"`
+	RemovedBlockHash common.Hash `json:"removed_block_hash"`
+	NewBlockHash     common.Hash `json:"new_block_hash"`
+	BlockNumber      uint64      `json:"block_number"`
+}
+
+func NewEventMessage(event *types.BridgeEvent) *Message {
+	return &Message{
+		Version:     MessageVersion,
+		Type:        MessageTypeEvent,
+		Key:         event.Key(),
+		Event:       event,
+		PublishedAt: time.Now().Unix(),
+	}
+}
+
+func NewTombstoneMessage(key string, chainID uint64, removedBlockHash common.Hash, newHeader *ethereumTypes.Header) *Message {
+	return &Message{
+		Version: MessageVersion,
+		Type:    MessageTypeTombstone,
+		Key:     key,
+		Tombstone: &Tombstone{
+			ChainID:          chainID,
+			RemovedBlockHash: removedBlockHash,
+			NewBlockHash:     newHeader.Hash(),
+			BlockNumber:      newHeader.Number.Uint64(),
+		},
+		PublishedAt: time.Now().Unix(),
+	}
+}
@@ -0,0 +1,124 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+const (
+	// maxTrackedBlocks bounds the blocks whose published keys are remembered
+	// for tombstones.
+	maxTrackedBlocks = 1024
+)
+
+type Transport interface {
+	Send(ctx context.Context, msg *Message, payload []byte) error
+	Close() error
+}
+
+type blockRef struct {
+	chainID   uint64
+	blockHash common.Hash
+}
+
+// Publisher emits every decoded event to the transports and retracts the
+// events of re-organized blocks with tombstones.
+type Publisher struct {
+	transports []Transport
+
+	mu        sync.Mutex
+	published map[blockRef][]string
+	order     []blockRef
+}
+
+func New(transports ...Transport) *Publisher {
+	return &Publisher{
+		transports: transports,
+		published:  make(map[blockRef][]string),
+	}
+}
+
+// Write publishes the event. An event sent by at least one transport is
+// retracted on re-organization even when the other transports failed.
+func (p *Publisher) Write(ctx context.Context, event *types.BridgeEvent) error {
+	msg := NewEventMessage(event)
+
+	sent, err := p.send(ctx, msg)
+	if sent > 0 {
+		p.track(blockRef{chainID: event.ChainID, blockHash: event.BlockHash}, msg.Key)
+	}
+
+	return err
+}
+
+// Retract publishes a tombstone for every event published from the removed block.
+func (p *Publisher) Retract(ctx context.Context, chainID uint64, removedBlockHash common.Hash, newHeader *ethereumTypes.Header) error {
+	ref := blockRef{chainID: chainID, blockHash: removedBlockHash}
+
+	p.mu.Lock()
+	keys := p.published[ref]
+	delete(p.published, ref)
+	p.mu.Unlock()
+
+	var errs []error
+	for _, key := range keys {
+		if _, err := p.send(ctx, NewTombstoneMessage(key, chainID, removedBlockHash, newHeader)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (p *Publisher) Close() error {
+	var errs []error
+	for _, transport := range p.transports {
+		if err := transport.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// send returns the number of transports which sent the message.
+func (p *Publisher) send(ctx context.Context, msg *Message) (int, error) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, transport := range p.transports {
+		if err := transport.Send(ctx, msg, payload); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+func (p *Publisher) track(ref blockRef, key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.published[ref]; !ok {
+		p.order = append(p.order, ref)
+	}
+	p.published[ref] = append(p.published[ref], key)
+
+	for len(p.order) > maxTrackedBlocks {
+		delete(p.published, p.order[0])
+		p.order = p.order[1:]
+	}
+}
@@ -0,0 +1,137 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+const testStream = "bridge-events"
+
+func readMessages(t *testing.T, client redis.UniversalClient) []*Message {
+	entries, err := client.XRange(context.Background(), testStream, "-", "+").Result()
+	require.NoError(t, err)
+
+	messages := make([]*Message, 0, len(entries))
+	for _, entry := range entries {
+		var msg Message
+		require.NoError(t, json.Unmarshal([]byte(entry.Values["payload"].(string)), &msg))
+		assert.Equal(t, msg.Key, entry.Values["key"])
+		assert.Equal(t, msg.Type, entry.Values["type"])
+
+		messages = append(messages, &msg)
+	}
+
+	return messages
+}
+
+func TestPublisher_RedisStream(t *testing.T) {
+	ctx := context.Background()
+
+	server := miniredis.RunT(t)
+	client := redis.NewUniversalClient(&redis.UniversalOptions{Addrs: []string{server.Addr()}})
+	t.Cleanup(func() { _ = client.Close() })
+
+	pub := New(NewRedisStreamTransport(client, testStream, 0))
+
+	amount, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	removedBlockHash := common.HexToHash("0xb1")
+	event := &types.BridgeEvent{
+		ChainID:     111551119090,
+		BlockNumber: 10,
+		BlockHash:   removedBlockHash,
+		TxHash:      common.HexToHash("0x7a"),
+		LogIndex:    3,
+		Direction:   types.DirectionDeposit,
+		Amount:      amount,
+	}
+
+	require.NoError(t, pub.Write(ctx, event))
+
+	// events of other blocks are not retracted
+	require.NoError(t, pub.Retract(ctx, event.ChainID, common.HexToHash("0xb2"), &ethereumTypes.Header{Number: big.NewInt(10)}))
+
+	newHeader := &ethereumTypes.Header{Number: big.NewInt(10), ParentHash: common.HexToHash("0x09")}
+	require.NoError(t, pub.Retract(ctx, event.ChainID, removedBlockHash, newHeader))
+
+	// the same block is retracted only once
+	require.NoError(t, pub.Retract(ctx, event.ChainID, removedBlockHash, newHeader))
+
+	messages := readMessages(t, client)
+	require.Len(t, messages, 2)
+
+	assert.Equal(t, MessageVersion, messages[0].Version)
+	assert.Equal(t, MessageTypeEvent, messages[0].Type)
+	assert.Equal(t, "111551119090:0x000000000000000000000000000000000000000000000000000000000000007a:3", messages[0].Key)
+	require.NotNil(t, messages[0].Event)
+	assert.Equal(t, 0, amount.Cmp(messages[0].Event.Amount))
+	assert.Equal(t, removedBlockHash, messages[0].Event.BlockHash)
+
+	assert.Equal(t, MessageTypeTombstone, messages[1].Type)
+	assert.Equal(t, messages[0].Key, messages[1].Key)
+	require.NotNil(t, messages[1].Tombstone)
+	assert.Equal(t, removedBlockHash, messages[1].Tombstone.RemovedBlockHash)
+	assert.Equal(t, newHeader.Hash(), messages[1].Tombstone.NewBlockHash)
+}
+
+// failingTransport fails every send.
+type failingTransport struct{}
+
+func (failingTransport) Send(context.Context, *Message, []byte) error {
+	return errors.New("broker unavailable")
+}
+
+func (failingTransport) Close() error {
+	return nil
+}
+
+func TestPublisher_partialFailure(t *testing.T) {
+	ctx := context.Background()
+
+	server := miniredis.RunT(t)
+	client := redis.NewUniversalClient(&redis.UniversalOptions{Addrs: []string{server.Addr()}})
+	t.Cleanup(func() { _ = client.Close() })
+
+	pub := New(failingTransport{}, NewRedisStreamTransport(client, testStream, 0))
+
+	removedBlockHash := common.HexToHash("0xb1")
+	event := &types.BridgeEvent{ChainID: 1, BlockHash: removedBlockHash, TxHash: common.HexToHash("0x7a"), Amount: big.NewInt(1)}
+
+	require.Error(t, pub.Write(ctx, event))
+
+	// the event sent by the redis stream is still retracted
+	require.Error(t, pub.Retract(ctx, event.ChainID, removedBlockHash, &ethereumTypes.Header{Number: big.NewInt(10)}))
+
+	messages := readMessages(t, client)
+	require.Len(t, messages, 2)
+	assert.Equal(t, MessageTypeTombstone, messages[1].Type)
+	assert.Equal(t, messages[0].Key, messages[1].Key)
+
+	// an event sent by no transport isn't tracked
+	failed := New(failingTransport{})
+	require.Error(t, failed.Write(ctx, event))
+	assert.Empty(t, failed.published)
+}
+
+func TestPublisher_trackIsBounded(t *testing.T) {
+	pub := New()
+
+	for i := 0; i < maxTrackedBlocks+10; i++ {
+		pub.track(blockRef{chainID: 1, blockHash: common.BigToHash(big.NewInt(int64(i)))}, "key")
+	}
+
+	assert.Len(t, pub.published, maxTrackedBlocks)
+	assert.Len(t, pub.order, maxTrackedBlocks)
+	assert.NotContains(t, pub.published, blockRef{chainID: 1, blockHash: common.BigToHash(big.NewInt(0))})
+}
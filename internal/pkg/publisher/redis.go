@@ -0,0 +1,49 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	DefaultRedisStreamMaxLen = 100000
+)
+
+// RedisStreamTransport appends messages to a Redis Stream. The stream is
+// trimmed approximately to maxLen entries.
+type RedisStreamTransport struct {
+	redisClient redis.UniversalClient
+	stream      string
+	maxLen      int64
+}
+
+func NewRedisStreamTransport(redisClient redis.UniversalClient, stream string, maxLen int64) *RedisStreamTransport {
+	if maxLen <= 0 {
+		maxLen = DefaultRedisStreamMaxLen
+	}
+
+	return &RedisStreamTransport{
+		redisClient: redisClient,
+		stream:      stream,
+		maxLen:      maxLen,
+	}
+}
+
+func (t *RedisStreamTransport) Send(ctx context.Context, msg *Message, payload []byte) error {
+	return t.redisClient.XAdd(ctx, &redis.XAddArgs{
+		Stream: t.stream,
+		MaxLen: t.maxLen,
+		Approx: true,
+		Values: map[string]any{
+			"key":     msg.Key,
+			"type":    msg.Type,
+			"version": msg.Version,
+			"payload": payload,
+		},
+	}).Err()
+}
+
+func (t *RedisStreamTransport) Close() error {
+	return nil
+}
@@ -2,27 +2,114 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
+	"os"
 	"strings"
 
 	"github.com/go-redis/redis/v8"
 )
 
 func New(ctx context.Context, redisConfig Config) (redis.UniversalClient, error) {
-	redisAddresses := strings.Split(redisConfig.Addresses, ",")
+	redisAddresses := splitAddresses(redisConfig.Addresses)
 	if len(redisAddresses) == 0 {
 		return nil, errors.New("redis host is empty")
 	}
 
-	redisClient := redis.NewUniversalClient(&redis.UniversalOptions{
-		Password: redisConfig.Password,
-		Addrs:    redisAddresses,
-		DB:       redisConfig.DB,
-	})
+	tlsConfig, err := newTLSConfig(redisConfig.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &redis.UniversalOptions{
+		Addrs:            redisAddresses,
+		DB:               redisConfig.DB,
+		Username:         redisConfig.Username,
+		Password:         redisConfig.Password,
+		SentinelUsername: redisConfig.SentinelUsername,
+		SentinelPassword: redisConfig.SentinelPassword,
+		MasterName:       redisConfig.MasterName,
+		DialTimeout:      redisConfig.DialTimeout,
+		ReadTimeout:      redisConfig.ReadTimeout,
+		WriteTimeout:     redisConfig.WriteTimeout,
+		PoolSize:         redisConfig.PoolSize,
+		MinIdleConns:     redisConfig.MinIdleConns,
+		TLSConfig:        tlsConfig,
+	}
+
+	var redisClient redis.UniversalClient
+	switch redisConfig.Mode {
+	case "":
+		redisClient = redis.NewUniversalClient(options)
+	case ModeStandalone:
+		if len(redisAddresses) != 1 {
+			return nil, fmt.Errorf("standalone redis expects a single address, got %d", len(redisAddresses))
+		}
+		redisClient = redis.NewClient(options.Simple())
+	case ModeSentinel:
+		if redisConfig.MasterName == "" {
+			return nil, errors.New("redis master name is required in sentinel mode")
+		}
+		redisClient = redis.NewFailoverClient(options.Failover())
+	case ModeCluster:
+		redisClient = redis.NewClusterClient(options.Cluster())
+	default:
+		return nil, fmt.Errorf("unknown redis mode: %s", redisConfig.Mode)
+	}
 
 	if _, err := redisClient.Ping(ctx).Result(); err != nil {
+		_ = redisClient.Close()
 		return nil, err
 	}
 
 	return redisClient, nil
 }
+
+func splitAddresses(addresses string) []string {
+	var result []string
+	for _, address := range strings.Split(addresses, ",") {
+		address = strings.TrimSpace(address)
+		if address != "" {
+			result = append(result, address)
+		}
+	}
+
+	return result
+}
+
+func newTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the redis CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in the redis CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the redis client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,176 @@
+package redis
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_Standalone(t *testing.T) {
+	ctx := context.Background()
+
+	server := miniredis.RunT(t)
+	server.RequireUserAuth("listener", "secret")
+
+	client, err := New(ctx, Config{
+		Mode:         ModeStandalone,
+		Addresses:    server.Addr(),
+		Username:     "listener",
+		Password:     "secret",
+		DialTimeout:  time.Second,
+		ReadTimeout:  time.Second,
+		WriteTimeout: time.Second,
+		PoolSize:     2,
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	require.NoError(t, client.Set(ctx, "key", "value", 0).Err())
+	assert.Equal(t, "value", mustGet(t, server, "key"))
+
+	_, err = New(ctx, Config{
+		Mode:      ModeStandalone,
+		Addresses: server.Addr(),
+		Username:  "listener",
+		Password:  "wrong",
+	})
+	require.Error(t, err)
+}
+
+func TestNew_TLS(t *testing.T) {
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	serverCert, caFile := newTestCertificate(t, dir)
+
+	server, err := miniredis.RunTLS(&tls.Config{Certificates: []tls.Certificate{serverCert}})
+	require.NoError(t, err)
+	defer server.Close()
+	server.RequireAuth("secret")
+
+	client, err := New(ctx, Config{
+		Mode:      ModeStandalone,
+		Addresses: server.Addr(),
+		Password:  "secret",
+		TLS: TLSConfig{
+			Enabled:    true,
+			CAFile:     caFile,
+			ServerName: "localhost",
+		},
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	require.NoError(t, client.Ping(ctx).Err())
+
+	// the server certificate is not trusted without the CA
+	_, err = New(ctx, Config{
+		Mode:      ModeStandalone,
+		Addresses: server.Addr(),
+		Password:  "secret",
+		TLS: TLSConfig{
+			Enabled:    true,
+			ServerName: "localhost",
+		},
+		DialTimeout: time.Second,
+	})
+	require.Error(t, err)
+}
+
+func TestNew_Cluster(t *testing.T) {
+	ctx := context.Background()
+
+	server := miniredis.RunT(t)
+
+	client, err := New(ctx, Config{
+		Mode:      ModeCluster,
+		Addresses: server.Addr(),
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	require.NoError(t, client.Set(ctx, "key", "value", 0).Err())
+	assert.Equal(t, "value", mustGet(t, server, "key"))
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	ctx := context.Background()
+
+	server := miniredis.RunT(t)
+
+	testCases := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "empty address", cfg: Config{Addresses: " , "}},
+		{name: "unknown mode", cfg: Config{Mode: "replica", Addresses: server.Addr()}},
+		{name: "standalone with many addresses", cfg: Config{Mode: ModeStandalone, Addresses: server.Addr() + "," + server.Addr()}},
+		{name: "sentinel without master name", cfg: Config{Mode: ModeSentinel, Addresses: server.Addr()}},
+		{name: "missing CA file", cfg: Config{Addresses: server.Addr(), TLS: TLSConfig{Enabled: true, CAFile: "/does/not/exist"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(ctx, tc.cfg)
+			require.Error(t, err)
+		})
+	}
+}
+
+func mustGet(t *testing.T, server *miniredis.Miniredis, key string) string {
+	value, err := server.Get(key)
+	require.NoError(t, err)
+	return value
+}
+
+// newTestCertificate creates a self-signed certificate for localhost and
+// writes it to dir as the CA file.
+func newTestCertificate(t *testing.T, dir string) (tls.Certificate, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, certPEM, 0o600))
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	return cert, caFile
+}
@@ -1,8 +1,43 @@
 package redis
 
+import "time"
+
+const (
+	// ModeStandalone connects to a single redis node.
+	ModeStandalone = "standalone"
+	// ModeSentinel connects to the master MasterName through the sentinels listed in Addresses.
+	ModeSentinel = "sentinel"
+	// ModeCluster connects to a redis cluster seeded by Addresses.
+	ModeCluster = "cluster"
+)
+
 type Config struct {
-	Addresses  string `json:"addresses"`
-	Password   string `json:"password"`
-	MasterName string `json:"master_name"`
-	DB         int    `json:"db"`
+	// Mode is one of standalone, sentinel or cluster. When empty, the client
+	// type is inferred from the number of addresses and MasterName.
+	Mode       string `json:"mode" yaml:"mode" toml:"mode"`
+	Addresses  string `json:"addresses" yaml:"addresses" toml:"addresses"`
+	Username   string `json:"username" yaml:"username" toml:"username"`
+	Password   string `json:"-" yaml:"password" toml:"password"`
+	MasterName string `json:"master_name" yaml:"master_name" toml:"master_name"`
+	DB         int    `json:"db" yaml:"db" toml:"db"`
+
+	SentinelUsername string `json:"sentinel_username" yaml:"sentinel_username" toml:"sentinel_username"`
+	SentinelPassword string `json:"-" yaml:"sentinel_password" toml:"sentinel_password"`
+
+	TLS TLSConfig `json:"tls" yaml:"tls" toml:"tls"`
+
+	DialTimeout  time.Duration `json:"dial_timeout" yaml:"dial_timeout" toml:"dial_timeout"`
+	ReadTimeout  time.Duration `json:"read_timeout" yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout time.Duration `json:"write_timeout" yaml:"write_timeout" toml:"write_timeout"`
+	PoolSize     int           `json:"pool_size" yaml:"pool_size" toml:"pool_size"`
+	MinIdleConns int           `json:"min_idle_conns" yaml:"min_idle_conns" toml:"min_idle_conns"`
+}
+
+type TLSConfig struct {
+	Enabled            bool   `json:"enabled" yaml:"enabled" toml:"enabled"`
+	CAFile             string `json:"ca_file" yaml:"ca_file" toml:"ca_file"`
+	CertFile           string `json:"cert_file" yaml:"cert_file" toml:"cert_file"`
+	KeyFile            string `json:"key_file" yaml:"key_file" toml:"key_file"`
+	ServerName         string `json:"server_name" yaml:"server_name" toml:"server_name"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify" toml:"insecure_skip_verify"`
 }
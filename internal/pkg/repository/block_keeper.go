@@ -120,10 +120,17 @@ func (bk *BlockKeeper) SetHead(ctx context.Context, header *ethereumTypes.Header
 		bk.q.Enqueue(header.Hash().String())
 	}
 
+	// keep the canonical hash of the recent blocks, so a re-org can tell which block it replaced
+	blockNo := header.Number.Uint64()
+	bk.blocks[blockNo] = header.Hash()
+	if blockNo >= TwoEpochBlocks {
+		delete(bk.blocks, blockNo-TwoEpochBlocks)
+	}
+
 	err := bk.syncBlockMetadataKeeper.SetHead(ctx, header.Hash().String())
 	if err != nil {
 		log.GetLogger().Errorw("Failed to set head", "err", err)
-		return nil
+		return err
 	}
 
 	return nil
@@ -0,0 +1,13 @@
+package repository
+
+const (
+	StorageTypeRedis    = "redis"
+	StorageTypeFile     = "file"
+	StorageTypePostgres = "postgres"
+)
+
+type StorageConfig struct {
+	Type        string `json:"type" yaml:"type" toml:"type"`
+	FileDir     string `json:"file_dir" yaml:"file_dir" toml:"file_dir"`
+	PostgresDSN string `json:"-" yaml:"postgres_dsn" toml:"postgres_dsn"`
+}
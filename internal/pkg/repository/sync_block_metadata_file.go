@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type fileSyncBlockMetadata struct {
+	Head string `json:"head"`
+}
+
+// FileSyncBlockMetadataRepository keeps the sync block metadata in a JSON file
+// per prefix. The file is replaced atomically, so a crash in the middle of a
+// write never leaves a truncated file behind.
+type FileSyncBlockMetadataRepository struct {
+	prefix string
+	dir    string
+	mu     sync.Mutex
+}
+
+func NewFileSyncBlockMetadataRepository(prefix string, dir string) (*FileSyncBlockMetadataRepository, error) {
+	if dir == "" {
+		return nil, errors.New("storage directory is empty")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileSyncBlockMetadataRepository{
+		prefix: prefix,
+		dir:    dir,
+	}, nil
+}
+
+func (r *FileSyncBlockMetadataRepository) GetHead(_ context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := os.ReadFile(r.getPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	var metadata fileSyncBlockMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", r.getPath(), err)
+	}
+
+	return metadata.Head, nil
+}
+
+func (r *FileSyncBlockMetadataRepository) SetHead(_ context.Context, blockHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := json.Marshal(fileSyncBlockMetadata{Head: blockHash})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(r.dir, filepath.Base(r.getPath())+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), r.getPath())
+}
+
+func (r *FileSyncBlockMetadataRepository) getPath() string {
+	name := strings.NewReplacer(":", "_", "/", "_").Replace(r.prefix)
+	return filepath.Join(r.dir, fmt.Sprintf("%s.%s.json", name, syncBlockMetadataKey))
+}
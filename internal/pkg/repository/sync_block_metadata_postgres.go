@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const (
+	syncBlockMetadataTable = "sync_block_metadata"
+)
+
+type PostgresSyncBlockMetadataRepository struct {
+	prefix string
+	db     *sql.DB
+}
+
+func NewPostgresSyncBlockMetadataRepository(ctx context.Context, prefix string, db *sql.DB) (*PostgresSyncBlockMetadataRepository, error) {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		key TEXT PRIMARY KEY,
+		block_hash TEXT NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`, syncBlockMetadataTable)
+
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return nil, err
+	}
+
+	return &PostgresSyncBlockMetadataRepository{
+		prefix: prefix,
+		db:     db,
+	}, nil
+}
+
+func (r *PostgresSyncBlockMetadataRepository) GetHead(ctx context.Context) (string, error) {
+	query := fmt.Sprintf(`SELECT block_hash FROM %s WHERE key = $1`, syncBlockMetadataTable)
+
+	var result string
+	err := r.db.QueryRowContext(ctx, query, r.getKey()).Scan(&result)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return result, nil
+}
+
+func (r *PostgresSyncBlockMetadataRepository) SetHead(ctx context.Context, blockHash string) error {
+	query := fmt.Sprintf(`INSERT INTO %s (key, block_hash, updated_at) VALUES ($1, $2, now())
+		ON CONFLICT (key) DO UPDATE SET block_hash = EXCLUDED.block_hash, updated_at = EXCLUDED.updated_at`, syncBlockMetadataTable)
+
+	_, err := r.db.ExecContext(ctx, query, r.getKey(), blockHash)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresSyncBlockMetadataRepository) getKey() string {
+	return fmt.Sprintf("%s:%s", r.prefix, syncBlockMetadataKey)
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/database"
+)
+
+// newKeeperFunc returns a keeper for the prefix. Keepers returned by the same
+// function share one backing store.
+type newKeeperFunc func(t *testing.T, prefix string) SyncBlockMetadataKeeper
+
+func testSyncBlockMetadataKeeper(t *testing.T, newKeeper newKeeperFunc) {
+	ctx := context.Background()
+
+	t.Run("empty head", func(t *testing.T) {
+		keeper := newKeeper(t, "empty:l1")
+
+		head, err := keeper.GetHead(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "", head)
+	})
+
+	t.Run("set and overwrite head", func(t *testing.T) {
+		keeper := newKeeper(t, "overwrite:l1")
+
+		require.NoError(t, keeper.SetHead(ctx, "0x01"))
+		head, err := keeper.GetHead(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "0x01", head)
+
+		require.NoError(t, keeper.SetHead(ctx, "0x02"))
+		head, err = keeper.GetHead(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "0x02", head)
+	})
+
+	t.Run("prefixes are isolated", func(t *testing.T) {
+		l1Keeper := newKeeper(t, "isolated:l1")
+		l2Keeper := newKeeper(t, "isolated:l2")
+
+		require.NoError(t, l1Keeper.SetHead(ctx, "0x0a"))
+		require.NoError(t, l2Keeper.SetHead(ctx, "0x0b"))
+
+		head, err := l1Keeper.GetHead(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "0x0a", head)
+
+		head, err = l2Keeper.GetHead(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "0x0b", head)
+	})
+
+	t.Run("head survives a new instance", func(t *testing.T) {
+		require.NoError(t, newKeeper(t, "persist:l1").SetHead(ctx, "0xff"))
+
+		head, err := newKeeper(t, "persist:l1").GetHead(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "0xff", head)
+	})
+}
+
+func TestSyncBlockMetadataRepository_Redis(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewUniversalClient(&redis.UniversalOptions{Addrs: []string{server.Addr()}})
+	t.Cleanup(func() { _ = client.Close() })
+
+	testSyncBlockMetadataKeeper(t, func(t *testing.T, prefix string) SyncBlockMetadataKeeper {
+		return NewSyncBlockMetadataRepository(prefix, client)
+	})
+}
+
+func TestSyncBlockMetadataRepository_File(t *testing.T) {
+	dir := t.TempDir()
+
+	testSyncBlockMetadataKeeper(t, func(t *testing.T, prefix string) SyncBlockMetadataKeeper {
+		keeper, err := NewFileSyncBlockMetadataRepository(prefix, dir)
+		require.NoError(t, err)
+		return keeper
+	})
+}
+
+func TestSyncBlockMetadataRepository_Postgres(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	ctx := context.Background()
+	db, err := database.New(ctx, database.Config{Driver: database.DriverPostgres, DSN: dsn})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = db.Exec(`DROP TABLE IF EXISTS ` + syncBlockMetadataTable)
+		_ = db.Close()
+	})
+
+	_, err = db.Exec(`DROP TABLE IF EXISTS ` + syncBlockMetadataTable)
+	require.NoError(t, err)
+
+	testSyncBlockMetadataKeeper(t, func(t *testing.T, prefix string) SyncBlockMetadataKeeper {
+		keeper, err := NewPostgresSyncBlockMetadataRepository(ctx, prefix, db)
+		require.NoError(t, err)
+		return keeper
+	})
+}
@@ -0,0 +1,132 @@
+package solvency
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tokamak-network/tokamak-thanos/op-bindings/bindings"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/erc20"
+)
+
+// Condition is a mismatch between the L1 custody and the L2 supply of a token.
+type Condition string
+
+const (
+	// ConditionDeficit is the L2 supply above the L1 custody.
+	ConditionDeficit Condition = "deficit"
+	// ConditionSurplus is the L1 custody above the L2 supply, such as the
+	// withdrawals waiting for their finalization.
+	ConditionSurplus Condition = "surplus"
+	// ConditionShortfall is the balance of the L1StandardBridge below the
+	// deposits of the token.
+	ConditionShortfall Condition = "shortfall"
+)
+
+// Conditions lists the conditions in the order they are checked.
+var Conditions = []Condition{ConditionDeficit, ConditionSurplus, ConditionShortfall}
+
+// Backend reads the contracts and the ETH balances of a chain.
+type Backend interface {
+	bind.ContractCaller
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+}
+
+// Bridge holds the L1 contracts of a rollup keeping the bridged tokens.
+type Bridge struct {
+	L1StandardBridge common.Address
+	OptimismPortal   common.Address
+}
+
+// Token is a token bridged to an OptimismMintableERC20, the zero L1 address
+// being the ETH.
+type Token struct {
+	L1Token common.Address
+	L2Token common.Address
+}
+
+// Balance is the L1 custody of a token and its L2 supply.
+type Balance struct {
+	// Custody is the deposits mapping of the L1StandardBridge for the token
+	// pair, or the ETH balance of the OptimismPortal.
+	Custody *big.Int
+	// Held is the balance of the token of the L1StandardBridge, nil for the
+	// ETH.
+	Held *big.Int
+	// Supply is the total supply of the L2 token.
+	Supply *big.Int
+}
+
+// Fetch reads the latest balance of the token.
+func Fetch(ctx context.Context, l1, l2 Backend, bridge Bridge, token Token) (*Balance, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	balance := &Balance{}
+
+	if token.L1Token == (common.Address{}) {
+		custody, err := l1.BalanceAt(ctx, bridge.OptimismPortal, nil)
+		if err != nil {
+			return nil, err
+		}
+		balance.Custody = custody
+	} else {
+		l1Bridge, err := bindings.NewL1StandardBridgeCaller(bridge.L1StandardBridge, l1)
+		if err != nil {
+			return nil, err
+		}
+
+		custody, err := l1Bridge.Deposits(opts, token.L1Token, token.L2Token)
+		if err != nil {
+			return nil, err
+		}
+		balance.Custody = custody
+
+		l1Token, err := erc20.NewErc20Caller(token.L1Token, l1)
+		if err != nil {
+			return nil, err
+		}
+
+		held, err := l1Token.BalanceOf(opts, bridge.L1StandardBridge)
+		if err != nil {
+			return nil, err
+		}
+		balance.Held = held
+	}
+
+	l2Token, err := erc20.NewErc20Caller(token.L2Token, l2)
+	if err != nil {
+		return nil, err
+	}
+
+	supply, err := l2Token.TotalSupply(opts)
+	if err != nil {
+		return nil, err
+	}
+	balance.Supply = supply
+
+	return balance, nil
+}
+
+// Difference returns the custody minus the supply.
+func (b *Balance) Difference() *big.Int {
+	return new(big.Int).Sub(b.Custody, b.Supply)
+}
+
+// Check reports the conditions holding. The deficit and the shortfall are
+// tolerated up to the tolerance fraction of the custody, and the surplus up
+// to the surplus fraction, not checked when 0.
+func (b *Balance) Check(tolerance, surplus float64) map[Condition]bool {
+	return map[Condition]bool{
+		ConditionDeficit:   exceeds(b.Supply, b.Custody, tolerance),
+		ConditionSurplus:   surplus > 0 && exceeds(b.Custody, b.Supply, surplus),
+		ConditionShortfall: b.Held != nil && exceeds(b.Custody, b.Held, tolerance),
+	}
+}
+
+// exceeds reports whether a is above b by more than the fraction of b.
+func exceeds(a, b *big.Int, fraction float64) bool {
+	margin, _ := new(big.Float).Mul(new(big.Float).SetInt(b), big.NewFloat(fraction)).Int(nil)
+
+	return a.Cmp(new(big.Int).Add(b, margin)) > 0
+}
@@ -0,0 +1,103 @@
+package solvency
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tokamak-network/tokamak-thanos/op-bindings/bindings"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/erc20"
+)
+
+// testBackend answers the calls by contract and method name.
+type testBackend struct {
+	t        *testing.T
+	results  map[common.Address]map[string]*big.Int
+	balances map[common.Address]*big.Int
+}
+
+func (b *testBackend) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{0x01}, nil
+}
+
+func (b *testBackend) CallContract(_ context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	erc20ABI, err := erc20.Erc20MetaData.GetAbi()
+	require.NoError(b.t, err)
+	bridgeABI, err := bindings.L1StandardBridgeMetaData.GetAbi()
+	require.NoError(b.t, err)
+
+	method, err := erc20ABI.MethodById(call.Data[:4])
+	if err != nil {
+		method, err = bridgeABI.MethodById(call.Data[:4])
+		require.NoError(b.t, err)
+	}
+
+	return method.Outputs.Pack(b.results[*call.To][method.Name])
+}
+
+func (b *testBackend) BalanceAt(_ context.Context, account common.Address, _ *big.Int) (*big.Int, error) {
+	return b.balances[account], nil
+}
+
+func TestFetch(t *testing.T) {
+	bridge := Bridge{
+		L1StandardBridge: common.HexToAddress("0xb1"),
+		OptimismPortal:   common.HexToAddress("0xb2"),
+	}
+	usdc := Token{L1Token: common.HexToAddress("0xa1"), L2Token: common.HexToAddress("0xa2")}
+	eth := Token{L2Token: common.HexToAddress("0xe2")}
+
+	l1 := &testBackend{
+		t: t,
+		results: map[common.Address]map[string]*big.Int{
+			bridge.L1StandardBridge: {"deposits": big.NewInt(1000)},
+			usdc.L1Token:            {"balanceOf": big.NewInt(1200)},
+		},
+		balances: map[common.Address]*big.Int{bridge.OptimismPortal: big.NewInt(500)},
+	}
+	l2 := &testBackend{
+		t: t,
+		results: map[common.Address]map[string]*big.Int{
+			usdc.L2Token: {"totalSupply": big.NewInt(900)},
+			eth.L2Token:  {"totalSupply": big.NewInt(510)},
+		},
+	}
+
+	balance, err := Fetch(context.Background(), l1, l2, bridge, usdc)
+	require.NoError(t, err)
+	assert.Equal(t, &Balance{Custody: big.NewInt(1000), Held: big.NewInt(1200), Supply: big.NewInt(900)}, balance)
+	assert.Equal(t, big.NewInt(100), balance.Difference())
+
+	balance, err = Fetch(context.Background(), l1, l2, bridge, eth)
+	require.NoError(t, err)
+	assert.Equal(t, &Balance{Custody: big.NewInt(500), Supply: big.NewInt(510)}, balance)
+}
+
+func TestBalance_Check(t *testing.T) {
+	balance := &Balance{Custody: big.NewInt(1000), Held: big.NewInt(995), Supply: big.NewInt(1005)}
+
+	assert.Equal(t, map[Condition]bool{
+		ConditionDeficit:   false,
+		ConditionSurplus:   false,
+		ConditionShortfall: false,
+	}, balance.Check(0.01, 0))
+
+	assert.Equal(t, map[Condition]bool{
+		ConditionDeficit:   true,
+		ConditionSurplus:   false,
+		ConditionShortfall: true,
+	}, balance.Check(0, 0))
+
+	// the ETH has no bridge balance
+	balance = &Balance{Custody: big.NewInt(2000), Supply: big.NewInt(1000)}
+	assert.Equal(t, map[Condition]bool{
+		ConditionDeficit:   false,
+		ConditionSurplus:   true,
+		ConditionShortfall: false,
+	}, balance.Check(0, 0.5))
+}
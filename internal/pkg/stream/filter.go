@@ -0,0 +1,123 @@
+package stream
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+// Cursor is the position of an event on its chain. A subscription resumed from
+// a cursor receives the buffered events of the chain after it.
+type Cursor struct {
+	ChainID     uint64 `json:"chain_id"`
+	BlockNumber uint64 `json:"block_number"`
+	LogIndex    uint   `json:"log_index"`
+}
+
+// ParseCursor parses a cursor formatted as <chain_id>:<block_number>:<log_index>.
+func ParseCursor(v string) (Cursor, error) {
+	parts := strings.Split(v, ":")
+	if len(parts) != 3 {
+		return Cursor{}, fmt.Errorf("invalid cursor: %s", v)
+	}
+
+	chainID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor chain id: %w", err)
+	}
+
+	blockNumber, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor block number: %w", err)
+	}
+
+	logIndex, err := strconv.ParseUint(parts[2], 10, 32)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor log index: %w", err)
+	}
+
+	return Cursor{ChainID: chainID, BlockNumber: blockNumber, LogIndex: uint(logIndex)}, nil
+}
+
+func (c Cursor) String() string {
+	return fmt.Sprintf("%d:%d:%d", c.ChainID, c.BlockNumber, c.LogIndex)
+}
+
+// before reports whether the event comes after the cursor on the same chain.
+func (c Cursor) before(event *types.BridgeEvent) bool {
+	if event.ChainID != c.ChainID {
+		return false
+	}
+
+	if event.BlockNumber != c.BlockNumber {
+		return event.BlockNumber > c.BlockNumber
+	}
+
+	return event.LogIndex > c.LogIndex
+}
+
+// SubscribeRequest selects the events of a subscription. Empty fields match
+// every event.
+type SubscribeRequest struct {
+	// Address matches either the sender or the recipient.
+	Address string `json:"address,omitempty"`
+	// Token matches either the L1 or the L2 token.
+	Token     string   `json:"token,omitempty"`
+	Direction string   `json:"direction,omitempty"`
+	Cursors   []Cursor `json:"cursors,omitempty"`
+}
+
+func (r *SubscribeRequest) Validate() error {
+	if r.Address != "" && !common.IsHexAddress(r.Address) {
+		return fmt.Errorf("invalid address: %s", r.Address)
+	}
+
+	if r.Token != "" && !common.IsHexAddress(r.Token) {
+		return fmt.Errorf("invalid token: %s", r.Token)
+	}
+
+	switch r.Direction {
+	case "", types.DirectionDeposit, types.DirectionWithdrawal:
+	default:
+		return fmt.Errorf("invalid direction: %s", r.Direction)
+	}
+
+	return nil
+}
+
+func (r *SubscribeRequest) matches(event *types.BridgeEvent) bool {
+	if r.Address != "" {
+		address := common.HexToAddress(r.Address)
+		if event.From != address && event.To != address {
+			return false
+		}
+	}
+
+	if r.Token != "" {
+		token := common.HexToAddress(r.Token)
+		if event.L1Token != token && event.L2Token != token {
+			return false
+		}
+	}
+
+	if r.Direction != "" && event.Direction != r.Direction {
+		return false
+	}
+
+	return true
+}
+
+// replays reports whether the buffered event is replayed to the new subscription.
+func (r *SubscribeRequest) replays(event *types.BridgeEvent) bool {
+	for _, cursor := range r.Cursors {
+		if cursor.before(event) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,152 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/publisher"
+)
+
+// The gRPC service is described without protobuf: requests and messages are
+// JSON encoded, so clients have to call it with the "json" content subtype.
+// There is no .proto, so the endpoint is meant for the Go clients through
+// GRPCClient; grpcurl and the clients of the other languages, which need a
+// protobuf description, use the WebSocket endpoint instead.
+const (
+	GRPCServiceName     = "thanosnotif.stream.v1.BridgeEventStream"
+	GRPCSubscribeMethod = "/" + GRPCServiceName + "/Subscribe"
+	grpcContentSubtype  = "json"
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return grpcContentSubtype
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+type bridgeEventStreamServer interface {
+	Subscribe(req *SubscribeRequest, stream grpc.ServerStream) error
+}
+
+var grpcServiceDesc = grpc.ServiceDesc{
+	ServiceName: GRPCServiceName,
+	HandlerType: (*bridgeEventStreamServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       subscribeStreamHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+func subscribeStreamHandler(srv any, stream grpc.ServerStream) error {
+	req := new(SubscribeRequest)
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+
+	return srv.(bridgeEventStreamServer).Subscribe(req, stream)
+}
+
+type grpcService struct {
+	hub *Hub
+}
+
+func RegisterGRPCService(server *grpc.Server, hub *Hub) {
+	server.RegisterService(&grpcServiceDesc, &grpcService{hub: hub})
+}
+
+func (s *grpcService) Subscribe(req *SubscribeRequest, stream grpc.ServerStream) error {
+	sub, err := s.hub.Subscribe(req)
+	if err != nil {
+		if errors.Is(err, ErrHubClosed) {
+			return status.Error(codes.Unavailable, err.Error())
+		}
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	defer sub.Close()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				return subscriptionStatus(sub.Err())
+			}
+
+			if err := stream.SendMsg(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func subscriptionStatus(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrSlowConsumer):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+}
+
+type GRPCClient struct {
+	conn grpc.ClientConnInterface
+}
+
+func NewGRPCClient(conn grpc.ClientConnInterface) *GRPCClient {
+	return &GRPCClient{conn: conn}
+}
+
+type GRPCSubscription struct {
+	stream grpc.ClientStream
+}
+
+func (c *GRPCClient) Subscribe(ctx context.Context, req *SubscribeRequest) (*GRPCSubscription, error) {
+	stream, err := c.conn.NewStream(ctx, &grpcServiceDesc.Streams[0], GRPCSubscribeMethod, grpc.CallContentSubtype(grpcContentSubtype))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := stream.SendMsg(req); err != nil {
+		return nil, err
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	return &GRPCSubscription{stream: stream}, nil
+}
+
+func (s *GRPCSubscription) Recv() (*publisher.Message, error) {
+	msg := new(publisher.Message)
+	if err := s.stream.RecvMsg(msg); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
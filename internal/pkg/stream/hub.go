@@ -0,0 +1,184 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/publisher"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+const (
+	DefaultBufferSize   = 10000
+	subscriberQueueSize = 256
+)
+
+var (
+	ErrSlowConsumer = errors.New("subscriber can't keep up with the event stream")
+	ErrHubClosed    = errors.New("event stream is closed")
+)
+
+// Subscription delivers the messages matching its request until it is closed.
+type Subscription struct {
+	id  uint64
+	req *SubscribeRequest
+	hub *Hub
+	ch  chan *publisher.Message
+
+	closeOnce sync.Once
+	err       error
+}
+
+// Messages is closed when the subscription ends. Err tells why.
+func (s *Subscription) Messages() <-chan *publisher.Message {
+	return s.ch
+}
+
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	return s.err
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.unsubscribe(s, nil)
+}
+
+// Hub fans out the decoded events to the live subscriptions and keeps the
+// latest events in a buffer, so subscriptions can resume from a cursor.
+type Hub struct {
+	mu         sync.Mutex
+	buffer     []*types.BridgeEvent
+	bufferSize int
+	subs       map[uint64]*Subscription
+	nextID     uint64
+	closed     bool
+}
+
+func NewHub(bufferSize int) *Hub {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+
+	return &Hub{
+		buffer:     make([]*types.BridgeEvent, 0, bufferSize),
+		bufferSize: bufferSize,
+		subs:       make(map[uint64]*Subscription),
+	}
+}
+
+func (h *Hub) Subscribe(req *SubscribeRequest) (*Subscription, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrHubClosed
+	}
+
+	replay := make([]*types.BridgeEvent, 0)
+	for _, event := range h.buffer {
+		if req.replays(event) && req.matches(event) {
+			replay = append(replay, event)
+		}
+	}
+
+	h.nextID++
+	sub := &Subscription{
+		id:  h.nextID,
+		req: req,
+		hub: h,
+		ch:  make(chan *publisher.Message, len(replay)+subscriberQueueSize),
+	}
+
+	for _, event := range replay {
+		sub.ch <- publisher.NewEventMessage(event)
+	}
+
+	h.subs[sub.id] = sub
+
+	return sub, nil
+}
+
+func (h *Hub) Write(_ context.Context, event *types.BridgeEvent) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.buffer) == h.bufferSize {
+		copy(h.buffer, h.buffer[1:])
+		h.buffer = h.buffer[:len(h.buffer)-1]
+	}
+	h.buffer = append(h.buffer, event)
+
+	msg := publisher.NewEventMessage(event)
+	for _, sub := range h.subs {
+		if sub.req.matches(event) {
+			h.deliver(sub, msg)
+		}
+	}
+
+	return nil
+}
+
+// Retract drops the buffered events of the re-organized block and sends a
+// tombstone for each of them to the subscriptions matching it.
+func (h *Hub) Retract(_ context.Context, chainID uint64, removedBlockHash common.Hash, newHeader *ethereumTypes.Header) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	kept := h.buffer[:0]
+	for _, event := range h.buffer {
+		if event.ChainID != chainID || event.BlockHash != removedBlockHash {
+			kept = append(kept, event)
+			continue
+		}
+
+		msg := publisher.NewTombstoneMessage(event.Key(), chainID, removedBlockHash, newHeader)
+		for _, sub := range h.subs {
+			if sub.req.matches(event) {
+				h.deliver(sub, msg)
+			}
+		}
+	}
+	h.buffer = kept
+
+	return nil
+}
+
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, sub := range h.subs {
+		h.unsubscribe(sub, ErrHubClosed)
+	}
+}
+
+// deliver never blocks the event stream: a subscription whose queue is full is dropped.
+func (h *Hub) deliver(sub *Subscription, msg *publisher.Message) {
+	select {
+	case sub.ch <- msg:
+	default:
+		h.unsubscribe(sub, ErrSlowConsumer)
+	}
+}
+
+func (h *Hub) unsubscribe(sub *Subscription, err error) {
+	sub.closeOnce.Do(func() {
+		delete(h.subs, sub.id)
+		sub.err = err
+		close(sub.ch)
+	})
+}
@@ -0,0 +1,161 @@
+package stream
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/publisher"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+var (
+	alice = common.HexToAddress("0x00000000000000000000000000000000000a11ce")
+	bob   = common.HexToAddress("0x0000000000000000000000000000000000000b0b")
+	ton   = common.HexToAddress("0xa30fe40285B8f5c0457DbC3B7C8A280373c40044")
+)
+
+func newTestEvent(chainID, blockNumber uint64, logIndex uint, direction string, from common.Address) *types.BridgeEvent {
+	return &types.BridgeEvent{
+		ChainID:     chainID,
+		BlockNumber: blockNumber,
+		BlockHash:   common.BigToHash(new(big.Int).SetUint64(blockNumber)),
+		TxHash:      common.BigToHash(new(big.Int).SetUint64(blockNumber*100 + uint64(logIndex))),
+		LogIndex:    logIndex,
+		Direction:   direction,
+		From:        from,
+		To:          from,
+		L1Token:     ton,
+		Amount:      big.NewInt(1),
+	}
+}
+
+func drain(sub *Subscription) []*publisher.Message {
+	messages := make([]*publisher.Message, 0)
+	for {
+		select {
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				return messages
+			}
+			messages = append(messages, msg)
+		default:
+			return messages
+		}
+	}
+}
+
+func keys(messages []*publisher.Message) []string {
+	result := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		result = append(result, msg.Type+":"+msg.Key)
+	}
+	return result
+}
+
+func TestHub_Filters(t *testing.T) {
+	ctx := context.Background()
+	hub := NewHub(0)
+
+	byAddress, err := hub.Subscribe(&SubscribeRequest{Address: alice.Hex()})
+	require.NoError(t, err)
+	byDirection, err := hub.Subscribe(&SubscribeRequest{Direction: types.DirectionWithdrawal})
+	require.NoError(t, err)
+	byToken, err := hub.Subscribe(&SubscribeRequest{Token: bob.Hex()})
+	require.NoError(t, err)
+
+	deposit := newTestEvent(1, 10, 0, types.DirectionDeposit, alice)
+	withdrawal := newTestEvent(1, 10, 1, types.DirectionWithdrawal, bob)
+	require.NoError(t, hub.Write(ctx, deposit))
+	require.NoError(t, hub.Write(ctx, withdrawal))
+
+	assert.Equal(t, []string{"event:" + deposit.Key()}, keys(drain(byAddress)))
+	assert.Equal(t, []string{"event:" + withdrawal.Key()}, keys(drain(byDirection)))
+	assert.Empty(t, drain(byToken))
+
+	_, err = hub.Subscribe(&SubscribeRequest{Direction: "sideways"})
+	assert.Error(t, err)
+}
+
+func TestHub_ResumeFromCursor(t *testing.T) {
+	ctx := context.Background()
+	hub := NewHub(3)
+
+	events := []*types.BridgeEvent{
+		newTestEvent(1, 10, 0, types.DirectionDeposit, alice),
+		newTestEvent(1, 10, 1, types.DirectionDeposit, alice),
+		newTestEvent(2, 500, 0, types.DirectionDeposit, alice),
+		newTestEvent(1, 11, 0, types.DirectionDeposit, alice),
+	}
+	for _, event := range events {
+		require.NoError(t, hub.Write(ctx, event))
+	}
+
+	// the first event fell out of the buffer
+	sub, err := hub.Subscribe(&SubscribeRequest{Cursors: []Cursor{{ChainID: 1, BlockNumber: 9}}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"event:" + events[1].Key(), "event:" + events[3].Key()}, keys(drain(sub)))
+
+	sub, err = hub.Subscribe(&SubscribeRequest{Cursors: []Cursor{{ChainID: 1, BlockNumber: 10, LogIndex: 1}, {ChainID: 2, BlockNumber: 499}}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"event:" + events[2].Key(), "event:" + events[3].Key()}, keys(drain(sub)))
+
+	// live events follow the replayed ones
+	live := newTestEvent(1, 12, 0, types.DirectionDeposit, alice)
+	require.NoError(t, hub.Write(ctx, live))
+	assert.Equal(t, []string{"event:" + live.Key()}, keys(drain(sub)))
+}
+
+func TestHub_Retract(t *testing.T) {
+	ctx := context.Background()
+	hub := NewHub(0)
+
+	sub, err := hub.Subscribe(&SubscribeRequest{})
+	require.NoError(t, err)
+
+	event := newTestEvent(1, 10, 0, types.DirectionDeposit, alice)
+	require.NoError(t, hub.Write(ctx, event))
+
+	newHeader := &ethereumTypes.Header{Number: big.NewInt(10)}
+	require.NoError(t, hub.Retract(ctx, 1, event.BlockHash, newHeader))
+	assert.Equal(t, []string{"event:" + event.Key(), "tombstone:" + event.Key()}, keys(drain(sub)))
+
+	// retracted events are not replayed anymore
+	resumed, err := hub.Subscribe(&SubscribeRequest{Cursors: []Cursor{{ChainID: 1}}})
+	require.NoError(t, err)
+	assert.Empty(t, drain(resumed))
+}
+
+func TestHub_SlowConsumerIsDropped(t *testing.T) {
+	ctx := context.Background()
+	hub := NewHub(0)
+
+	sub, err := hub.Subscribe(&SubscribeRequest{})
+	require.NoError(t, err)
+
+	for i := 0; i <= subscriberQueueSize; i++ {
+		require.NoError(t, hub.Write(ctx, newTestEvent(1, uint64(i), 0, types.DirectionDeposit, alice)))
+	}
+
+	assert.Len(t, drain(sub), subscriberQueueSize)
+	_, ok := <-sub.Messages()
+	assert.False(t, ok)
+	assert.ErrorIs(t, sub.Err(), ErrSlowConsumer)
+}
+
+func TestParseCursor(t *testing.T) {
+	cursor, err := ParseCursor("111551119090:42:7")
+	require.NoError(t, err)
+	assert.Equal(t, Cursor{ChainID: 111551119090, BlockNumber: 42, LogIndex: 7}, cursor)
+	assert.Equal(t, "111551119090:42:7", cursor.String())
+
+	for _, v := range []string{"", "1:2", "a:2:3", "1:b:3", "1:2:c"} {
+		_, err := ParseCursor(v)
+		assert.Error(t, err, v)
+	}
+}
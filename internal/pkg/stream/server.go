@@ -0,0 +1,119 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	shutdownTimeout = 5 * time.Second
+)
+
+// Server serves the live event stream over gRPC and WebSocket. An empty
+// address disables the corresponding endpoint.
+type Server struct {
+	hub           *Hub
+	grpcAddr      string
+	websocketAddr string
+}
+
+func NewServer(hub *Hub, grpcAddr string, websocketAddr string) *Server {
+	return &Server{
+		hub:           hub,
+		grpcAddr:      grpcAddr,
+		websocketAddr: websocketAddr,
+	}
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	// ending the subscriptions lets the servers drain their open streams. The
+	// hub stays open when an endpoint fails, so the server can be restarted.
+	go func() {
+		<-ctx.Done()
+		s.hub.Close()
+	}()
+
+	g, groupCtx := errgroup.WithContext(ctx)
+
+	if s.grpcAddr != "" {
+		g.Go(func() error {
+			return s.startGRPC(groupCtx)
+		})
+	}
+
+	if s.websocketAddr != "" {
+		g.Go(func() error {
+			return s.startWebSocket(groupCtx)
+		})
+	}
+
+	return g.Wait()
+}
+
+func (s *Server) startGRPC(ctx context.Context) error {
+	lis, err := net.Listen("tcp", s.grpcAddr)
+	if err != nil {
+		return err
+	}
+
+	srv := grpc.NewServer()
+	RegisterGRPCService(srv, s.hub)
+
+	// the open streams are only ended by the hub on shutdown, not when the
+	// other endpoint fails
+	go func() {
+		<-ctx.Done()
+
+		stopped := make(chan struct{})
+		go func() {
+			srv.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(shutdownTimeout):
+			srv.Stop()
+		}
+	}()
+
+	log.GetLogger().Infow("Start the gRPC event stream server", "addr", s.grpcAddr)
+
+	return srv.Serve(lis)
+}
+
+func (s *Server) startWebSocket(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.Handle("/ws", WebSocketHandler(s.hub))
+
+	srv := &http.Server{
+		Addr:              s.websocketAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.GetLogger().Infow("Start the websocket event stream server", "addr", s.websocketAddr)
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
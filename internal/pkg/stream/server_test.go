@@ -0,0 +1,107 @@
+package stream
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/publisher"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+func TestWebSocketHandler(t *testing.T) {
+	ctx := context.Background()
+	hub := NewHub(0)
+
+	replayed := newTestEvent(1, 10, 0, types.DirectionDeposit, alice)
+	require.NoError(t, hub.Write(ctx, replayed))
+
+	server := httptest.NewServer(WebSocketHandler(hub))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL+"?direction=sideways", nil)
+	require.Error(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?address="+alice.Hex()+"&cursor=1:9:0", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// wait for the subscription to be registered before writing the live event
+	var msg publisher.Message
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, conn.ReadJSON(&msg))
+	assert.Equal(t, replayed.Key(), msg.Key)
+
+	require.NoError(t, hub.Write(ctx, newTestEvent(1, 11, 0, types.DirectionDeposit, bob)))
+	live := newTestEvent(1, 11, 1, types.DirectionWithdrawal, alice)
+	require.NoError(t, hub.Write(ctx, live))
+
+	require.NoError(t, conn.ReadJSON(&msg))
+	assert.Equal(t, publisher.MessageTypeEvent, msg.Type)
+	assert.Equal(t, live.Key(), msg.Key)
+	assert.Equal(t, live.Amount, msg.Event.Amount)
+}
+
+func TestGRPCService(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	hub := NewHub(0)
+	replayed := newTestEvent(1, 10, 0, types.DirectionDeposit, alice)
+	require.NoError(t, hub.Write(ctx, replayed))
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	RegisterGRPCService(srv, hub)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := NewGRPCClient(conn)
+
+	invalid, err := client.Subscribe(ctx, &SubscribeRequest{Token: "not-an-address"})
+	require.NoError(t, err)
+	_, err = invalid.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	sub, err := client.Subscribe(ctx, &SubscribeRequest{Direction: types.DirectionDeposit, Cursors: []Cursor{{ChainID: 1}}})
+	require.NoError(t, err)
+
+	msg, err := sub.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, replayed.Key(), msg.Key)
+
+	require.NoError(t, hub.Write(ctx, newTestEvent(1, 11, 0, types.DirectionWithdrawal, alice)))
+	live := newTestEvent(1, 11, 1, types.DirectionDeposit, bob)
+	require.NoError(t, hub.Write(ctx, live))
+
+	msg, err = sub.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, live.Key(), msg.Key)
+	assert.Equal(t, live.From, msg.Event.From)
+
+	hub.Close()
+	_, err = sub.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,115 @@
+package stream
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsPongTimeout  = 60 * time.Second
+	wsPingInterval = wsPongTimeout * 9 / 10
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// the feed is read-only public data, so it can be embedded in any frontend
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// WebSocketHandler serves GET /ws?address=&token=&direction=&cursor=<chain_id>:<block_number>:<log_index>
+// The cursor parameter can be repeated, once per chain.
+func WebSocketHandler(hub *Hub) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		req := &SubscribeRequest{
+			Address:   query.Get("address"),
+			Token:     query.Get("token"),
+			Direction: query.Get("direction"),
+		}
+
+		for _, v := range query["cursor"] {
+			cursor, err := ParseCursor(v)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			req.Cursors = append(req.Cursors, cursor)
+		}
+
+		if err := req.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.GetLogger().Errorw("Failed to upgrade the websocket connection", "error", err)
+			return
+		}
+		defer conn.Close()
+
+		sub, err := hub.Subscribe(req)
+		if err != nil {
+			_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, err.Error()), time.Now().Add(wsWriteTimeout))
+			return
+		}
+		defer sub.Close()
+
+		serveWebSocket(conn, sub)
+	})
+}
+
+func serveWebSocket(conn *websocket.Conn, sub *Subscription) {
+	closed := make(chan struct{})
+
+	// the reader only handles the control messages and detects the closed connection
+	go func() {
+		defer close(closed)
+
+		_ = conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+		})
+
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				reason := "stream closed"
+				if err := sub.Err(); err != nil {
+					reason = err.Error()
+				}
+				_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, reason), time.Now().Add(wsWriteTimeout))
+				return
+			}
+
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := conn.WriteJSON(msg); err != nil {
+				return
+			}
+		}
+	}
+}
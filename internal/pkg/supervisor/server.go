@@ -0,0 +1,76 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	shutdownTimeout = 5 * time.Second
+)
+
+type tasksResponse struct {
+	Tasks []Stats `json:"tasks"`
+}
+
+// Server exposes the restarts of the supervised tasks through HTTP/JSON.
+type Server struct {
+	addr       string
+	supervisor *Supervisor
+}
+
+func NewServer(addr string, supervisor *Supervisor) *Server {
+	return &Server{
+		addr:       addr,
+		supervisor: supervisor,
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tasks", s.handleTasks)
+	return mux
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.GetLogger().Infow("Start the supervisor status server", "addr", s.addr)
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// handleTasks serves GET /tasks
+func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(tasksResponse{Tasks: s.supervisor.Stats()}); err != nil {
+		log.GetLogger().Errorw("Failed to write the response", "error", err)
+	}
+}
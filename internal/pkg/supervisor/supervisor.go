@@ -0,0 +1,200 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	DefaultMinBackoff = time.Second
+	DefaultMaxBackoff = 5 * time.Minute
+	DefaultAlertAfter = 3
+	DefaultResetAfter = 10 * time.Minute
+)
+
+type Config struct {
+	// MinBackoff is the delay before the first restart, doubled after each
+	// consecutive failure up to MaxBackoff.
+	MinBackoff time.Duration `json:"min_backoff" yaml:"min_backoff" toml:"min_backoff"`
+	MaxBackoff time.Duration `json:"max_backoff" yaml:"max_backoff" toml:"max_backoff"`
+	// AlertAfter is the number of consecutive failures raising an alert,
+	// raised again every AlertAfter failures.
+	AlertAfter int `json:"alert_after" yaml:"alert_after" toml:"alert_after"`
+	// ResetAfter is the run duration after which a failure is not counted as
+	// consecutive to the previous one.
+	ResetAfter time.Duration `json:"reset_after" yaml:"reset_after" toml:"reset_after"`
+}
+
+// RunFunc runs a task until ctx is done or the task fails.
+type RunFunc func(ctx context.Context) error
+
+// AlertFunc is called when a task failed AlertAfter consecutive times.
+type AlertFunc func(name string, failures int, err error)
+
+// RecoverFunc is called when a task alerted by AlertFunc has run for
+// ResetAfter without failing.
+type RecoverFunc func(name string)
+
+// Stats are the restarts of a supervised task.
+type Stats struct {
+	Name                string    `json:"name"`
+	Running             bool      `json:"running"`
+	Restarts            uint64    `json:"restarts"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastFailure         time.Time `json:"last_failure,omitempty"`
+	StartedAt           time.Time `json:"started_at"`
+}
+
+// Supervisor restarts the failed tasks with an exponential backoff, so a
+// failing task doesn't stop the other ones.
+type Supervisor struct {
+	cfg       Config
+	alert     AlertFunc
+	recovered RecoverFunc
+	mu        sync.Mutex
+	stats     map[string]*Stats
+}
+
+func New(cfg Config, alert AlertFunc) *Supervisor {
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = DefaultMinBackoff
+	}
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		cfg.MaxBackoff = max(DefaultMaxBackoff, cfg.MinBackoff)
+	}
+	if cfg.AlertAfter <= 0 {
+		cfg.AlertAfter = DefaultAlertAfter
+	}
+	if cfg.ResetAfter <= 0 {
+		cfg.ResetAfter = DefaultResetAfter
+	}
+
+	return &Supervisor{
+		cfg:   cfg,
+		alert: alert,
+		stats: make(map[string]*Stats),
+	}
+}
+
+// OnRecover sets the function called when an alerted task recovers. It must
+// be set before the tasks run.
+func (s *Supervisor) OnRecover(recovered RecoverFunc) {
+	s.recovered = recovered
+}
+
+// Run runs the task named name until ctx is done, restarting it whenever it
+// fails, panics or returns early.
+func (s *Supervisor) Run(ctx context.Context, name string, run RunFunc) error {
+	s.mu.Lock()
+	if _, ok := s.stats[name]; ok {
+		s.mu.Unlock()
+		return fmt.Errorf("task %s is already supervised", name)
+	}
+	stats := &Stats{Name: name}
+	s.stats[name] = stats
+	s.mu.Unlock()
+
+	alerted := false
+	for {
+		startedAt := time.Now()
+		s.update(func() {
+			stats.Running = true
+			stats.StartedAt = startedAt
+		})
+
+		var recoverTimer *time.Timer
+		if alerted && s.recovered != nil {
+			recoverTimer = time.AfterFunc(s.cfg.ResetAfter, func() { s.recovered(name) })
+		}
+
+		err := runSafely(ctx, run)
+		if recoverTimer != nil && !recoverTimer.Stop() {
+			alerted = false
+		}
+		if ctx.Err() != nil {
+			s.update(func() { stats.Running = false })
+			return nil
+		}
+
+		if err == nil {
+			err = errors.New("stopped unexpectedly")
+		}
+
+		var failures int
+		s.update(func() {
+			if time.Since(startedAt) >= s.cfg.ResetAfter {
+				stats.ConsecutiveFailures = 0
+			}
+			stats.Running = false
+			stats.Restarts++
+			stats.ConsecutiveFailures++
+			stats.LastError = err.Error()
+			stats.LastFailure = time.Now()
+			failures = stats.ConsecutiveFailures
+		})
+
+		backoff := s.backoff(failures)
+		log.GetLogger().Errorw("Supervised task failed, restarting", "error", err, "task", name, "failures", failures, "backoff", backoff)
+
+		if failures%s.cfg.AlertAfter == 0 && s.alert != nil {
+			s.alert(name, failures, err)
+			alerted = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// Stats returns the stats of the supervised tasks sorted by name.
+func (s *Supervisor) Stats() []Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]Stats, 0, len(s.stats))
+	for _, taskStats := range s.stats {
+		stats = append(stats, *taskStats)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+
+	return stats
+}
+
+func (s *Supervisor) update(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fn()
+}
+
+func (s *Supervisor) backoff(failures int) time.Duration {
+	backoff := s.cfg.MinBackoff
+	for i := 1; i < failures && backoff < s.cfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, s.cfg.MaxBackoff)
+}
+
+func runSafely(ctx context.Context, run RunFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return run(ctx)
+}
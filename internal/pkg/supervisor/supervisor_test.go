@@ -0,0 +1,140 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupervisor_Run(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		alerts []int
+	)
+	s := New(Config{MinBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond, AlertAfter: 2}, func(name string, failures int, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		assert.Equal(t, "l2", name)
+		alerts = append(alerts, failures)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs atomic.Int32
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx, "l2", func(ctx context.Context) error {
+			switch runs.Add(1) {
+			case 1, 2:
+				return errors.New("subscription failed")
+			case 3:
+				panic("boom")
+			default:
+				<-ctx.Done()
+				return nil
+			}
+		})
+	}()
+
+	// the healthy task keeps running while the other one restarts
+	go func() {
+		_ = s.Run(ctx, "l1", func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+	}()
+
+	require.Eventually(t, func() bool { return runs.Load() == 4 }, time.Second, time.Millisecond)
+	require.Error(t, s.Run(ctx, "l2", nil))
+
+	stats := s.Stats()
+	require.Len(t, stats, 2)
+	assert.Equal(t, "l1", stats[0].Name)
+	assert.True(t, stats[0].Running)
+	assert.Zero(t, stats[0].Restarts)
+
+	assert.Equal(t, "l2", stats[1].Name)
+	assert.Equal(t, uint64(3), stats[1].Restarts)
+	assert.Equal(t, 3, stats[1].ConsecutiveFailures)
+	assert.Equal(t, "panic: boom", stats[1].LastError)
+
+	mu.Lock()
+	assert.Equal(t, []int{2}, alerts)
+	mu.Unlock()
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.False(t, s.Stats()[1].Running)
+}
+
+func TestSupervisor_OnRecover(t *testing.T) {
+	var alerts, recoveries atomic.Int32
+	s := New(Config{MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond, AlertAfter: 1, ResetAfter: 20 * time.Millisecond}, func(string, int, error) {
+		alerts.Add(1)
+	})
+	s.OnRecover(func(name string) {
+		assert.Equal(t, "l2", name)
+		recoveries.Add(1)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs atomic.Int32
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx, "l2", func(ctx context.Context) error {
+			if runs.Add(1) == 1 {
+				return errors.New("subscription failed")
+			}
+			<-ctx.Done()
+			return nil
+		})
+	}()
+
+	// the alerted task recovers once it runs for ResetAfter
+	require.Eventually(t, func() bool { return recoveries.Load() == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), alerts.Load())
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, int32(1), recoveries.Load())
+}
+
+func TestSupervisor_backoff(t *testing.T) {
+	s := New(Config{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}, nil)
+
+	assert.Equal(t, time.Second, s.backoff(1))
+	assert.Equal(t, 2*time.Second, s.backoff(2))
+	assert.Equal(t, 4*time.Second, s.backoff(3))
+	assert.Equal(t, 5*time.Second, s.backoff(4))
+	assert.Equal(t, 5*time.Second, s.backoff(100))
+}
+
+func TestServer_Tasks(t *testing.T) {
+	s := New(Config{}, nil)
+	s.stats["l1"] = &Stats{Name: "l1", Restarts: 2}
+
+	server := httptest.NewServer(NewServer("", s).Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/tasks")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body tasksResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(t, body.Tasks, 1)
+	assert.Equal(t, uint64(2), body.Tasks[0].Restarts)
+}
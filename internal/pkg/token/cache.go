@@ -0,0 +1,59 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-redis/redis/v8"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+)
+
+const (
+	tokenKey = "token"
+)
+
+// RedisCache keeps the token metadata in Redis. The metadata of a token never
+// changes, so the keys don't expire.
+type RedisCache struct {
+	redisClient redis.UniversalClient
+}
+
+func NewRedisCache(redisClient redis.UniversalClient) *RedisCache {
+	return &RedisCache{
+		redisClient: redisClient,
+	}
+}
+
+func (c *RedisCache) Get(ctx context.Context, chainID uint64, address common.Address) (*types.Token, error) {
+	result, err := c.redisClient.Get(ctx, c.getKey(chainID, address)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var token types.Token
+	if err := json.Unmarshal(result, &token); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (c *RedisCache) Set(ctx context.Context, chainID uint64, token *types.Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	return c.redisClient.Set(ctx, c.getKey(chainID, common.HexToAddress(token.Address)), data, 0).Err()
+}
+
+func (c *RedisCache) getKey(chainID uint64, address common.Address) string {
+	return fmt.Sprintf("%s:%d:%s", tokenKey, chainID, address.Hex())
+}
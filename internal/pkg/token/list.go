@@ -0,0 +1,111 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+const (
+	listFetchTimeout = 30 * time.Second
+	maxListSize      = 32 << 20
+)
+
+// List is a token list in the Uniswap format, also used by the Optimism
+// token list.
+type List struct {
+	Name   string      `json:"name"`
+	Tokens []ListToken `json:"tokens"`
+}
+
+type ListToken struct {
+	ChainID  uint64 `json:"chainId"`
+	Address  string `json:"address"`
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+	Decimals int    `json:"decimals"`
+}
+
+func ParseList(data []byte) (*List, error) {
+	var list List
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid token list: %w", err)
+	}
+
+	if list.Tokens == nil {
+		return nil, fmt.Errorf("invalid token list: no tokens")
+	}
+
+	return &list, nil
+}
+
+// ImportList registers the valid tokens of the list and returns their count.
+func (r *Registry) ImportList(list *List) int {
+	imported := 0
+	for _, listToken := range list.Tokens {
+		if !common.IsHexAddress(listToken.Address) || listToken.Symbol == "" || listToken.Decimals < 0 || listToken.Decimals > 255 {
+			log.GetLogger().Warnw("Skip the invalid token of the token list", "list", list.Name, "token", listToken)
+			continue
+		}
+
+		r.Add(listToken.ChainID, &types.Token{
+			Symbol:   listToken.Symbol,
+			Decimals: listToken.Decimals,
+			Address:  common.HexToAddress(listToken.Address).Hex(),
+		})
+		imported++
+	}
+
+	return imported
+}
+
+// LoadList reads a token list from a file or an http(s) URL.
+func LoadList(ctx context.Context, source string) (*List, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		data, err = fetchList(ctx, source)
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseList(data)
+}
+
+func fetchList(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, listFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch the token list %s: %s", url, resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxListSize))
+}
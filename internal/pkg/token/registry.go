@@ -0,0 +1,137 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/erc20"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/types"
+	"github.com/tokamak-network/tokamak-thanos-event-listener/pkg/log"
+)
+
+// UnknownSymbol is the symbol of the tokens whose metadata can't be read. Their
+// amounts are shown in raw units.
+const UnknownSymbol = "UNKNOWN"
+
+// Cache persists the token metadata across restarts.
+type Cache interface {
+	// Get returns nil when the token is not cached.
+	Get(ctx context.Context, chainID uint64, address common.Address) (*types.Token, error)
+	Set(ctx context.Context, chainID uint64, token *types.Token) error
+}
+
+type key struct {
+	chainID uint64
+	address common.Address
+}
+
+// Registry resolves the token metadata of every chain from the imported
+// token lists, the cache, then the token contract. It is safe for concurrent
+// use.
+type Registry struct {
+	cache   Cache
+	mu      sync.RWMutex
+	tokens  map[key]*types.Token
+	callers map[uint64]ethereum.ContractCaller
+}
+
+// NewRegistry creates a registry. cache may be nil to keep the metadata in
+// memory only.
+func NewRegistry(cache Cache) *Registry {
+	return &Registry{
+		cache:   cache,
+		tokens:  make(map[key]*types.Token),
+		callers: make(map[uint64]ethereum.ContractCaller),
+	}
+}
+
+// AddChain reads the tokens of the chain through caller.
+func (r *Registry) AddChain(chainID uint64, caller ethereum.ContractCaller) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.callers[chainID] = caller
+}
+
+// Add registers the metadata of a token, replacing the known one.
+func (r *Registry) Add(chainID uint64, token *types.Token) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[key{chainID, common.HexToAddress(token.Address)}] = token
+}
+
+// Lookup returns the metadata of the token, reading it from the contract
+// when it is not known yet.
+func (r *Registry) Lookup(ctx context.Context, chainID uint64, address common.Address) (*types.Token, error) {
+	k := key{chainID, address}
+
+	r.mu.RLock()
+	token, ok := r.tokens[k]
+	caller := r.callers[chainID]
+	r.mu.RUnlock()
+	if ok {
+		return token, nil
+	}
+
+	if r.cache != nil {
+		token, err := r.cache.Get(ctx, chainID, address)
+		if err != nil {
+			log.GetLogger().Warnw("Failed to read the token cache", "error", err, "chain_id", chainID, "token", address)
+		}
+
+		if token != nil {
+			r.Add(chainID, token)
+			return token, nil
+		}
+	}
+
+	if caller == nil {
+		return nil, fmt.Errorf("unknown chain id: %d", chainID)
+	}
+
+	token, err := erc20.FetchToken(ctx, caller, address)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Add(chainID, token)
+
+	if r.cache != nil {
+		if err := r.cache.Set(ctx, chainID, token); err != nil {
+			log.GetLogger().Warnw("Failed to write the token cache", "error", err, "chain_id", chainID, "token", address)
+		}
+	}
+
+	return token, nil
+}
+
+// Token returns the metadata of the token, or an UNKNOWN token with no
+// decimals when it can't be read. The UNKNOWN token isn't kept so the next
+// lookup retries.
+func (r *Registry) Token(ctx context.Context, chainID uint64, address common.Address) *types.Token {
+	token, err := r.Lookup(ctx, chainID, address)
+	if err != nil {
+		log.GetLogger().Warnw("Failed to get the token metadata, use the raw units", "error", err, "chain_id", chainID, "token", address)
+		return Unknown(address)
+	}
+
+	return token
+}
+
+func Unknown(address common.Address) *types.Token {
+	return &types.Token{
+		Symbol:  UnknownSymbol,
+		Address: address.Hex(),
+	}
+}
+
+// IsUnknown reports whether token is the fallback of an unreadable token.
+func IsUnknown(token *types.Token) bool {
+	return strings.EqualFold(token.Symbol, UnknownSymbol) && token.Decimals == 0
+}
@@ -0,0 +1,173 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tokamak-network/tokamak-thanos-event-listener/internal/pkg/erc20"
+)
+
+var (
+	ton = common.HexToAddress("0xa30fe40285B8f5c0457DbC3B7C8A280373c40044")
+	mkr = common.HexToAddress("0x9f8F72aA9304c8B593d555F12eF6589cC3A579A2")
+	eoa = common.HexToAddress("0x00000000000000000000000000000000000000e0")
+)
+
+// testCaller serves the symbol and decimals calls of ton and mkr.
+type testCaller struct {
+	calls atomic.Int32
+}
+
+func (c *testCaller) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	c.calls.Add(1)
+
+	parsed, err := erc20.Erc20MetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+
+	method, err := parsed.MethodById(msg.Data[:4])
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case *msg.To == ton && method.Name == "symbol":
+		return method.Outputs.Pack("TON")
+	case *msg.To == mkr && method.Name == "symbol":
+		symbol := make([]byte, 32)
+		copy(symbol, "MKR")
+		return symbol, nil
+	case (*msg.To == ton || *msg.To == mkr) && method.Name == "decimals":
+		return method.Outputs.Pack(uint8(18))
+	case *msg.To == eoa:
+		return nil, nil
+	default:
+		return nil, errors.New("execution reverted")
+	}
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	ctx := context.Background()
+	caller := &testCaller{}
+
+	redisServer := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+
+	registry := NewRegistry(NewRedisCache(redisClient))
+	registry.AddChain(1, caller)
+
+	token, err := registry.Lookup(ctx, 1, ton)
+	require.NoError(t, err)
+	assert.Equal(t, "TON", token.Symbol)
+	assert.Equal(t, 18, token.Decimals)
+	assert.Equal(t, ton.Hex(), token.Address)
+
+	token, err = registry.Lookup(ctx, 1, mkr)
+	require.NoError(t, err)
+	assert.Equal(t, "MKR", token.Symbol)
+
+	// the known tokens are served from memory
+	calls := caller.calls.Load()
+	_, err = registry.Lookup(ctx, 1, ton)
+	require.NoError(t, err)
+	assert.Equal(t, calls, caller.calls.Load())
+
+	// a new registry reads the persisted cache
+	restarted := NewRegistry(NewRedisCache(redisClient))
+	token, err = restarted.Lookup(ctx, 1, mkr)
+	require.NoError(t, err)
+	assert.Equal(t, "MKR", token.Symbol)
+
+	_, err = registry.Lookup(ctx, 1, eoa)
+	require.Error(t, err)
+
+	_, err = registry.Lookup(ctx, 2, ton)
+	require.Error(t, err)
+}
+
+func TestRegistry_Token(t *testing.T) {
+	ctx := context.Background()
+	registry := NewRegistry(nil)
+	registry.AddChain(1, &testCaller{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "TON", registry.Token(ctx, 1, ton).Symbol)
+		}()
+	}
+	wg.Wait()
+
+	unknown := registry.Token(ctx, 1, eoa)
+	assert.Equal(t, UnknownSymbol, unknown.Symbol)
+	assert.Zero(t, unknown.Decimals)
+	assert.True(t, IsUnknown(unknown))
+	assert.False(t, IsUnknown(registry.Token(ctx, 1, ton)))
+}
+
+const testList = `{
+  "name": "Optimism",
+  "tokens": [
+    {"chainId": 1, "address": "0x9f8f72aa9304c8b593d555f12ef6589cc3a579a2", "name": "Maker", "symbol": "MKR", "decimals": 18},
+    {"chainId": 10, "address": "0xab7badef82e9fe11f6f33f87bc9bc2aa27f2fcb5", "name": "Maker", "symbol": "MKR", "decimals": 18},
+    {"chainId": 1, "address": "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", "name": "USD Coin", "symbol": "USDC", "decimals": 6},
+    {"chainId": 1, "address": "not an address", "symbol": "BAD", "decimals": 18}
+  ]
+}`
+
+func TestRegistry_ImportList(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	require.NoError(t, os.WriteFile(path, []byte(testList), 0o600))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tokens.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(testList))
+	}))
+	defer server.Close()
+
+	for _, source := range []string{path, server.URL + "/tokens.json"} {
+		list, err := LoadList(ctx, source)
+		require.NoError(t, err)
+
+		registry := NewRegistry(nil)
+		assert.Equal(t, 3, registry.ImportList(list))
+
+		token, err := registry.Lookup(ctx, 1, common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"))
+		require.NoError(t, err)
+		assert.Equal(t, "USDC", token.Symbol)
+		assert.Equal(t, 6, token.Decimals)
+
+		token, err = registry.Lookup(ctx, 10, common.HexToAddress("0xab7badef82e9fe11f6f33f87bc9bc2aa27f2fcb5"))
+		require.NoError(t, err)
+		assert.Equal(t, "MKR", token.Symbol)
+	}
+
+	_, err := LoadList(ctx, server.URL+"/missing.json")
+	require.Error(t, err)
+
+	_, err = ParseList([]byte(`{"name": "empty"}`))
+	require.Error(t, err)
+}
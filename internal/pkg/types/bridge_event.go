@@ -0,0 +1,129 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	DirectionDeposit    = "deposit"
+	DirectionWithdrawal = "withdrawal"
+
+	StatusInitiated = "initiated"
+	StatusFinalized = "finalized"
+
+	BridgeStandard = "standard"
+	BridgeUsdc     = "usdc"
+
+	LayerL1 = "l1"
+	LayerL2 = "l2"
+
+	CategoryNative     = "native"
+	CategoryWrapped    = "wrapped"
+	CategoryStablecoin = "stablecoin"
+	CategoryERC20      = "erc20"
+)
+
+// BridgeEvent is a decoded deposit or withdrawal event of one of the bridges.
+type BridgeEvent struct {
+	Network string `json:"network"`
+	Layer   string `json:"layer"`
+	ChainID uint64 `json:"chain_id"`
+	// L2ChainID identifies the rollup of the event, the L1 chain being shared
+	// by the rollups settling to it.
+	L2ChainID   uint64         `json:"l2_chain_id"`
+	Bridge      string         `json:"bridge"`
+	Direction   string         `json:"direction"`
+	Status      string         `json:"status"`
+	BlockNumber uint64         `json:"block_number"`
+	BlockHash   common.Hash    `json:"block_hash"`
+	BlockTime   time.Time      `json:"block_time"`
+	TxHash      common.Hash    `json:"tx_hash"`
+	LogIndex    uint           `json:"log_index"`
+	L1Token     common.Address `json:"l1_token"`
+	L2Token     common.Address `json:"l2_token"`
+	From        common.Address `json:"from"`
+	To          common.Address `json:"to"`
+	Amount      *big.Int       `json:"amount"`
+	Symbol      string         `json:"symbol"`
+	Decimals    int            `json:"decimals"`
+	// Asset is the display name of a classified token, empty for the other
+	// tokens.
+	Asset    string `json:"asset,omitempty"`
+	Category string `json:"category"`
+	// ValueUSD is the USD value of the amount when the asset has a price.
+	ValueUSD *float64 `json:"value_usd,omitempty"`
+	// FromLabel and ToLabel name the addresses of the address book, and Tags
+	// are the watchlist tags of both addresses.
+	FromLabel string   `json:"from_label,omitempty"`
+	ToLabel   string   `json:"to_label,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+}
+
+// Key identifies the event across re-deliveries. Events emitted again after a
+// re-org keep the same key.
+func (e *BridgeEvent) Key() string {
+	return fmt.Sprintf("%d:%s:%d", e.ChainID, e.TxHash.Hex(), e.LogIndex)
+}
+
+// TransferKey pairs the initiated and finalized events of a transfer by their
+// rollup, bridge, tokens, addresses and amount, as the events have no common
+// id.
+func (e *BridgeEvent) TransferKey() string {
+	amount := "0"
+	if e.Amount != nil {
+		amount = e.Amount.String()
+	}
+
+	return strings.Join([]string{
+		strconv.FormatUint(e.L2ChainID, 10), e.Bridge, e.Direction,
+		e.L1Token.Hex(), e.L2Token.Hex(), e.From.Hex(), e.To.Hex(), amount,
+	}, ":")
+}
+
+// MarshalJSON encodes the amount as a decimal string, so consumers without
+// arbitrary precision numbers don't lose digits.
+func (e *BridgeEvent) MarshalJSON() ([]byte, error) {
+	type alias BridgeEvent
+
+	amount := "0"
+	if e.Amount != nil {
+		amount = e.Amount.String()
+	}
+
+	return json.Marshal(&struct {
+		*alias
+		Amount string `json:"amount"`
+	}{
+		alias:  (*alias)(e),
+		Amount: amount,
+	})
+}
+
+func (e *BridgeEvent) UnmarshalJSON(data []byte) error {
+	type alias BridgeEvent
+
+	v := &struct {
+		*alias
+		Amount string `json:"amount"`
+	}{
+		alias: (*alias)(e),
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	amount, ok := new(big.Int).SetString(v.Amount, 10)
+	if !ok {
+		return fmt.Errorf("invalid amount: %s", v.Amount)
+	}
+	e.Amount = amount
+
+	return nil
+}
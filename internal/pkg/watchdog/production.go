@@ -0,0 +1,135 @@
+package watchdog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// ConditionHalt is no new block produced within the halt timeout.
+	ConditionHalt Condition = "halt"
+	// ConditionDrift is the timestamp of the latest block away from the wall
+	// clock by more than the maximum drift.
+	ConditionDrift Condition = "drift"
+	// ConditionBlockTime is the average block time away from the expected
+	// block time by more than the tolerance.
+	ConditionBlockTime Condition = "block_time"
+
+	maxProductionSamples = 4096
+)
+
+// ProductionConfig holds the thresholds of the block production, a condition
+// is disabled when its threshold is 0.
+type ProductionConfig struct {
+	HaltAfter time.Duration
+	MaxDrift  time.Duration
+	// BlockTime is the expected block time, e.g. 2s, and Tolerance the
+	// fraction of it the average block time of the last Window can depart
+	// from it.
+	BlockTime time.Duration
+	Tolerance float64
+	Window    time.Duration
+}
+
+type productionSample struct {
+	at     time.Time
+	number uint64
+}
+
+// Production analyses the block production of a chain from its headers. The
+// block time is measured on the wall clock, as the timestamps of the L2
+// blocks are fixed to the expected block time by the derivation rules.
+type Production struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	samples []productionSample
+	time    time.Time
+	drift   time.Duration
+	firing  firing
+}
+
+func NewProduction() *Production {
+	return &Production{
+		now:    time.Now,
+		firing: make(firing),
+	}
+}
+
+// Observe records the header, ignoring the headers not above the latest one.
+func (p *Production) Observe(header *ethereumTypes.Header) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	number := header.Number.Uint64()
+	if len(p.samples) > 0 && number <= p.samples[len(p.samples)-1].number {
+		return
+	}
+
+	now := p.now()
+	p.samples = append(p.samples, productionSample{at: now, number: number})
+	if len(p.samples) > maxProductionSamples {
+		p.samples = p.samples[len(p.samples)-maxProductionSamples:]
+	}
+	p.time = time.Unix(int64(header.Time), 0)
+	p.drift = now.Sub(p.time)
+}
+
+// Check returns the conditions which started or ended since the last check.
+// Nothing is reported before the first header.
+func (p *Production) Check(cfg ProductionConfig) []Alert {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.samples) == 0 {
+		return nil
+	}
+
+	now := p.now()
+	latest := p.samples[len(p.samples)-1]
+
+	var alerts []Alert
+
+	since := now.Sub(latest.at).Truncate(time.Second)
+	halted := cfg.HaltAfter > 0 && since > cfg.HaltAfter && now.Sub(p.time) > cfg.HaltAfter
+	if halted {
+		alerts = p.firing.update(alerts, ConditionHalt, true, fmt.Sprintf("No new block since #%d produced at %s, %s ago", latest.number, p.time.UTC().Format(time.RFC3339), since))
+	} else {
+		alerts = p.firing.update(alerts, ConditionHalt, false, fmt.Sprintf("Blocks are produced again, the latest one is #%d", latest.number))
+	}
+
+	drift := p.drift.Truncate(time.Second)
+	switch {
+	case cfg.MaxDrift > 0 && drift > cfg.MaxDrift:
+		alerts = p.firing.update(alerts, ConditionDrift, true, fmt.Sprintf("The timestamp of block #%d is %s behind the wall clock", latest.number, drift))
+	case cfg.MaxDrift > 0 && -drift > cfg.MaxDrift:
+		alerts = p.firing.update(alerts, ConditionDrift, true, fmt.Sprintf("The timestamp of block #%d is %s ahead of the wall clock", latest.number, -drift))
+	default:
+		alerts = p.firing.update(alerts, ConditionDrift, false, fmt.Sprintf("The timestamp of block #%d is %s away from the wall clock", latest.number, drift))
+	}
+
+	// the block time is left as is while the samples don't span half of the
+	// window, e.g. while the chain is halted
+	p.expire(cfg.Window, now)
+	first := p.samples[0]
+	if cfg.BlockTime > 0 && latest.number > first.number && latest.at.Sub(first.at) >= cfg.Window/2 {
+		average := (latest.at.Sub(first.at) / time.Duration(latest.number-first.number)).Round(time.Millisecond)
+		departure := float64(average-cfg.BlockTime) / float64(cfg.BlockTime)
+		detail := fmt.Sprintf("The blocks of the last %s were produced every %s on average, expected %s", cfg.Window, average, cfg.BlockTime)
+		alerts = p.firing.update(alerts, ConditionBlockTime, departure > cfg.Tolerance || -departure > cfg.Tolerance, detail)
+	}
+
+	return alerts
+}
+
+// expire drops the samples older than window, keeping the latest one.
+func (p *Production) expire(window time.Duration, now time.Time) {
+	i := 0
+	for i < len(p.samples)-1 && p.samples[i].at.Before(now.Add(-window)) {
+		i++
+	}
+	p.samples = p.samples[i:]
+}
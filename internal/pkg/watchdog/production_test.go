@@ -0,0 +1,72 @@
+package watchdog
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProduction_Check(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := NewProduction()
+	p.now = func() time.Time { return now }
+
+	cfg := ProductionConfig{
+		HaltAfter: time.Minute,
+		MaxDrift:  20 * time.Second,
+		BlockTime: 2 * time.Second,
+		Tolerance: 0.5,
+		Window:    40 * time.Second,
+	}
+
+	require.Empty(t, p.Check(cfg))
+
+	// a block every 2s
+	number := int64(100)
+	produce := func(every time.Duration, blocks int) {
+		for i := 0; i < blocks; i++ {
+			now = now.Add(every)
+			number++
+			p.Observe(&ethereumTypes.Header{Number: big.NewInt(number), Time: uint64(now.Unix())})
+		}
+	}
+	produce(2*time.Second, 30)
+	require.Empty(t, p.Check(cfg))
+
+	// an older header is ignored
+	p.Observe(&ethereumTypes.Header{Number: big.NewInt(10), Time: 0})
+	require.Empty(t, p.Check(cfg))
+
+	// the sequencer halts
+	now = now.Add(2 * time.Minute)
+	alerts := p.Check(cfg)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, Alert{Condition: ConditionHalt, Detail: "No new block since #130 produced at 2024-01-01T00:01:00Z, 2m0s ago"}, alerts[0])
+	require.Empty(t, p.Check(cfg))
+
+	// it resumes with the timestamps behind the wall clock, faster than the
+	// block time to catch up
+	behind := now.Add(-2 * time.Minute)
+	for i := 0; i < 60; i++ {
+		now = now.Add(time.Second / 2)
+		behind = behind.Add(2 * time.Second)
+		number++
+		p.Observe(&ethereumTypes.Header{Number: big.NewInt(number), Time: uint64(behind.Unix())})
+	}
+	alerts = p.Check(cfg)
+	require.Len(t, alerts, 3)
+	assert.Equal(t, Alert{Condition: ConditionHalt, Resolved: true, Detail: "Blocks are produced again, the latest one is #190"}, alerts[0])
+	assert.Equal(t, Alert{Condition: ConditionDrift, Detail: "The timestamp of block #190 is 30s behind the wall clock"}, alerts[1])
+	assert.Equal(t, Alert{Condition: ConditionBlockTime, Detail: "The blocks of the last 40s were produced every 500ms on average, expected 2s"}, alerts[2])
+
+	// caught up with the wall clock
+	produce(2*time.Second, 30)
+	alerts = p.Check(cfg)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, Alert{Condition: ConditionDrift, Resolved: true, Detail: "The timestamp of block #220 is 0s away from the wall clock"}, alerts[0])
+	assert.Equal(t, Alert{Condition: ConditionBlockTime, Resolved: true, Detail: "The blocks of the last 40s were produced every 2s on average, expected 2s"}, alerts[1])
+}
@@ -0,0 +1,138 @@
+package watchdog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// ConditionBatchStale is no batch posted to the batch inbox within the
+	// batch timeout.
+	ConditionBatchStale Condition = "batch_stale"
+	// ConditionOutputStale is no output proposed within the output timeout.
+	ConditionOutputStale Condition = "output_stale"
+	// ConditionOutputLag is the L2 head ahead of the last proposed output by
+	// more than the maximum output lag.
+	ConditionOutputLag Condition = "output_lag"
+)
+
+// SettlementConfig holds the thresholds of the settlement of a rollup on L1,
+// a condition is disabled when its threshold is 0.
+type SettlementConfig struct {
+	BatchTimeout  time.Duration
+	OutputTimeout time.Duration
+	// MaxOutputLag is the number of L2 blocks the last proposed output can
+	// be behind the L2 head.
+	MaxOutputLag uint64
+}
+
+// Settlement tracks the batches and the outputs a rollup posts to L1. The
+// timeouts count from the creation until the first batch or output.
+type Settlement struct {
+	now func() time.Time
+
+	mu          sync.Mutex
+	started     time.Time
+	batchTime   time.Time
+	batchBlock  uint64
+	outputTime  time.Time
+	outputBlock uint64
+	l2Head      uint64
+	firing      firing
+}
+
+func NewSettlement() *Settlement {
+	s := &Settlement{
+		now:    time.Now,
+		firing: make(firing),
+	}
+	s.started = s.now()
+
+	return s
+}
+
+// BatchPosted records a batch posted in the L1 block of the time.
+func (s *Settlement) BatchPosted(l1Block uint64, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if at.After(s.batchTime) {
+		s.batchTime = at
+		s.batchBlock = l1Block
+	}
+}
+
+// OutputProposed records an output of the L2 block proposed at the time.
+func (s *Settlement) OutputProposed(l2Block uint64, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if at.After(s.outputTime) {
+		s.outputTime = at
+	}
+	if l2Block > s.outputBlock {
+		s.outputBlock = l2Block
+	}
+}
+
+// L2Head records the latest block number of the L2 chain.
+func (s *Settlement) L2Head(number uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.l2Head = number
+}
+
+// Check returns the conditions which started or ended since the last check.
+func (s *Settlement) Check(cfg SettlementConfig) []Alert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	var alerts []Alert
+
+	if stale, since := s.stale(s.batchTime, cfg.BatchTimeout, now); stale {
+		alerts = s.firing.update(alerts, ConditionBatchStale, true, fmt.Sprintf("No batch posted for %s%s", since, lastSeen("batch in L1 block", s.batchBlock, s.batchTime)))
+	} else {
+		alerts = s.firing.update(alerts, ConditionBatchStale, false, fmt.Sprintf("Batches are posted again, the latest one in L1 block #%d", s.batchBlock))
+	}
+
+	if stale, since := s.stale(s.outputTime, cfg.OutputTimeout, now); stale {
+		alerts = s.firing.update(alerts, ConditionOutputStale, true, fmt.Sprintf("No output proposed for %s%s", since, lastSeen("output of L2 block", s.outputBlock, s.outputTime)))
+	} else {
+		alerts = s.firing.update(alerts, ConditionOutputStale, false, fmt.Sprintf("Outputs are proposed again, the latest one of L2 block #%d", s.outputBlock))
+	}
+
+	lag := uint64(0)
+	if s.l2Head > s.outputBlock {
+		lag = s.l2Head - s.outputBlock
+	}
+	if cfg.MaxOutputLag > 0 && s.outputBlock > 0 && lag > cfg.MaxOutputLag {
+		alerts = s.firing.update(alerts, ConditionOutputLag, true, fmt.Sprintf("The latest output of L2 block #%d is %d blocks behind the L2 head #%d", s.outputBlock, lag, s.l2Head))
+	} else {
+		alerts = s.firing.update(alerts, ConditionOutputLag, false, fmt.Sprintf("The latest output of L2 block #%d is %d blocks behind the L2 head", s.outputBlock, lag))
+	}
+
+	return alerts
+}
+
+// stale reports whether the last time, or the creation when none, is older
+// than the timeout, with the time since.
+func (s *Settlement) stale(last time.Time, timeout time.Duration, now time.Time) (bool, time.Duration) {
+	if last.IsZero() {
+		last = s.started
+	}
+	since := now.Sub(last).Truncate(time.Second)
+
+	return timeout > 0 && since > timeout, since
+}
+
+func lastSeen(what string, block uint64, at time.Time) string {
+	if at.IsZero() {
+		return " since the start"
+	}
+
+	return fmt.Sprintf(", the latest %s #%d at %s", what, block, at.UTC().Format(time.RFC3339))
+}
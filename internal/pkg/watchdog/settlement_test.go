@@ -0,0 +1,57 @@
+package watchdog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettlement_Check(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewSettlement()
+	s.now = func() time.Time { return now }
+	s.started = now
+
+	cfg := SettlementConfig{
+		BatchTimeout:  30 * time.Minute,
+		OutputTimeout: 2 * time.Hour,
+		MaxOutputLag:  1000,
+	}
+
+	s.BatchPosted(10, now)
+	s.OutputProposed(500, now)
+	s.L2Head(1200)
+	require.Empty(t, s.Check(cfg))
+
+	// an older batch of the catch-up doesn't move the latest one
+	s.BatchPosted(5, now.Add(-time.Hour))
+
+	now = now.Add(time.Hour)
+	s.L2Head(1800)
+	alerts := s.Check(cfg)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, Alert{Condition: ConditionBatchStale, Detail: "No batch posted for 1h0m0s, the latest batch in L1 block #10 at 2024-01-01T00:00:00Z"}, alerts[0])
+	assert.Equal(t, Alert{Condition: ConditionOutputLag, Detail: "The latest output of L2 block #500 is 1300 blocks behind the L2 head #1800"}, alerts[1])
+	require.Empty(t, s.Check(cfg))
+
+	s.BatchPosted(300, now)
+	s.OutputProposed(1500, now)
+	alerts = s.Check(cfg)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, Alert{Condition: ConditionBatchStale, Resolved: true, Detail: "Batches are posted again, the latest one in L1 block #300"}, alerts[0])
+	assert.Equal(t, Alert{Condition: ConditionOutputLag, Resolved: true, Detail: "The latest output of L2 block #1500 is 300 blocks behind the L2 head"}, alerts[1])
+}
+
+func TestSettlement_CheckFromStart(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewSettlement()
+	s.now = func() time.Time { return now }
+	s.started = now
+
+	now = now.Add(3 * time.Hour)
+	alerts := s.Check(SettlementConfig{OutputTimeout: 2 * time.Hour})
+	require.Len(t, alerts, 1)
+	assert.Equal(t, Alert{Condition: ConditionOutputStale, Detail: "No output proposed for 3h0m0s since the start"}, alerts[0])
+}
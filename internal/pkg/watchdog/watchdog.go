@@ -0,0 +1,210 @@
+package watchdog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// Condition is an unhealthy state of a listener.
+type Condition string
+
+const (
+	// ConditionNoHead is no new head within the head timeout.
+	ConditionNoHead Condition = "no_head"
+	// ConditionLag is the chain head ahead of the processed head by more
+	// than the maximum lag.
+	ConditionLag Condition = "lag"
+	// ConditionHeadStore is the last head failing to be saved.
+	ConditionHeadStore Condition = "head_store"
+	// ConditionRPCErrors is a burst of RPC errors.
+	ConditionRPCErrors Condition = "rpc_errors"
+
+	maxRPCErrors = 1000
+)
+
+// Conditions lists the conditions in the order they are checked.
+var Conditions = []Condition{ConditionNoHead, ConditionLag, ConditionHeadStore, ConditionRPCErrors}
+
+// Config holds the thresholds of the conditions, a condition is disabled when
+// its threshold is 0.
+type Config struct {
+	HeadTimeout time.Duration
+	MaxLag      uint64
+	// RPCErrors is the number of RPC errors in RPCErrorWindow from which
+	// they are a burst.
+	RPCErrors      int
+	RPCErrorWindow time.Duration
+}
+
+// Alert is a condition raised, or resolved when Resolved is set.
+type Alert struct {
+	Condition Condition
+	Resolved  bool
+	Detail    string
+}
+
+// Watchdog observes the heads and the failures of a listener and reports the
+// conditions which started or ended since the last check.
+type Watchdog struct {
+	now func() time.Time
+
+	mu         sync.Mutex
+	lastHead   time.Time
+	headNumber uint64
+	chainHead  uint64
+	storeErr   error
+	rpcErrors  []time.Time
+	rpcErr     error
+	firing     firing
+}
+
+// New returns a watchdog counting the head timeout from now.
+func New() *Watchdog {
+	w := &Watchdog{
+		now:    time.Now,
+		firing: make(firing),
+	}
+	w.lastHead = w.now()
+
+	return w
+}
+
+// HeadReceived records a new head delivered by the subscription.
+func (w *Watchdog) HeadReceived(header *ethereumTypes.Header) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.lastHead = w.now()
+}
+
+// HeadStored records the result of saving the processed head.
+func (w *Watchdog) HeadStored(header *ethereumTypes.Header, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.storeErr = err
+	if err != nil {
+		return
+	}
+
+	w.lastHead = w.now()
+	if number := header.Number.Uint64(); number > w.headNumber {
+		w.headNumber = number
+	}
+}
+
+// RPCFailed records a failed RPC call.
+func (w *Watchdog) RPCFailed(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.rpcErr = err
+	w.rpcErrors = append(w.rpcErrors, w.now())
+	if len(w.rpcErrors) > maxRPCErrors {
+		w.rpcErrors = w.rpcErrors[len(w.rpcErrors)-maxRPCErrors:]
+	}
+}
+
+// ChainHead records the latest block number of the chain.
+func (w *Watchdog) ChainHead(number uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.chainHead = number
+}
+
+// Head returns the number of the latest processed head.
+func (w *Watchdog) Head() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.headNumber
+}
+
+// Heads returns the number of the latest processed head and the latest block
+// number of the chain.
+func (w *Watchdog) Heads() (head, chainHead uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.headNumber, w.chainHead
+}
+
+// Check returns the conditions which started or ended since the last check.
+func (w *Watchdog) Check(cfg Config) []Alert {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+
+	var alerts []Alert
+	for _, condition := range Conditions {
+		firing, detail := w.check(cfg, condition, now)
+		alerts = w.firing.update(alerts, condition, firing, detail)
+	}
+
+	return alerts
+}
+
+// check returns whether the condition holds, with its detail.
+func (w *Watchdog) check(cfg Config, condition Condition, now time.Time) (bool, string) {
+	switch condition {
+	case ConditionNoHead:
+		since := now.Sub(w.lastHead).Truncate(time.Second)
+		if cfg.HeadTimeout > 0 && since > cfg.HeadTimeout {
+			return true, fmt.Sprintf("No new head for %s", since)
+		}
+		return false, "New heads are received again"
+	case ConditionLag:
+		lag := uint64(0)
+		if w.chainHead > w.headNumber {
+			lag = w.chainHead - w.headNumber
+		}
+		if cfg.MaxLag > 0 && w.headNumber > 0 && lag > cfg.MaxLag {
+			return true, fmt.Sprintf("The processed head %d is %d blocks behind the chain head %d", w.headNumber, lag, w.chainHead)
+		}
+		return false, fmt.Sprintf("The processed head %d is %d blocks behind the chain head", w.headNumber, lag)
+	case ConditionHeadStore:
+		if w.storeErr != nil {
+			return true, fmt.Sprintf("Failed to save the head: %s", w.storeErr)
+		}
+		return false, "The heads are saved again"
+	case ConditionRPCErrors:
+		count := w.countRPCErrors(cfg.RPCErrorWindow, now)
+		if cfg.RPCErrors > 0 && count >= cfg.RPCErrors {
+			return true, fmt.Sprintf("%d RPC errors in %s, the last one: %s", count, cfg.RPCErrorWindow, w.rpcErr)
+		}
+		return false, fmt.Sprintf("%d RPC errors in %s", count, cfg.RPCErrorWindow)
+	}
+
+	return false, ""
+}
+
+// firing holds the conditions raised and not resolved yet.
+type firing map[Condition]bool
+
+// update appends the alert of the condition to alerts when it started or
+// ended.
+func (f firing) update(alerts []Alert, condition Condition, raised bool, detail string) []Alert {
+	if raised == f[condition] {
+		return alerts
+	}
+	f[condition] = raised
+
+	return append(alerts, Alert{Condition: condition, Resolved: !raised, Detail: detail})
+}
+
+// countRPCErrors drops the RPC errors older than window and returns the
+// number of the remaining ones.
+func (w *Watchdog) countRPCErrors(window time.Duration, now time.Time) int {
+	i := 0
+	for i < len(w.rpcErrors) && !w.rpcErrors[i].After(now.Add(-window)) {
+		i++
+	}
+	w.rpcErrors = w.rpcErrors[i:]
+
+	return len(w.rpcErrors)
+}
@@ -0,0 +1,88 @@
+package watchdog
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	ethereumTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchdog_Check(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := New()
+	w.now = func() time.Time { return now }
+	w.lastHead = now
+
+	cfg := Config{
+		HeadTimeout:    10 * time.Minute,
+		MaxLag:         10,
+		RPCErrors:      3,
+		RPCErrorWindow: time.Minute,
+	}
+
+	w.HeadStored(&ethereumTypes.Header{Number: big.NewInt(100)}, nil)
+	w.ChainHead(105)
+	require.Empty(t, w.Check(cfg))
+
+	// the subscription stalls while the chain moves on
+	now = now.Add(11 * time.Minute)
+	w.ChainHead(120)
+	alerts := w.Check(cfg)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, ConditionNoHead, alerts[0].Condition)
+	assert.False(t, alerts[0].Resolved)
+	assert.Equal(t, "No new head for 11m0s", alerts[0].Detail)
+	assert.Equal(t, ConditionLag, alerts[1].Condition)
+	assert.Equal(t, "The processed head 100 is 20 blocks behind the chain head 120", alerts[1].Detail)
+
+	// the alerts are reported once
+	require.Empty(t, w.Check(cfg))
+
+	w.HeadReceived(&ethereumTypes.Header{Number: big.NewInt(120)})
+	w.HeadStored(&ethereumTypes.Header{Number: big.NewInt(120)}, errors.New("redis: connection refused"))
+	alerts = w.Check(cfg)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, ConditionNoHead, alerts[0].Condition)
+	assert.True(t, alerts[0].Resolved)
+	assert.Equal(t, ConditionHeadStore, alerts[1].Condition)
+	assert.Equal(t, "Failed to save the head: redis: connection refused", alerts[1].Detail)
+
+	// the lag is checked again after the stored head moved
+	w.HeadStored(&ethereumTypes.Header{Number: big.NewInt(120)}, nil)
+	alerts = w.Check(cfg)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, Alert{Condition: ConditionLag, Resolved: true, Detail: "The processed head 120 is 0 blocks behind the chain head"}, alerts[0])
+	assert.Equal(t, ConditionHeadStore, alerts[1].Condition)
+	assert.True(t, alerts[1].Resolved)
+
+	// the RPC errors are counted in the window
+	for i := 0; i < 3; i++ {
+		w.RPCFailed(errors.New("i/o timeout"))
+	}
+	alerts = w.Check(cfg)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, Alert{Condition: ConditionRPCErrors, Detail: "3 RPC errors in 1m0s, the last one: i/o timeout"}, alerts[0])
+
+	now = now.Add(2 * time.Minute)
+	alerts = w.Check(cfg)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, Alert{Condition: ConditionRPCErrors, Resolved: true, Detail: "0 RPC errors in 1m0s"}, alerts[0])
+}
+
+func TestWatchdog_CheckDisabled(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := New()
+	w.now = func() time.Time { return now }
+	w.lastHead = now
+
+	w.HeadStored(&ethereumTypes.Header{Number: big.NewInt(100)}, nil)
+	w.ChainHead(1000)
+	w.RPCFailed(errors.New("i/o timeout"))
+	now = now.Add(time.Hour)
+
+	require.Empty(t, w.Check(Config{}))
+}